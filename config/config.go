@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -12,6 +13,10 @@ type Config struct {
 	Database DatabaseConfig `yaml:"database"`
 	Redis    RedisConfig    `yaml:"redis"`
 	Server   ServerConfig   `yaml:"server"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Order    OrderConfig    `yaml:"order"`
+	Payment  PaymentConfig  `yaml:"payment"`
+	DevMode  bool           `yaml:"dev_mode"` // 开发模式，允许使用公开的开发密钥和较短的回调签名密钥，生产环境禁止开启
 }
 
 // DatabaseConfig 数据库配置
@@ -37,6 +42,38 @@ type ServerConfig struct {
 	Port int    `yaml:"port"`
 }
 
+// AuthConfig 认证配置
+type AuthConfig struct {
+	Issuer          string            `yaml:"issuer"`            // 令牌签发者（iss）
+	Audience        string            `yaml:"audience"`          // 令牌受众（aud）
+	AccessTokenTTL  time.Duration     `yaml:"access_token_ttl"`  // 访问令牌有效期，如 15m
+	RefreshTokenTTL time.Duration     `yaml:"refresh_token_ttl"` // 刷新令牌有效期，如 168h
+	ActiveKeyID     string            `yaml:"active_key_id"`     // 当前用于签名的密钥ID
+	SigningKeys     map[string]string `yaml:"signing_keys"`      // 密钥ID -> HMAC密钥，轮换期间保留旧密钥用于验签
+	BootstrapAdmins []string          `yaml:"bootstrap_admins"`  // 启动时提升为管理员的用户名
+}
+
+// OrderConfig 订单配置
+type OrderConfig struct {
+	PaymentTimeout     time.Duration `yaml:"payment_timeout"`      // 未支付订单自动取消时间，如 30m
+	ExpiryPollInterval time.Duration `yaml:"expiry_poll_interval"` // 超时订单扫描间隔，如 5s
+	FlashSaleInterval  time.Duration `yaml:"flash_sale_interval"`  // 抢购开始/结束检查和下单队列处理间隔，如 200ms
+	RaffleInterval     time.Duration `yaml:"raffle_interval"`      // 抽签开奖和中签资格过期检查间隔，如 5s
+}
+
+// PaymentConfig 支付配置
+type PaymentConfig struct {
+	DefaultProvider string            `yaml:"default_provider"` // 未指定渠道时使用的支付渠道
+	Mock            MockPaymentConfig `yaml:"mock"`             // 内置模拟支付渠道
+}
+
+// MockPaymentConfig 模拟支付渠道配置，仅用于开发和测试
+type MockPaymentConfig struct {
+	Enabled       bool   `yaml:"enabled"`        // 是否启用模拟支付渠道
+	WebhookSecret string `yaml:"webhook_secret"` // 回调签名密钥
+	CallbackURL   string `yaml:"callback_url"`   // 模拟支付完成后回调的地址，默认回调本服务
+}
+
 // GetDSN 获取数据库连接字符串
 func (c *DatabaseConfig) GetDSN() string {
 	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local",
@@ -50,23 +87,42 @@ func (c *ServerConfig) GetAddr() string {
 
 var AppConfig *Config
 
-// LoadConfig 从YAML文件加载配置
+// LoadConfig 加载配置：读取YAML文件，再用环境变量覆盖，最后填充默认值并校验
+// configPath 为空时使用 config.yaml，且该文件不存在时只使用环境变量
 func LoadConfig(configPath string) (*Config, error) {
-	if configPath == "" {
+	explicit := configPath != ""
+	if !explicit {
 		configPath = "config.yaml"
 	}
 
+	var config Config
 	data, err := os.ReadFile(configPath)
-	if err != nil {
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", configPath, err)
+		}
+	case os.IsNotExist(err) && !explicit:
+		// 未指定配置文件时允许完全通过环境变量配置
+	default:
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	if err := applyEnvOverrides(&config); err != nil {
+		return nil, err
+	}
+	config.setDefaults()
+
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
 
-	// 设置默认值
+	AppConfig = &config
+	return &config, nil
+}
+
+// setDefaults 设置默认值
+func (config *Config) setDefaults() {
 	if config.Database.Port == 0 {
 		config.Database.Port = 3306
 	}
@@ -82,7 +138,34 @@ func LoadConfig(configPath string) (*Config, error) {
 	if config.Server.Host == "" {
 		config.Server.Host = "0.0.0.0"
 	}
-
-	AppConfig = &config
-	return &config, nil
+	if config.Auth.Issuer == "" {
+		config.Auth.Issuer = "shop"
+	}
+	if config.Auth.Audience == "" {
+		config.Auth.Audience = "shop-api"
+	}
+	if config.Auth.AccessTokenTTL == 0 {
+		config.Auth.AccessTokenTTL = 15 * time.Minute
+	}
+	if config.Auth.RefreshTokenTTL == 0 {
+		config.Auth.RefreshTokenTTL = 7 * 24 * time.Hour
+	}
+	if config.Order.PaymentTimeout == 0 {
+		config.Order.PaymentTimeout = 30 * time.Minute
+	}
+	if config.Order.ExpiryPollInterval == 0 {
+		config.Order.ExpiryPollInterval = 5 * time.Second
+	}
+	if config.Order.FlashSaleInterval == 0 {
+		config.Order.FlashSaleInterval = 200 * time.Millisecond
+	}
+	if config.Order.RaffleInterval == 0 {
+		config.Order.RaffleInterval = 5 * time.Second
+	}
+	if config.Payment.DefaultProvider == "" {
+		config.Payment.DefaultProvider = "mock"
+	}
+	if config.Payment.Mock.CallbackURL == "" {
+		config.Payment.Mock.CallbackURL = fmt.Sprintf("http://127.0.0.1:%d/api/payments/webhook/mock", config.Server.Port)
+	}
 }
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix 环境变量前缀，变量名为 SHOP_{段名}_{字段名}，如 SHOP_DATABASE_PASSWORD
+// 每个变量都可以改用 {变量名}_FILE 指向一个文件，从文件读取值（用于 Docker/Kubernetes secret 挂载）
+const EnvPrefix = "SHOP"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnvOverrides 使用环境变量覆盖配置，字段名取自 yaml 标签
+func applyEnvOverrides(cfg *Config) error {
+	return applyEnv(reflect.ValueOf(cfg).Elem(), EnvPrefix)
+}
+
+// applyEnv 递归处理结构体字段
+func applyEnv(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name := prefix + "_" + strings.ToUpper(tag)
+		field := v.Field(i)
+
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, name); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, ok, err := lookupEnv(name)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if err := setField(field, value); err != nil {
+				return fmt.Errorf("invalid value for %s: %w", name, err)
+			}
+		}
+
+		// 映射字段还支持按键单独覆盖，如 SHOP_AUTH_SIGNING_KEYS_K2 或 SHOP_AUTH_SIGNING_KEYS_K2_FILE
+		if field.Kind() == reflect.Map {
+			if err := applyMapEntries(field, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// lookupEnv 读取环境变量，未设置时尝试读取 {name}_FILE 指向的文件
+func lookupEnv(name string) (string, bool, error) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true, nil
+	}
+	if path, ok := os.LookupEnv(name + "_FILE"); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to read %s_FILE: %w", name, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), true, nil
+	}
+	return "", false, nil
+}
+
+// applyMapEntries 处理 {name}_{KEY} 形式的映射单项覆盖，键名统一转为小写
+func applyMapEntries(field reflect.Value, name string) error {
+	prefix := name + "_"
+	for _, kv := range os.Environ() {
+		envName := strings.SplitN(kv, "=", 2)[0]
+		if !strings.HasPrefix(envName, prefix) || envName == name+"_FILE" {
+			continue
+		}
+		key := strings.TrimSuffix(strings.TrimPrefix(envName, prefix), "_FILE")
+		if key == "" {
+			continue
+		}
+
+		value, ok, err := lookupEnv(prefix + key)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if field.IsNil() {
+			field.Set(reflect.MakeMap(field.Type()))
+		}
+		field.SetMapIndex(reflect.ValueOf(strings.ToLower(key)), reflect.ValueOf(value))
+	}
+	return nil
+}
+
+// setField 将字符串值写入字段
+func setField(field reflect.Value, value string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		// 逗号分隔，如 SHOP_AUTH_BOOTSTRAP_ADMINS=alice,bob
+		items := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	case reflect.Map:
+		// 逗号分隔的 key=value，如 SHOP_AUTH_SIGNING_KEYS=k1=secret1,k2=secret2
+		m := reflect.MakeMap(field.Type())
+		for _, pair := range strings.Split(value, ",") {
+			parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+			if len(parts) != 2 || parts[0] == "" {
+				return fmt.Errorf("expected key=value pairs")
+			}
+			m.SetMapIndex(reflect.ValueOf(parts[0]), reflect.ValueOf(parts[1]))
+		}
+		field.Set(m)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
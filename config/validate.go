@@ -0,0 +1,81 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// minSigningKeyLength 令牌签名密钥最小长度（字节）
+const minSigningKeyLength = 32
+
+// minWebhookSecretLength 支付回调签名密钥最小长度（字节）
+const minWebhookSecretLength = 32
+
+// minDevWebhookSecretLength 开发模式下支付回调签名密钥最小长度（字节）
+// 令牌签名密钥不放宽：HS256 密钥不足 32 字节时 auth.InitAuth 会拒绝
+const minDevWebhookSecretLength = 16
+
+// knownDevSecrets 曾随仓库提交的开发密钥，任何人都能读到并伪造令牌和支付回调，只允许在开发模式下使用
+var knownDevSecrets = map[string]bool{
+	"dev-only-signing-key-please-rotate-0001": true,
+	"dev-only-mock-webhook-secret-0001":       true,
+}
+
+// Validate 校验配置，一次性返回所有错误
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.Database.Host != "", "database.host is required (SHOP_DATABASE_HOST)")
+	check(c.Database.Port > 0 && c.Database.Port <= 65535, "database.port must be between 1 and 65535, got %d", c.Database.Port)
+	check(c.Database.User != "", "database.user is required (SHOP_DATABASE_USER)")
+	check(c.Database.Database != "", "database.database is required (SHOP_DATABASE_DATABASE)")
+
+	_, _, err := net.SplitHostPort(c.Redis.Addr)
+	check(err == nil, "redis.addr must be host:port, got %q", c.Redis.Addr)
+	check(c.Redis.DB >= 0, "redis.db must not be negative, got %d", c.Redis.DB)
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port must be between 1 and 65535, got %d", c.Server.Port)
+
+	check(c.Auth.AccessTokenTTL > 0, "auth.access_token_ttl must be positive")
+	check(c.Auth.RefreshTokenTTL > c.Auth.AccessTokenTTL, "auth.refresh_token_ttl must be longer than auth.access_token_ttl")
+	check(len(c.Auth.SigningKeys) > 0, "auth.signing_keys must contain at least one key (SHOP_AUTH_SIGNING_KEYS_{KID} or SHOP_AUTH_SIGNING_KEYS_{KID}_FILE)")
+	if len(c.Auth.SigningKeys) > 0 {
+		_, ok := c.Auth.SigningKeys[c.Auth.ActiveKeyID]
+		check(ok, "auth.active_key_id %q is not present in auth.signing_keys", c.Auth.ActiveKeyID)
+	}
+	for kid, secret := range c.Auth.SigningKeys {
+		check(len(secret) >= minSigningKeyLength, "auth.signing_keys.%s must be at least %d bytes", kid, minSigningKeyLength)
+		check(c.DevMode || !knownDevSecrets[secret],
+			"auth.signing_keys.%s is a published development key, set a new key with SHOP_AUTH_SIGNING_KEYS_%s or SHOP_AUTH_SIGNING_KEYS_%s_FILE (or enable dev_mode)",
+			kid, strings.ToUpper(kid), strings.ToUpper(kid))
+	}
+
+	check(c.Order.PaymentTimeout >= time.Minute, "order.payment_timeout must be at least 1m")
+	check(c.Order.ExpiryPollInterval > 0, "order.expiry_poll_interval must be positive")
+	check(c.Order.FlashSaleInterval > 0, "order.flash_sale_interval must be positive")
+	check(c.Order.RaffleInterval > 0, "order.raffle_interval must be positive")
+
+	if c.Payment.Mock.Enabled {
+		minLength := minWebhookSecretLength
+		if c.DevMode {
+			minLength = minDevWebhookSecretLength
+		}
+		check(len(c.Payment.Mock.WebhookSecret) >= minLength,
+			"payment.mock.webhook_secret must be at least %d bytes (SHOP_PAYMENT_MOCK_WEBHOOK_SECRET or SHOP_PAYMENT_MOCK_WEBHOOK_SECRET_FILE)", minLength)
+		check(c.DevMode || !knownDevSecrets[c.Payment.Mock.WebhookSecret],
+			"payment.mock.webhook_secret is a published development key, set a new secret with SHOP_PAYMENT_MOCK_WEBHOOK_SECRET or SHOP_PAYMENT_MOCK_WEBHOOK_SECRET_FILE (or enable dev_mode)")
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New("invalid config:\n  - " + strings.Join(problems, "\n  - "))
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// validConfig 返回可以通过校验的配置
+func validConfig() *Config {
+	c := &Config{
+		Database: DatabaseConfig{Host: "127.0.0.1", User: "shop", Database: "shop"},
+		Auth: AuthConfig{
+			ActiveKeyID: "k1",
+			SigningKeys: map[string]string{"k1": strings.Repeat("a", minSigningKeyLength)},
+		},
+		Payment: PaymentConfig{Mock: MockPaymentConfig{
+			Enabled:       true,
+			WebhookSecret: strings.Repeat("b", minWebhookSecretLength),
+		}},
+	}
+	c.setDefaults()
+	return c
+}
+
+func TestValidateSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			modify: func(c *Config) {},
+		},
+		{
+			name:    "published signing key",
+			modify:  func(c *Config) { c.Auth.SigningKeys["k1"] = "dev-only-signing-key-please-rotate-0001" },
+			wantErr: "auth.signing_keys.k1 is a published development key",
+		},
+		{
+			name:    "short signing key",
+			modify:  func(c *Config) { c.Auth.SigningKeys["k1"] = strings.Repeat("a", minSigningKeyLength-1) },
+			wantErr: "auth.signing_keys.k1 must be at least 32 bytes",
+		},
+		{
+			name:    "missing signing key",
+			modify:  func(c *Config) { c.Auth.SigningKeys = nil },
+			wantErr: "auth.signing_keys must contain at least one key",
+		},
+		{
+			name:    "published webhook secret",
+			modify:  func(c *Config) { c.Payment.Mock.WebhookSecret = "dev-only-mock-webhook-secret-0001" },
+			wantErr: "payment.mock.webhook_secret is a published development key",
+		},
+		{
+			name:    "short webhook secret",
+			modify:  func(c *Config) { c.Payment.Mock.WebhookSecret = strings.Repeat("b", minDevWebhookSecretLength) },
+			wantErr: "payment.mock.webhook_secret must be at least 32 bytes",
+		},
+		{
+			name:   "short webhook secret with mock disabled",
+			modify: func(c *Config) { c.Payment.Mock = MockPaymentConfig{} },
+		},
+		{
+			name: "dev mode accepts published keys",
+			modify: func(c *Config) {
+				c.DevMode = true
+				c.Auth.SigningKeys["k1"] = "dev-only-signing-key-please-rotate-0001"
+				c.Payment.Mock.WebhookSecret = "dev-only-mock-webhook-secret-0001"
+			},
+		},
+		{
+			name: "dev mode accepts 16 byte webhook secret",
+			modify: func(c *Config) {
+				c.DevMode = true
+				c.Payment.Mock.WebhookSecret = strings.Repeat("b", minDevWebhookSecretLength)
+			},
+		},
+		{
+			name: "dev mode still requires 32 byte signing keys",
+			modify: func(c *Config) {
+				c.DevMode = true
+				c.Auth.SigningKeys["k1"] = strings.Repeat("a", minSigningKeyLength-1)
+			},
+			wantErr: "auth.signing_keys.k1 must be at least 32 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig()
+			tt.modify(c)
+			err := c.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDevModeFromEnv(t *testing.T) {
+	t.Setenv("SHOP_DEV_MODE", "true")
+	var c Config
+	if err := applyEnvOverrides(&c); err != nil {
+		t.Fatal(err)
+	}
+	if !c.DevMode {
+		t.Fatal("SHOP_DEV_MODE=true did not enable dev_mode")
+	}
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"shop/logic"
+	"shop/model"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// adminBlindBoxErrorStatus 根据错误类型返回状态码
+func adminBlindBoxErrorStatus(err error) int {
+	switch err.Error() {
+	case "盲盒系列不存在", "款式不存在":
+		return 404
+	}
+	return adminProductErrorStatus(err)
+}
+
+// AdminGetBlindBoxSeriesList 获取全部盲盒系列
+func AdminGetBlindBoxSeriesList(ctx context.Context, c *app.RequestContext) {
+	list, err := logic.AdminGetBlindBoxSeriesList()
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询盲盒系列失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"series": list,
+	})
+}
+
+// AdminGetBlindBoxSeries 获取盲盒系列详情（包含款式库存和权重）
+func AdminGetBlindBoxSeries(ctx context.Context, c *app.RequestContext) {
+	seriesID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的系列ID",
+		})
+		return
+	}
+
+	series, err := logic.AdminGetBlindBoxSeries(seriesID)
+	if err != nil {
+		c.JSON(adminBlindBoxErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, series)
+}
+
+// AdminCreateBlindBoxSeries 将商品设置为盲盒
+func AdminCreateBlindBoxSeries(ctx context.Context, c *app.RequestContext) {
+	var req model.CreateBlindBoxSeriesRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	series, err := logic.CreateBlindBoxSeries(&req)
+	if err != nil {
+		c.JSON(adminBlindBoxErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, series)
+}
+
+// AdminAddBlindBoxFigure 为盲盒系列添加款式
+func AdminAddBlindBoxFigure(ctx context.Context, c *app.RequestContext) {
+	operatorID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	seriesID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的系列ID",
+		})
+		return
+	}
+
+	var req model.CreateBlindBoxFigureRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	figure, err := logic.AddBlindBoxFigure(operatorID.(int), seriesID, &req)
+	if err != nil {
+		c.JSON(adminBlindBoxErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, figure)
+}
+
+// AdminUpdateBlindBoxFigure 更新款式信息和权重
+func AdminUpdateBlindBoxFigure(ctx context.Context, c *app.RequestContext) {
+	figureID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的款式ID",
+		})
+		return
+	}
+
+	var req model.UpdateBlindBoxFigureRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	figure, err := logic.UpdateBlindBoxFigure(figureID, &req)
+	if err != nil {
+		c.JSON(adminBlindBoxErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, figure)
+}
+
+// AdminAdjustBlindBoxFigureStock 调整款式库存
+func AdminAdjustBlindBoxFigureStock(ctx context.Context, c *app.RequestContext) {
+	operatorID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	figureID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的款式ID",
+		})
+		return
+	}
+
+	var req model.AdjustStockRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	adjustment, err := logic.AdjustBlindBoxFigureStock(operatorID.(int), figureID, &req)
+	if err != nil {
+		c.JSON(adminBlindBoxErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, adjustment)
+}
+
+// AdminDrawOrder 为订单中未抽取的盲盒重新抽取
+func AdminDrawOrder(ctx context.Context, c *app.RequestContext) {
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的订单ID",
+		})
+		return
+	}
+
+	order, undrawn, err := logic.AdminDrawOrder(orderID)
+	if err != nil {
+		c.JSON(orderErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"order":   order,
+		"undrawn": undrawn,
+	})
+}
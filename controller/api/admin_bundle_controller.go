@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"shop/logic"
+	"shop/model"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// AdminGetBundles 获取全部套装（包含已下架）
+func AdminGetBundles(ctx context.Context, c *app.RequestContext) {
+	bundles, err := logic.AdminGetBundles(c.Query("series"))
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询套装失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"bundles": bundles,
+	})
+}
+
+// AdminCreateBundle 创建套装
+func AdminCreateBundle(ctx context.Context, c *app.RequestContext) {
+	var req model.CreateBundleRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	bundle, err := logic.CreateBundle(&req)
+	if err != nil {
+		c.JSON(bundleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, bundle)
+}
+
+// AdminUpdateBundle 更新套装
+func AdminUpdateBundle(ctx context.Context, c *app.RequestContext) {
+	bundleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的套装ID",
+		})
+		return
+	}
+
+	var req model.UpdateBundleRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	bundle, err := logic.UpdateBundle(bundleID, &req)
+	if err != nil {
+		c.JSON(bundleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, bundle)
+}
+
+// AdminDeleteBundle 下架套装（保留记录，历史订单仍可关联）
+func AdminDeleteBundle(ctx context.Context, c *app.RequestContext) {
+	bundleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的套装ID",
+		})
+		return
+	}
+
+	if err := logic.DeactivateBundle(bundleID); err != nil {
+		c.JSON(bundleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "套装已下架",
+	})
+}
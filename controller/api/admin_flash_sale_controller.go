@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"shop/logic"
+	"shop/model"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// AdminGetFlashSales 获取全部抢购和排队中的请求数
+func AdminGetFlashSales(ctx context.Context, c *app.RequestContext) {
+	sales, err := logic.AdminGetFlashSales()
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询抢购失败: " + err.Error(),
+		})
+		return
+	}
+	queueLength, err := logic.GetFlashSaleQueueLength()
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询抢购队列失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"flash_sales":  sales,
+		"queue_length": queueLength,
+	})
+}
+
+// AdminCreateFlashSale 创建限时抢购
+func AdminCreateFlashSale(ctx context.Context, c *app.RequestContext) {
+	var req model.CreateFlashSaleRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	sale, err := logic.CreateFlashSale(&req)
+	if err != nil {
+		c.JSON(flashSaleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, sale)
+}
+
+// AdminCancelFlashSale 取消未开始或进行中的抢购
+func AdminCancelFlashSale(ctx context.Context, c *app.RequestContext) {
+	saleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的抢购ID",
+		})
+		return
+	}
+
+	sale, err := logic.CancelFlashSale(saleID)
+	if err != nil {
+		c.JSON(flashSaleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, sale)
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"shop/logic"
+	"shop/model"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// AdminGetOrders 分页获取订单列表，可按状态过滤
+func AdminGetOrders(ctx context.Context, c *app.RequestContext) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	orders, total, err := logic.AdminGetOrders(c.Query("status"), page, pageSize)
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询订单失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"orders": orders,
+		"total":  total,
+	})
+}
+
+// AdminGetOrder 获取订单详情
+func AdminGetOrder(ctx context.Context, c *app.RequestContext) {
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的订单ID",
+		})
+		return
+	}
+
+	order, err := logic.AdminGetOrder(orderID)
+	if err != nil {
+		c.JSON(orderErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, order)
+}
+
+// AdminShipOrder 发货
+func AdminShipOrder(ctx context.Context, c *app.RequestContext) {
+	operatorID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的订单ID",
+		})
+		return
+	}
+
+	var req model.ShipOrderRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	order, err := logic.ShipOrder(operatorID.(int), orderID, &req)
+	if err != nil {
+		c.JSON(orderErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "已发货",
+		"status":  order.Status,
+	})
+}
+
+// AdminDeliverOrder 标记订单已送达
+func AdminDeliverOrder(ctx context.Context, c *app.RequestContext) {
+	operatorID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的订单ID",
+		})
+		return
+	}
+
+	order, err := logic.DeliverOrder(operatorID.(int), orderID)
+	if err != nil {
+		c.JSON(orderErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "已标记送达",
+		"status":  order.Status,
+	})
+}
+
+// AdminRefundOrder 退款
+func AdminRefundOrder(ctx context.Context, c *app.RequestContext) {
+	operatorID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的订单ID",
+		})
+		return
+	}
+
+	var req model.RefundOrderRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	order, err := logic.RefundOrder(operatorID.(int), orderID, &req)
+	if err != nil {
+		c.JSON(orderErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "已退款",
+		"status":  order.Status,
+	})
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"shop/logic"
+	"shop/model"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// adminProductErrorStatus 根据错误类型返回状态码
+func adminProductErrorStatus(err error) int {
+	var validationErr *logic.ValidationError
+	if errors.As(err, &validationErr) {
+		return 400
+	}
+	if err.Error() == "商品不存在" {
+		return 404
+	}
+	return 500
+}
+
+// AdminGetProducts 获取商品列表（可通过 include_deleted=true 包含已删除商品）
+func AdminGetProducts(ctx context.Context, c *app.RequestContext) {
+	includeDeleted := c.Query("include_deleted") == "true"
+
+	products, err := logic.AdminGetProducts(includeDeleted)
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询商品失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"products": products,
+	})
+}
+
+// AdminCreateProduct 创建商品
+func AdminCreateProduct(ctx context.Context, c *app.RequestContext) {
+	var req model.CreateProductRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	product, err := logic.CreateProduct(&req)
+	if err != nil {
+		c.JSON(adminProductErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, product)
+}
+
+// AdminUpdateProduct 更新商品信息
+func AdminUpdateProduct(ctx context.Context, c *app.RequestContext) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的商品ID",
+		})
+		return
+	}
+
+	var req model.UpdateProductRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	product, err := logic.UpdateProduct(productID, &req)
+	if err != nil {
+		c.JSON(adminProductErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, product)
+}
+
+// AdminDeleteProduct 软删除商品
+func AdminDeleteProduct(ctx context.Context, c *app.RequestContext) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的商品ID",
+		})
+		return
+	}
+
+	if err := logic.DeleteProduct(productID); err != nil {
+		c.JSON(adminProductErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "商品已下架",
+	})
+}
+
+// AdminAdjustStock 调整商品库存
+func AdminAdjustStock(ctx context.Context, c *app.RequestContext) {
+	operatorID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的商品ID",
+		})
+		return
+	}
+
+	var req model.AdjustStockRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	adjustment, err := logic.AdjustStock(operatorID.(int), productID, &req)
+	if err != nil {
+		c.JSON(adminProductErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, adjustment)
+}
+
+// AdminGetStockAdjustments 获取商品的库存调整记录
+func AdminGetStockAdjustments(ctx context.Context, c *app.RequestContext) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的商品ID",
+		})
+		return
+	}
+
+	adjustments, err := logic.GetStockAdjustments(productID)
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询库存记录失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"adjustments": adjustments,
+	})
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"shop/logic"
+	"shop/model"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// AdminGetPromotions 获取全部促销
+func AdminGetPromotions(ctx context.Context, c *app.RequestContext) {
+	promotions, err := logic.AdminGetPromotions()
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询促销失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"promotions": promotions,
+	})
+}
+
+// AdminGetPromotion 获取促销详情
+func AdminGetPromotion(ctx context.Context, c *app.RequestContext) {
+	promotionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的促销ID",
+		})
+		return
+	}
+
+	promotion, err := logic.AdminGetPromotion(promotionID)
+	if err != nil {
+		c.JSON(promotionErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, promotion)
+}
+
+// AdminCreatePromotion 创建促销
+func AdminCreatePromotion(ctx context.Context, c *app.RequestContext) {
+	var req model.CreatePromotionRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	promotion, err := logic.CreatePromotion(&req)
+	if err != nil {
+		c.JSON(promotionErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, promotion)
+}
+
+// AdminUpdatePromotion 更新促销
+func AdminUpdatePromotion(ctx context.Context, c *app.RequestContext) {
+	promotionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的促销ID",
+		})
+		return
+	}
+
+	var req model.UpdatePromotionRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	promotion, err := logic.UpdatePromotion(promotionID, &req)
+	if err != nil {
+		c.JSON(promotionErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, promotion)
+}
+
+// AdminGetCoupons 获取优惠码列表，可按 promotion_id 过滤
+func AdminGetCoupons(ctx context.Context, c *app.RequestContext) {
+	promotionID, _ := strconv.Atoi(c.Query("promotion_id"))
+
+	coupons, err := logic.AdminGetCoupons(promotionID)
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询优惠码失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"coupons": coupons,
+	})
+}
+
+// AdminCreateCoupon 创建优惠码
+func AdminCreateCoupon(ctx context.Context, c *app.RequestContext) {
+	var req model.CreateCouponRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	coupon, err := logic.CreateCoupon(&req)
+	if err != nil {
+		c.JSON(promotionErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, coupon)
+}
+
+// AdminUpdateCoupon 更新优惠码
+func AdminUpdateCoupon(ctx context.Context, c *app.RequestContext) {
+	couponID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的优惠码ID",
+		})
+		return
+	}
+
+	var req model.UpdateCouponRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	coupon, err := logic.UpdateCoupon(couponID, &req)
+	if err != nil {
+		c.JSON(promotionErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, coupon)
+}
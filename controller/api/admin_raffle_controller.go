@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"shop/logic"
+	"shop/model"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// AdminGetRaffles 获取全部抽签
+func AdminGetRaffles(ctx context.Context, c *app.RequestContext) {
+	raffles, err := logic.AdminGetRaffles()
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询抽签失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"raffles": raffles,
+	})
+}
+
+// AdminGetRaffle 获取抽签详情
+func AdminGetRaffle(ctx context.Context, c *app.RequestContext) {
+	raffleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的抽签ID",
+		})
+		return
+	}
+
+	raffle, err := logic.GetRaffle(raffleID)
+	if err != nil {
+		c.JSON(raffleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, raffle)
+}
+
+// AdminCreateRaffle 创建抽签
+func AdminCreateRaffle(ctx context.Context, c *app.RequestContext) {
+	var req model.CreateRaffleRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	raffle, err := logic.CreateRaffle(&req)
+	if err != nil {
+		c.JSON(raffleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, raffle)
+}
+
+// AdminDrawRaffle 报名截止后立即开奖（无需等待后台任务）
+func AdminDrawRaffle(ctx context.Context, c *app.RequestContext) {
+	raffleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的抽签ID",
+		})
+		return
+	}
+
+	raffle, err := logic.DrawRaffle(raffleID)
+	if err != nil {
+		c.JSON(raffleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, raffle)
+}
+
+// AdminCancelRaffle 取消尚未开奖的抽签
+func AdminCancelRaffle(ctx context.Context, c *app.RequestContext) {
+	raffleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的抽签ID",
+		})
+		return
+	}
+
+	raffle, err := logic.CancelRaffle(raffleID)
+	if err != nil {
+		c.JSON(raffleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, raffle)
+}
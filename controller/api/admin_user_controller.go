@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"shop/logic"
+	"shop/model"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// AdminGetUsers 分页获取用户列表
+func AdminGetUsers(ctx context.Context, c *app.RequestContext) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	users, total, err := logic.GetUsers(page, pageSize)
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询用户失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"users": users,
+		"total": total,
+	})
+}
+
+// AdminUpdateUserRole 修改用户角色（仅管理员）
+func AdminUpdateUserRole(ctx context.Context, c *app.RequestContext) {
+	operatorID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的用户ID",
+		})
+		return
+	}
+
+	var req model.UpdateUserRoleRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	err = logic.UpdateUserRole(operatorID.(int), userID, &req)
+	if err != nil {
+		statusCode := 500
+		if err.Error() == "无效的角色" || err.Error() == "不能修改自己的角色" {
+			statusCode = 400
+		} else if err.Error() == "用户不存在" {
+			statusCode = 404
+		}
+		c.JSON(statusCode, utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "角色已更新",
+	})
+}
+
+// AdminRevokeUserSessions 注销指定用户在所有设备上的会话（账号被盗时由客服操作）
+func AdminRevokeUserSessions(ctx context.Context, c *app.RequestContext) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的用户ID",
+		})
+		return
+	}
+
+	if err := logic.RevokeAllSessions(userID); err != nil {
+		c.JSON(500, utils.H{
+			"error": "注销失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "该用户所有设备已退出登录",
+	})
+}
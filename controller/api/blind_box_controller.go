@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"shop/logic"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// GetBlindBox 获取盲盒商品的款式和抽中概率
+func GetBlindBox(ctx context.Context, c *app.RequestContext) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的商品ID",
+		})
+		return
+	}
+
+	series, err := logic.GetBlindBoxSeries(productID)
+	if err != nil {
+		statusCode := 500
+		if err.Error() == "盲盒系列不存在" {
+			statusCode = 404
+		}
+		c.JSON(statusCode, utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, series)
+}
+
+// GetMyDraws 获取当前用户的盲盒抽取记录
+func GetMyDraws(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	draws, total, err := logic.GetMyDraws(userID.(int), page, pageSize)
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询抽取记录失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"draws": draws,
+		"total": total,
+	})
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"shop/logic"
+	"shop/model"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// bundleErrorStatus 根据套装操作错误返回状态码
+func bundleErrorStatus(err error) int {
+	var validationErr *logic.ValidationError
+	if errors.As(err, &validationErr) {
+		return 400
+	}
+	switch err.Error() {
+	case "套装不存在", "购物车项不存在":
+		return 404
+	}
+	return 500
+}
+
+// GetBundles 获取上架中的套装列表
+func GetBundles(ctx context.Context, c *app.RequestContext) {
+	bundles, err := logic.GetBundles(c.Query("series"))
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询套装失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"bundles": bundles,
+	})
+}
+
+// GetBundle 获取套装详情
+func GetBundle(ctx context.Context, c *app.RequestContext) {
+	bundleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的套装ID",
+		})
+		return
+	}
+
+	bundle, err := logic.GetBundle(bundleID)
+	if err != nil {
+		c.JSON(bundleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, bundle)
+}
+
+// AddBundleToCart 添加套装到购物车
+func AddBundleToCart(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	var req model.AddBundleToCartRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if err := logic.AddBundleToCart(userID.(int), &req); err != nil {
+		c.JSON(bundleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "添加到购物车成功",
+	})
+}
+
+// UpdateCartBundle 更新购物车中套装的数量
+func UpdateCartBundle(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	bundleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的套装ID",
+		})
+		return
+	}
+
+	var req model.UpdateCartRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	if err := logic.UpdateCartBundle(userID.(int), bundleID, &req); err != nil {
+		c.JSON(bundleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "更新成功",
+	})
+}
+
+// DeleteCartBundle 从购物车删除套装
+func DeleteCartBundle(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	bundleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的套装ID",
+		})
+		return
+	}
+
+	if err := logic.DeleteCartBundle(userID.(int), bundleID); err != nil {
+		c.JSON(bundleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "删除成功",
+	})
+}
@@ -29,11 +29,42 @@ func GetCart(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
+	bundles, err := logic.GetCartBundles(userID.(int))
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询购物车失败: " + err.Error(),
+		})
+		return
+	}
+
 	c.JSON(200, utils.H{
-		"items": items,
+		"items":   items,
+		"bundles": bundles,
 	})
 }
 
+// GetCartSummary 获取购物车结算预览（总价、优惠、运费和各行的库存与价格提示）
+func GetCartSummary(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	// 可选的 coupon 参数用于预览优惠码，不可用时在 coupon_error 中说明
+	summary, err := logic.GetCartSummary(userID.(int), c.Query("coupon"))
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询购物车失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, summary)
+}
+
 // AddToCart 添加到购物车
 func AddToCart(ctx context.Context, c *app.RequestContext) {
 	userID, exists := c.Get("user_id")
@@ -54,8 +85,12 @@ func AddToCart(ctx context.Context, c *app.RequestContext) {
 
 	err := logic.AddToCart(userID.(int), &req)
 	if err != nil {
+		if body, ok := purchaseLimitResponse(err); ok {
+			c.JSON(400, body)
+			return
+		}
 		statusCode := 500
-		if err.Error() == "库存不足" {
+		if err.Error() == "库存不足" || err.Error() == "商品不存在" {
 			statusCode = 400
 		}
 		c.JSON(statusCode, utils.H{
@@ -98,6 +133,10 @@ func UpdateCartItem(ctx context.Context, c *app.RequestContext) {
 
 	err = logic.UpdateCartItem(userID.(int), productID, &req)
 	if err != nil {
+		if body, ok := purchaseLimitResponse(err); ok {
+			c.JSON(400, body)
+			return
+		}
 		statusCode := 500
 		if err.Error() == "库存不足" || err.Error() == "购物车项不存在" {
 			statusCode = 400
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"strconv"
+	"strings"
 
 	"shop/logic"
 	"shop/model"
@@ -53,8 +54,12 @@ func IncrementCartItem(ctx context.Context, c *app.RequestContext) {
 
 	err = logic.IncrementCartItem(userID.(int), productID, req.Delta)
 	if err != nil {
+		if body, ok := purchaseLimitResponse(err); ok {
+			c.JSON(400, body)
+			return
+		}
 		statusCode := 500
-		if err.Error() == "库存不足" || err.Error() == "购物车项不存在" {
+		if err.Error() == "购物车项不存在" || err.Error() == "商品不存在" || strings.HasPrefix(err.Error(), "库存不足") {
 			statusCode = 400
 		}
 		c.JSON(statusCode, utils.H{
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"shop/logic"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// GetCollection 获取各系列的收藏进度
+func GetCollection(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	series, err := logic.GetCollection(userID.(int))
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询收藏失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"series": series,
+	})
+}
+
+// GetCollectionSeries 获取某个系列已拥有和缺少的商品
+func GetCollectionSeries(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	collection, err := logic.GetCollectionSeries(userID.(int), c.Param("series"))
+	if err != nil {
+		statusCode := 500
+		var validationErr *logic.ValidationError
+		if errors.As(err, &validationErr) {
+			statusCode = 400
+		} else if err.Error() == "系列不存在" {
+			statusCode = 404
+		}
+		c.JSON(statusCode, utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, collection)
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"shop/dao"
+	"shop/logic"
+	"shop/model"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// flashSaleErrorStatus 根据抢购操作错误返回状态码
+func flashSaleErrorStatus(err error) int {
+	var validationErr *logic.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return 400
+	case errors.Is(err, logic.ErrFlashSaleNotFound), err.Error() == "票据不存在", err.Error() == "商品不存在":
+		return 404
+	case errors.Is(err, dao.ErrFlashSaleNotActive), errors.Is(err, dao.ErrFlashSaleSoldOut), errors.Is(err, dao.ErrFlashSaleLimitExceeded):
+		return 409
+	}
+	return 500
+}
+
+// GetFlashSales 获取未开始和进行中的抢购
+func GetFlashSales(ctx context.Context, c *app.RequestContext) {
+	sales, err := logic.GetFlashSales()
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询抢购失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"flash_sales": sales,
+	})
+}
+
+// GetFlashSale 获取抢购详情（进行中时包含剩余库存）
+func GetFlashSale(ctx context.Context, c *app.RequestContext) {
+	saleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的抢购ID",
+		})
+		return
+	}
+
+	sale, err := logic.GetFlashSale(saleID)
+	if err != nil {
+		c.JSON(flashSaleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, sale)
+}
+
+// PurchaseFlashSale 参与抢购，成功时返回票据，订单异步创建
+func PurchaseFlashSale(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	saleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的抢购ID",
+		})
+		return
+	}
+
+	var req model.FlashSalePurchaseRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	ticket, err := logic.PurchaseFlashSale(userID.(int), saleID, &req)
+	if err != nil {
+		c.JSON(flashSaleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(202, ticket)
+}
+
+// GetFlashSaleTicket 轮询抢购票据的下单结果
+func GetFlashSaleTicket(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	ticket, err := logic.GetFlashSaleTicket(userID.(int), c.Param("ticket"))
+	if err != nil {
+		c.JSON(flashSaleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, ticket)
+}
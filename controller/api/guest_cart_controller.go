@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"shop/logic"
+	"shop/model"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// GetGuestCart 获取游客购物车
+func GetGuestCart(ctx context.Context, c *app.RequestContext) {
+	items, err := logic.GetGuestCart(c.GetString("guest_cart_id"))
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询购物车失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"items":   items,
+		"bundles": []model.CartBundle{},
+	})
+}
+
+// AddToGuestCart 添加商品到游客购物车
+func AddToGuestCart(ctx context.Context, c *app.RequestContext) {
+	var req model.AddToCartRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+	if req.Quantity < 1 {
+		c.JSON(400, utils.H{
+			"error": "数量至少为1",
+		})
+		return
+	}
+
+	if err := logic.AddToGuestCart(c.GetString("guest_cart_id"), &req); err != nil {
+		statusCode := 500
+		if err.Error() == "库存不足" || err.Error() == "商品不存在" {
+			statusCode = 400
+		}
+		c.JSON(statusCode, utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "添加到购物车成功",
+	})
+}
+
+// UpdateGuestCartItem 更新游客购物车商品数量
+func UpdateGuestCartItem(ctx context.Context, c *app.RequestContext) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的商品ID",
+		})
+		return
+	}
+
+	var req model.UpdateCartRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+	if req.Quantity < 1 {
+		c.JSON(400, utils.H{
+			"error": "数量至少为1",
+		})
+		return
+	}
+
+	if err := logic.UpdateGuestCartItem(c.GetString("guest_cart_id"), productID, &req); err != nil {
+		statusCode := 500
+		if err.Error() == "库存不足" || err.Error() == "购物车项不存在" {
+			statusCode = 400
+		}
+		c.JSON(statusCode, utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "更新成功",
+	})
+}
+
+// IncrementGuestCartItem 增量更新游客购物车商品数量
+func IncrementGuestCartItem(ctx context.Context, c *app.RequestContext) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的商品ID",
+		})
+		return
+	}
+
+	var req model.IncrementCartRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+	if req.Delta == 0 {
+		c.JSON(400, utils.H{
+			"error": "增量值不能为 0",
+		})
+		return
+	}
+	if req.Delta > 100 || req.Delta < -100 {
+		c.JSON(400, utils.H{
+			"error": "增量值超出范围（-100 到 100）",
+		})
+		return
+	}
+
+	if err := logic.IncrementGuestCartItem(c.GetString("guest_cart_id"), productID, req.Delta); err != nil {
+		statusCode := 500
+		if err.Error() == "购物车项不存在" || err.Error() == "商品不存在" || strings.HasPrefix(err.Error(), "库存不足") {
+			statusCode = 400
+		}
+		c.JSON(statusCode, utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "更新成功",
+	})
+}
+
+// DeleteGuestCartItem 从游客购物车删除商品
+func DeleteGuestCartItem(ctx context.Context, c *app.RequestContext) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的商品ID",
+		})
+		return
+	}
+
+	if err := logic.DeleteGuestCartItem(c.GetString("guest_cart_id"), productID); err != nil {
+		statusCode := 500
+		if err.Error() == "购物车项不存在" {
+			statusCode = 404
+		}
+		c.JSON(statusCode, utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "删除成功",
+	})
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"shop/logic"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// GetInventory 获取我的库存物品（已完成订单中的商品按件列出）
+func GetInventory(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	items, err := logic.GetInventory(userID.(int))
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询库存失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"items": items,
+	})
+}
+
+// ListInventoryItem 挂出物品供其他用户发起交换
+func ListInventoryItem(ctx context.Context, c *app.RequestContext) {
+	setInventoryItemListed(c, true)
+}
+
+// UnlistInventoryItem 撤下挂出的物品
+func UnlistInventoryItem(ctx context.Context, c *app.RequestContext) {
+	setInventoryItemListed(c, false)
+}
+
+// setInventoryItemListed 挂出或撤下物品
+func setInventoryItemListed(c *app.RequestContext, listed bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	itemID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的物品ID",
+		})
+		return
+	}
+
+	item, err := logic.SetItemListed(userID.(int), itemID, listed)
+	if err != nil {
+		c.JSON(tradeErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, item)
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"errors"
 	"strconv"
 
 	"shop/logic"
@@ -29,10 +30,28 @@ func CreateOrder(ctx context.Context, c *app.RequestContext) {
 
 	orderID, totalPrice, err := logic.CreateOrder(userID.(int), &req)
 	if err != nil {
+		var outOfStock *logic.OutOfStockError
+		if errors.As(err, &outOfStock) {
+			c.JSON(400, utils.H{
+				"error":      err.Error(),
+				"code":       "out_of_stock",
+				"product_id": outOfStock.ProductID,
+				"requested":  outOfStock.Requested,
+				"available":  outOfStock.Available,
+			})
+			return
+		}
+		if body, ok := purchaseLimitResponse(err); ok {
+			c.JSON(400, body)
+			return
+		}
+
+		var validationErr *logic.ValidationError
 		statusCode := 500
 		if err.Error() == "购物车项不存在" {
 			statusCode = 404
-		} else if err.Error() == "商品库存不足" || err.Error() == "库存不足" {
+		} else if err.Error() == "购物车为空" || err.Error() == "指定的商品不在购物车中" ||
+			errors.Is(err, logic.ErrCouponNotFound) || errors.As(err, &validationErr) {
 			statusCode = 400
 		}
 		c.JSON(statusCode, utils.H{
@@ -48,6 +67,27 @@ func CreateOrder(ctx context.Context, c *app.RequestContext) {
 	})
 }
 
+// purchaseLimitResponse 超出限购时返回包含限购类型的响应体
+func purchaseLimitResponse(err error) (utils.H, bool) {
+	var limitErr *logic.PurchaseLimitError
+	if !errors.As(err, &limitErr) {
+		return nil, false
+	}
+	body := utils.H{
+		"error":      err.Error(),
+		"code":       "purchase_limit",
+		"limit":      limitErr.Limit,
+		"product_id": limitErr.ProductID,
+		"max":        limitErr.Max,
+		"used":       limitErr.Used,
+		"requested":  limitErr.Requested,
+	}
+	if limitErr.RetryAfter != nil {
+		body["retry_after"] = limitErr.RetryAfter
+	}
+	return body, true
+}
+
 // GetOrders 获取订单历史
 func GetOrders(ctx context.Context, c *app.RequestContext) {
 	userID, exists := c.Get("user_id")
@@ -92,7 +132,7 @@ func GetOrder(ctx context.Context, c *app.RequestContext) {
 	order, err := logic.GetOrder(userID.(int), orderID)
 	if err != nil {
 		statusCode := 500
-		if err.Error() == "订单不存在" {
+		if errors.Is(err, logic.ErrOrderNotFound) {
 			statusCode = 404
 		}
 		c.JSON(statusCode, utils.H{
@@ -103,3 +143,86 @@ func GetOrder(ctx context.Context, c *app.RequestContext) {
 
 	c.JSON(200, order)
 }
+
+// orderErrorStatus 根据订单操作错误返回状态码
+func orderErrorStatus(err error) int {
+	var validationErr *logic.ValidationError
+	var transitionErr *logic.InvalidTransitionError
+	switch {
+	case errors.As(err, &validationErr):
+		return 400
+	case errors.As(err, &transitionErr):
+		return 409
+	case errors.Is(err, logic.ErrOrderNotFound):
+		return 404
+	}
+	return 500
+}
+
+// CancelOrder 取消未支付的订单
+func CancelOrder(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的订单ID",
+		})
+		return
+	}
+
+	// 取消原因可选，允许空请求体
+	var req model.CancelOrderRequest
+	_ = c.BindAndValidate(&req)
+
+	order, err := logic.CancelOrder(userID.(int), orderID, &req)
+	if err != nil {
+		c.JSON(orderErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "订单已取消",
+		"status":  order.Status,
+	})
+}
+
+// ConfirmReceipt 确认收货
+func ConfirmReceipt(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的订单ID",
+		})
+		return
+	}
+
+	order, err := logic.ConfirmReceipt(userID.(int), orderID)
+	if err != nil {
+		c.JSON(orderErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "已确认收货",
+		"status":  order.Status,
+	})
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"shop/global/payment"
+	"shop/logic"
+	"shop/model"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// paymentErrorStatus 根据支付操作错误返回状态码
+func paymentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, payment.ErrInvalidSignature), errors.Is(err, payment.ErrStaleWebhook):
+		return 401
+	case err.Error() == "支付渠道不存在" || err.Error() == "支付记录不存在":
+		return 404
+	}
+	return orderErrorStatus(err)
+}
+
+// PayOrder 发起支付
+func PayOrder(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的订单ID",
+		})
+		return
+	}
+
+	// 支付渠道可选，允许空请求体
+	var req model.PayOrderRequest
+	_ = c.BindAndValidate(&req)
+
+	p, err := logic.PayOrder(userID.(int), orderID, &req)
+	if err != nil {
+		c.JSON(paymentErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "支付已创建",
+		"payment": p,
+	})
+}
+
+// PaymentWebhook 支付渠道回调，签名校验通过后才会更新订单
+func PaymentWebhook(ctx context.Context, c *app.RequestContext) {
+	signature := string(c.GetHeader(payment.SignatureHeader))
+	if err := logic.HandlePaymentWebhook(c.Param("provider"), c.Request.Body(), signature); err != nil {
+		c.JSON(paymentErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "ok",
+	})
+}
+
+// CompleteMockPayment 模拟用户在模拟渠道完成支付
+func CompleteMockPayment(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	// 支付结果可选，默认支付成功
+	var req model.CompleteMockPaymentRequest
+	_ = c.BindAndValidate(&req)
+
+	if err := logic.CompleteMockPayment(userID.(int), c.Param("ref"), &req); err != nil {
+		c.JSON(paymentErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "模拟支付已完成",
+	})
+}
@@ -2,26 +2,84 @@ package api
 
 import (
 	"context"
+	"errors"
+	"strconv"
 
 	"shop/logic"
+	"shop/model"
 
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/common/utils"
 )
 
-// GetProducts 获取商品列表
+// parseProductListQuery 解析商品列表查询参数
+func parseProductListQuery(c *app.RequestContext) (*model.ProductListQuery, error) {
+	q := &model.ProductListQuery{
+		Cursor:  c.Query("cursor"),
+		Series:  c.Query("series"),
+		Keyword: c.Query("keyword"),
+		Sort:    c.Query("sort"),
+	}
+
+	var err error
+	if v := c.Query("page"); v != "" {
+		if q.Page, err = strconv.Atoi(v); err != nil {
+			return nil, errors.New("无效的 page")
+		}
+	}
+	if v := c.Query("page_size"); v != "" {
+		if q.PageSize, err = strconv.Atoi(v); err != nil {
+			return nil, errors.New("无效的 page_size")
+		}
+	}
+	if v := c.Query("min_price"); v != "" {
+		price, err := model.ParseMoney(v)
+		if err != nil {
+			return nil, errors.New("无效的 min_price")
+		}
+		q.MinPrice = &price
+	}
+	if v := c.Query("max_price"); v != "" {
+		price, err := model.ParseMoney(v)
+		if err != nil {
+			return nil, errors.New("无效的 max_price")
+		}
+		q.MaxPrice = &price
+	}
+	if v := c.Query("in_stock"); v != "" {
+		if q.InStock, err = strconv.ParseBool(v); err != nil {
+			return nil, errors.New("无效的 in_stock")
+		}
+	}
+	return q, nil
+}
+
+// GetProducts 获取商品列表，支持分页、过滤、排序和关键词搜索
 func GetProducts(ctx context.Context, c *app.RequestContext) {
-	products, err := logic.GetProducts()
+	q, err := parseProductListQuery(c)
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	resp, err := logic.GetProducts(q)
 	if err != nil {
+		var validationErr *logic.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(400, utils.H{
+				"error": err.Error(),
+			})
+			return
+		}
 		c.JSON(500, utils.H{
 			"error": "查询商品失败: " + err.Error(),
 		})
 		return
 	}
 
-	c.JSON(200, utils.H{
-		"products": products,
-	})
+	c.JSON(200, resp)
 }
 
 // GetProduct 获取单个商品详情
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"shop/logic"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// promotionErrorStatus 根据促销和优惠码操作错误返回状态码
+func promotionErrorStatus(err error) int {
+	var validationErr *logic.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return 400
+	case errors.Is(err, logic.ErrPromotionNotFound), errors.Is(err, logic.ErrCouponNotFound), err.Error() == "商品不存在":
+		return 404
+	}
+	return 500
+}
+
+// GetPromotions 获取当前生效的自动应用促销
+func GetPromotions(ctx context.Context, c *app.RequestContext) {
+	promotions, err := logic.GetActivePromotions()
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询促销失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"promotions": promotions,
+	})
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"shop/logic"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// raffleErrorStatus 根据抽签操作错误返回状态码
+func raffleErrorStatus(err error) int {
+	var validationErr *logic.ValidationError
+	var outOfStock *logic.OutOfStockError
+	switch {
+	case errors.As(err, &validationErr), errors.As(err, &outOfStock):
+		return 400
+	case errors.Is(err, logic.ErrRaffleNotFound), errors.Is(err, logic.ErrRaffleEntryNotFound), err.Error() == "商品不存在":
+		return 404
+	case errors.Is(err, logic.ErrRaffleNotOpen), errors.Is(err, logic.ErrRaffleAlreadyEntered), errors.Is(err, logic.ErrRaffleNotWon):
+		return 409
+	}
+	return 500
+}
+
+// GetRaffles 获取报名中和已开奖的抽签
+func GetRaffles(ctx context.Context, c *app.RequestContext) {
+	raffles, err := logic.GetRaffles()
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询抽签失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"raffles": raffles,
+	})
+}
+
+// GetRaffle 获取抽签详情（开奖后包含种子）
+func GetRaffle(ctx context.Context, c *app.RequestContext) {
+	raffleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的抽签ID",
+		})
+		return
+	}
+
+	raffle, err := logic.GetRaffle(raffleID)
+	if err != nil {
+		c.JSON(raffleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, raffle)
+}
+
+// GetRaffleResults 获取开奖结果（种子、全部报名ID和中签报名ID），用于重放校验
+func GetRaffleResults(ctx context.Context, c *app.RequestContext) {
+	raffleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的抽签ID",
+		})
+		return
+	}
+
+	result, err := logic.GetRaffleResults(raffleID)
+	if err != nil {
+		c.JSON(raffleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, result)
+}
+
+// EnterRaffle 报名抽签
+func EnterRaffle(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	raffleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的抽签ID",
+		})
+		return
+	}
+
+	entry, err := logic.EnterRaffle(userID.(int), raffleID)
+	if err != nil {
+		c.JSON(raffleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, entry)
+}
+
+// GetMyRaffleEntry 获取当前用户的报名和中签状态
+func GetMyRaffleEntry(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	raffleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的抽签ID",
+		})
+		return
+	}
+
+	entry, err := logic.GetMyRaffleEntry(userID.(int), raffleID)
+	if err != nil {
+		c.JSON(raffleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, entry)
+}
+
+// CheckoutRaffle 中签者按抽签价下单
+func CheckoutRaffle(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	raffleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的抽签ID",
+		})
+		return
+	}
+
+	order, err := logic.CheckoutRaffle(userID.(int), raffleID)
+	if err != nil {
+		if body, ok := purchaseLimitResponse(err); ok {
+			c.JSON(400, body)
+			return
+		}
+		c.JSON(raffleErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message":     "订单创建成功",
+		"order_id":    order.ID,
+		"total_price": order.TotalPrice,
+	})
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+
+	"shop/logic"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// Logout 注销当前会话
+func Logout(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	if err := logic.Logout(userID.(int), c.GetString("session_id")); err != nil {
+		c.JSON(500, utils.H{
+			"error": "注销失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "已退出登录",
+	})
+}
+
+// GetSessions 获取当前用户的登录会话列表
+func GetSessions(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	sessions, err := logic.GetSessions(userID.(int), c.GetString("session_id"))
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询会话失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"sessions": sessions,
+	})
+}
+
+// RevokeSession 注销指定会话（踢下线某台设备）
+func RevokeSession(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	err := logic.RevokeSession(userID.(int), c.Param("id"))
+	if err != nil {
+		statusCode := 500
+		if err.Error() == "会话不存在" {
+			statusCode = 404
+		}
+		c.JSON(statusCode, utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "会话已注销",
+	})
+}
+
+// RevokeAllSessions 注销所有设备上的会话
+func RevokeAllSessions(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	if err := logic.RevokeAllSessions(userID.(int)); err != nil {
+		c.JSON(500, utils.H{
+			"error": "注销失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"message": "所有设备已退出登录",
+	})
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"shop/logic"
+	"shop/model"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// tradeErrorStatus 根据交易操作错误返回状态码
+func tradeErrorStatus(err error) int {
+	var validationErr *logic.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return 400
+	case errors.Is(err, logic.ErrTradeNotPending), errors.Is(err, logic.ErrTradeItemsUnavailable):
+		return 409
+	case err.Error() == "报价不存在", err.Error() == "物品不存在":
+		return 404
+	}
+	return 500
+}
+
+// GetTradeListings 获取其他用户挂出的物品
+func GetTradeListings(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	productID, _ := strconv.Atoi(c.Query("product_id"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	items, total, err := logic.GetTradeListings(userID.(int), productID, c.Query("series"), page, pageSize)
+	if err != nil {
+		c.JSON(500, utils.H{
+			"error": "查询挂出物品失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"items": items,
+		"total": total,
+	})
+}
+
+// CreateTradeOffer 发起交换报价
+func CreateTradeOffer(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	var req model.CreateTradeOfferRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	offer, err := logic.CreateTradeOffer(userID.(int), &req)
+	if err != nil {
+		c.JSON(tradeErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, offer)
+}
+
+// GetTradeOffers 获取我收到或发出的报价
+func GetTradeOffers(ctx context.Context, c *app.RequestContext) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	offers, total, err := logic.GetTradeOffers(userID.(int), c.DefaultQuery("box", "incoming"), c.Query("status"), page, pageSize)
+	if err != nil {
+		c.JSON(tradeErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, utils.H{
+		"offers": offers,
+		"total":  total,
+	})
+}
+
+// GetTradeOffer 获取报价详情（包含状态变更记录）
+func GetTradeOffer(ctx context.Context, c *app.RequestContext) {
+	handleTradeOffer(c, logic.GetTradeOffer)
+}
+
+// AcceptTradeOffer 接受报价，双方物品在同一事务中交换
+func AcceptTradeOffer(ctx context.Context, c *app.RequestContext) {
+	handleTradeOffer(c, logic.AcceptTradeOffer)
+}
+
+// RejectTradeOffer 拒绝报价
+func RejectTradeOffer(ctx context.Context, c *app.RequestContext) {
+	handleTradeOffer(c, logic.RejectTradeOffer)
+}
+
+// CancelTradeOffer 取消自己发起的报价
+func CancelTradeOffer(ctx context.Context, c *app.RequestContext) {
+	handleTradeOffer(c, logic.CancelTradeOffer)
+}
+
+// handleTradeOffer 解析报价ID并执行操作
+func handleTradeOffer(c *app.RequestContext, action func(userID, offerID int) (*model.TradeOffer, error)) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(401, utils.H{
+			"error": "未授权",
+		})
+		return
+	}
+
+	offerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, utils.H{
+			"error": "无效的报价ID",
+		})
+		return
+	}
+
+	offer, err := action(userID.(int), offerID)
+	if err != nil {
+		c.JSON(tradeErrorStatus(err), utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, offer)
+}
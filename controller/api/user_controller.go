@@ -48,7 +48,18 @@ func Login(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
-	resp, err := logic.Login(&req)
+	// 未在请求体中传入时使用请求头或Cookie中的游客购物车令牌
+	if req.CartToken == "" {
+		req.CartToken = c.GetString("guest_cart_token")
+	}
+
+	meta := &model.SessionMeta{
+		Device:    req.Device,
+		UserAgent: string(c.UserAgent()),
+		IP:        c.ClientIP(),
+	}
+
+	resp, err := logic.Login(&req, meta)
 	if err != nil {
 		statusCode := 500
 		if err.Error() == "用户名或密码错误" {
@@ -62,3 +73,28 @@ func Login(ctx context.Context, c *app.RequestContext) {
 
 	c.JSON(200, resp)
 }
+
+// RefreshToken 刷新访问令牌
+func RefreshToken(ctx context.Context, c *app.RequestContext) {
+	var req model.RefreshTokenRequest
+	if err := c.BindAndValidate(&req); err != nil {
+		c.JSON(400, utils.H{
+			"error": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	resp, err := logic.RefreshToken(&req)
+	if err != nil {
+		statusCode := 500
+		if err.Error() == "刷新令牌无效" {
+			statusCode = 401
+		}
+		c.JSON(statusCode, utils.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(200, resp)
+}
@@ -0,0 +1,151 @@
+package dao
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"shop/global/db"
+	"shop/model"
+)
+
+// CreateBlindBoxSeries 创建盲盒系列
+func CreateBlindBoxSeries(series *model.BlindBoxSeries) error {
+	return db.DB.Create(series).Error
+}
+
+// GetBlindBoxSeriesList 获取全部盲盒系列（包含款式）
+func GetBlindBoxSeriesList() ([]model.BlindBoxSeries, error) {
+	var list []model.BlindBoxSeries
+	err := db.DB.Preload("Figures", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("id")
+	}).Order("id DESC").Find(&list).Error
+	return list, err
+}
+
+// GetBlindBoxSeriesByID 根据ID获取盲盒系列（包含款式）
+func GetBlindBoxSeriesByID(seriesID int) (*model.BlindBoxSeries, error) {
+	var series model.BlindBoxSeries
+	err := db.DB.Preload("Figures", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("id")
+	}).First(&series, seriesID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &series, nil
+}
+
+// GetBlindBoxSeriesByProductID 根据盲盒商品ID获取系列（包含款式）
+func GetBlindBoxSeriesByProductID(productID int) (*model.BlindBoxSeries, error) {
+	var series model.BlindBoxSeries
+	err := db.DB.Preload("Figures", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("id")
+	}).Where("product_id = ?", productID).First(&series).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &series, nil
+}
+
+// GetBlindBoxSeriesByProductIDTx 在事务中根据盲盒商品ID获取系列（不加载款式）
+func GetBlindBoxSeriesByProductIDTx(tx *gorm.DB, productID int) (*model.BlindBoxSeries, error) {
+	var series model.BlindBoxSeries
+	err := tx.Where("product_id = ?", productID).First(&series).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &series, nil
+}
+
+// CreateBlindBoxFigureTx 在事务中创建款式
+func CreateBlindBoxFigureTx(tx *gorm.DB, figure *model.BlindBoxFigure) error {
+	return tx.Create(figure).Error
+}
+
+// GetBlindBoxFigureByID 根据ID获取款式
+func GetBlindBoxFigureByID(figureID int) (*model.BlindBoxFigure, error) {
+	var figure model.BlindBoxFigure
+	err := db.DB.First(&figure, figureID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &figure, nil
+}
+
+// UpdateBlindBoxFigure 更新款式字段
+func UpdateBlindBoxFigure(figureID int, updates map[string]interface{}) error {
+	return db.DB.Model(&model.BlindBoxFigure{}).Where("id = ?", figureID).Updates(updates).Error
+}
+
+// AdjustBlindBoxFigureStockTx 在事务中调整款式库存，库存不允许调整为负数
+func AdjustBlindBoxFigureStockTx(tx *gorm.DB, figureID, delta int) error {
+	result := tx.Model(&model.BlindBoxFigure{}).
+		Where("id = ? AND stock + ? >= 0", figureID, delta).
+		Update("stock", gorm.Expr("stock + ?", delta))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+// GetBlindBoxFiguresForUpdate 在事务中锁定系列的全部款式（按ID升序）
+func GetBlindBoxFiguresForUpdate(tx *gorm.DB, seriesID int) ([]model.BlindBoxFigure, error) {
+	var figures []model.BlindBoxFigure
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("series_id = ?", seriesID).
+		Order("id").
+		Find(&figures).Error
+	return figures, err
+}
+
+// SetOrderItemDrawSeed 在事务中记录订单项的抽取种子
+func SetOrderItemDrawSeed(tx *gorm.DB, orderItemID int, seed string) error {
+	return tx.Model(&model.OrderItem{}).Where("id = ?", orderItemID).Update("draw_seed", seed).Error
+}
+
+// CreateBlindBoxDraws 在事务中保存抽取记录
+func CreateBlindBoxDraws(tx *gorm.DB, draws []model.BlindBoxDraw) error {
+	if len(draws) == 0 {
+		return nil
+	}
+	return tx.Create(&draws).Error
+}
+
+// GetBlindBoxDrawsByOrderTx 在事务中获取订单的抽取记录
+func GetBlindBoxDrawsByOrderTx(tx *gorm.DB, orderID int) ([]model.BlindBoxDraw, error) {
+	var draws []model.BlindBoxDraw
+	err := tx.Where("order_id = ?", orderID).Find(&draws).Error
+	return draws, err
+}
+
+// GetBlindBoxDrawsByUser 分页获取用户的抽取记录（包含款式）
+func GetBlindBoxDrawsByUser(userID, page, pageSize int) ([]model.BlindBoxDraw, int64, error) {
+	var draws []model.BlindBoxDraw
+	var total int64
+
+	query := db.DB.Model(&model.BlindBoxDraw{}).Where("user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Preload("Figure").
+		Order("id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&draws).Error
+	return draws, total, err
+}
@@ -0,0 +1,96 @@
+package dao
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"shop/global/db"
+	"shop/model"
+)
+
+// preloadBundleItems 预加载套装成员及商品（包含已下架商品）
+func preloadBundleItems(query *gorm.DB) *gorm.DB {
+	return query.Preload("Items", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("product_id")
+	}).Preload("Items.Product", func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped()
+	})
+}
+
+// GetBundles 获取套装列表，activeOnly 为 true 时只返回上架中的套装，series 为空时不过滤
+func GetBundles(series string, activeOnly bool) ([]model.Bundle, error) {
+	var bundles []model.Bundle
+	query := preloadBundleItems(db.DB)
+	if series != "" {
+		query = query.Where("series = ?", series)
+	}
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+	if err := query.Order("id DESC").Find(&bundles).Error; err != nil {
+		return nil, err
+	}
+	for i := range bundles {
+		if err := bundles[i].FillListPrice(); err != nil {
+			return nil, err
+		}
+	}
+	return bundles, nil
+}
+
+// GetBundleByID 根据ID获取套装（包含成员）
+func GetBundleByID(bundleID int) (*model.Bundle, error) {
+	var bundle model.Bundle
+	err := preloadBundleItems(db.DB).First(&bundle, bundleID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := bundle.FillListPrice(); err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+// GetBundleByIDTx 在事务中获取套装（包含成员，不加载商品）
+func GetBundleByIDTx(tx *gorm.DB, bundleID int) (*model.Bundle, error) {
+	var bundle model.Bundle
+	err := tx.Preload("Items", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("product_id")
+	}).First(&bundle, bundleID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+// CreateBundle 创建套装及成员
+func CreateBundle(bundle *model.Bundle) error {
+	return db.DB.Create(bundle).Error
+}
+
+// UpdateBundle 更新套装字段，items 非空时整体替换成员
+func UpdateBundle(bundleID int, updates map[string]interface{}, items []model.BundleItem) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if len(updates) > 0 {
+			if err := tx.Model(&model.Bundle{}).Where("id = ?", bundleID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		if items == nil {
+			return nil
+		}
+		if err := tx.Where("bundle_id = ?", bundleID).Delete(&model.BundleItem{}).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].BundleID = bundleID
+		}
+		return tx.Create(&items).Error
+	})
+}
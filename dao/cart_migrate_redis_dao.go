@@ -0,0 +1,112 @@
+package dao
+
+import (
+	"fmt"
+
+	"shop/global/redis"
+
+	redisv9 "github.com/redis/go-redis/v9"
+)
+
+const (
+	// cartLayoutVersionKey 购物车存储结构版本，迁移完成后写入，避免每次启动都扫描
+	cartLayoutVersionKey = "cart:layout:version"
+	// cartLayoutVersion 当前购物车存储结构版本（每个用户一个Hash，商品行和套装行都在其中）
+	cartLayoutVersion = 3
+	// cartMigrateScanCount 每次SCAN返回的键数量提示
+	cartMigrateScanCount = 500
+)
+
+// cartMigrateKeyScript 把旧版购物车项键（每个商品/套装一个Hash）合并到新的用户购物车Hash中并删除旧键
+// 新Hash中已有的数量不会被覆盖；返回 1 已迁移，0 旧键为空
+var cartMigrateKeyScript = redisv9.NewScript(`
+local quantity = redis.call('HGET', KEYS[1], 'quantity')
+redis.call('DEL', KEYS[1])
+if not quantity or tonumber(quantity) == nil or tonumber(quantity) <= 0 then
+	return 0
+end
+redis.call('HSETNX', KEYS[2], ARGV[1], quantity)
+redis.call('EXPIRE', KEYS[2], ARGV[2])
+return 1
+`)
+
+// cartMigrateBundlesScript 把版本2单独保存套装行的Hash合并到用户购物车Hash中（字段加上套装前缀）并删除旧键
+// 新Hash中已有的字段不会被覆盖；返回迁移的套装行数量
+var cartMigrateBundlesScript = redisv9.NewScript(`
+local data = redis.call('HGETALL', KEYS[1])
+redis.call('DEL', KEYS[1])
+local migrated = 0
+for i = 1, #data, 2 do
+	redis.call('HSETNX', KEYS[2], ARGV[1] .. data[i], data[i + 1])
+	if not string.find(data[i], ':', 1, true) then
+		migrated = migrated + 1
+	end
+end
+if #data > 0 then
+	redis.call('EXPIRE', KEYS[2], ARGV[2])
+end
+return migrated
+`)
+
+// MigrateLegacyCartKeys 把旧版的购物车键 cart:user:{用户ID}:product:{商品ID}、
+// cart:user:{用户ID}:bundle:{套装ID} 和 cart:user:{用户ID}:bundles 合并到每个用户一个Hash，返回迁移的购物车项数量
+// 使用SCAN遍历，不阻塞Redis；迁移完成后写入版本标记，之后的调用直接返回
+func MigrateLegacyCartKeys() (int, error) {
+	ctx := redis.GetContext()
+	version, err := redis.Client.Get(ctx, cartLayoutVersionKey).Int()
+	if err != nil && err != redisv9.Nil {
+		return 0, fmt.Errorf("读取购物车存储版本失败: %w", err)
+	}
+	if version >= cartLayoutVersion {
+		return 0, nil
+	}
+
+	migrated := 0
+	for _, kind := range []string{"product", "bundle"} {
+		iter := redis.Client.Scan(ctx, 0, CartKeyPrefix+"*:"+kind+":*", cartMigrateScanCount).Iterator()
+		for iter.Next(ctx) {
+			legacyKey := iter.Val()
+			var userID, id int
+			if _, err := fmt.Sscanf(legacyKey, CartKeyPrefix+"%d:"+kind+":%d", &userID, &id); err != nil {
+				continue
+			}
+
+			field := cartField("", id)
+			if kind == "bundle" {
+				field = cartField(cartBundleFieldPrefix, id)
+			}
+			n, err := cartMigrateKeyScript.Run(ctx, redis.Client,
+				[]string{legacyKey, getCartKey(userID)}, field, int(CartExpireTime.Seconds())).Int()
+			if err != nil {
+				return migrated, fmt.Errorf("迁移购物车键 %s 失败: %w", legacyKey, err)
+			}
+			migrated += n
+		}
+		if err := iter.Err(); err != nil {
+			return migrated, fmt.Errorf("扫描旧版购物车键失败: %w", err)
+		}
+	}
+
+	iter := redis.Client.Scan(ctx, 0, CartKeyPrefix+"*:bundles", cartMigrateScanCount).Iterator()
+	for iter.Next(ctx) {
+		legacyKey := iter.Val()
+		var userID int
+		if _, err := fmt.Sscanf(legacyKey, CartKeyPrefix+"%d:bundles", &userID); err != nil || legacyKey != getLegacyCartBundlesKey(userID) {
+			continue
+		}
+		n, err := cartMigrateBundlesScript.Run(ctx, redis.Client,
+			[]string{legacyKey, getCartKey(userID)}, cartBundleFieldPrefix, int(CartExpireTime.Seconds())).Int()
+		if err != nil {
+			return migrated, fmt.Errorf("迁移购物车键 %s 失败: %w", legacyKey, err)
+		}
+		migrated += n
+	}
+	if err := iter.Err(); err != nil {
+		return migrated, fmt.Errorf("扫描旧版购物车键失败: %w", err)
+	}
+
+	if err := redis.Client.Set(ctx, cartLayoutVersionKey, cartLayoutVersion, 0).Err(); err != nil {
+		return migrated, fmt.Errorf("写入购物车存储版本失败: %w", err)
+	}
+	return migrated, nil
+}
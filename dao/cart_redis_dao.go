@@ -3,6 +3,9 @@ package dao
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"shop/global/redis"
@@ -12,10 +15,26 @@ import (
 )
 
 const (
-	// CartKeyPrefix 购物车键前缀
+	// CartKeyPrefix 购物车键前缀，每个用户一个Hash（商品ID -> 数量，商品ID:price -> 加入时单价；
+	// 套装行使用 cartBundleFieldPrefix 前缀的字段保存在同一个Hash中）
 	CartKeyPrefix = "cart:user:"
+	// GuestCartKeyPrefix 游客购物车键前缀，每个游客购物车一个Hash（商品ID -> 数量）
+	GuestCartKeyPrefix = "cart:guest:"
 	// CartExpireTime 购物车过期时间（30天）
 	CartExpireTime = 30 * 24 * time.Hour
+	// cartPriceFieldSuffix 加入购物车时单价的字段后缀，与数量保存在同一个Hash中
+	cartPriceFieldSuffix = ":price"
+	// cartBundleFieldPrefix 套装行的字段前缀（bundle:套装ID -> 数量，bundle:套装ID:price -> 加入时套装价）
+	cartBundleFieldPrefix = "bundle:"
+)
+
+var (
+	// ErrCartItemNotFound 购物车项不存在
+	ErrCartItemNotFound = errors.New("购物车项不存在")
+	// ErrCartStockExceeded 购物车商品数量超过库存
+	ErrCartStockExceeded = errors.New("库存不足")
+	// ErrCartLimitExceeded 购物车商品数量超过限购
+	ErrCartLimitExceeded = errors.New("超过限购数量")
 )
 
 // getCartKey 获取购物车Redis键
@@ -23,149 +42,296 @@ func getCartKey(userID int) string {
 	return fmt.Sprintf("%s%d", CartKeyPrefix, userID)
 }
 
-// getCartItemKey 获取购物车项Redis键
-func getCartItemKey(userID, productID int) string {
-	return fmt.Sprintf("%s%d:product:%d", CartKeyPrefix, userID, productID)
+// getLegacyCartBundlesKey 旧版（存储结构版本2）单独保存套装行的Redis键，只用于迁移和清空购物车
+func getLegacyCartBundlesKey(userID int) string {
+	return fmt.Sprintf("%s%d:bundles", CartKeyPrefix, userID)
 }
 
-// GetCartItems 获取用户的购物车项（从Redis）
-func GetCartItemsFromRedis(userID int) ([]model.CartItem, error) {
-	// 获取所有购物车项的键
-	pattern := fmt.Sprintf("%s%d:product:*", CartKeyPrefix, userID)
-	keys, err := redis.Client.Keys(redis.GetContext(), pattern).Result()
+// cartIncrScript 原子地增减购物车中商品的数量，新加入时记录当前单价，数量减到0或以下时删除该商品和单价
+// 只有增加数量时才校验库存和限购上限；返回 {状态, 数量}：
+// 状态 0 成功（数量为新数量，删除时为0），-1 超过库存，-2 购物车项不存在，-3 超过限购（数量为尝试设置的数量）
+var cartIncrScript = redisv9.NewScript(`
+local current = tonumber(redis.call('HGET', KEYS[1], ARGV[1]) or '0')
+local delta = tonumber(ARGV[2])
+if current == 0 and delta < 0 then
+	return {-2, 0}
+end
+local quantity = current + delta
+if quantity <= 0 then
+	redis.call('HDEL', KEYS[1], ARGV[1], ARGV[1] .. ':price')
+	return {0, 0}
+end
+if delta > 0 then
+	if quantity > tonumber(ARGV[3]) then
+		return {-1, quantity}
+	end
+	if quantity > tonumber(ARGV[4]) then
+		return {-3, quantity}
+	end
+end
+redis.call('HSET', KEYS[1], ARGV[1], quantity)
+if current == 0 then
+	redis.call('HSET', KEYS[1], ARGV[1] .. ':price', ARGV[6])
+end
+redis.call('EXPIRE', KEYS[1], ARGV[5])
+return {0, quantity}
+`)
+
+// cartSetScript 设置购物车中商品的数量并刷新过期时间，还没有记录单价时写入加入时单价
+var cartSetScript = redisv9.NewScript(`
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+redis.call('HSETNX', KEYS[1], ARGV[1] .. ':price', ARGV[4])
+redis.call('EXPIRE', KEYS[1], ARGV[3])
+return 1
+`)
+
+// cartSetExistingScript 仅在商品已在购物车中时设置数量，新数量不能超过库存和限购上限
+// 返回 1 成功，-1 超过库存，-2 购物车项不存在，-3 超过限购
+var cartSetExistingScript = redisv9.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+	return -2
+end
+local quantity = tonumber(ARGV[2])
+if quantity > tonumber(ARGV[3]) then
+	return -1
+end
+if quantity > tonumber(ARGV[4]) then
+	return -3
+end
+redis.call('HSET', KEYS[1], ARGV[1], quantity)
+redis.call('EXPIRE', KEYS[1], ARGV[5])
+return 1
+`)
+
+// getCartLines 读取购物车Hash中字段前缀为 prefix 的各商品（或套装）的数量和加入时的单价，忽略无法解析的字段
+// 商品行的前缀为空，套装行的前缀为 cartBundleFieldPrefix；旧版本加入的购物车项没有记录单价，不在 prices 中
+func getCartLines(key, prefix string) (map[int]int, map[int]model.Money, error) {
+	data, err := redis.Client.HGetAll(redis.GetContext(), key).Result()
 	if err != nil {
-		return nil, fmt.Errorf("获取购物车键失败: %w", err)
+		return nil, nil, fmt.Errorf("获取购物车失败: %w", err)
 	}
 
-	if len(keys) == 0 {
-		return []model.CartItem{}, nil
-	}
-
-	var items []model.CartItem
-	for _, itemKey := range keys {
-		// 获取商品ID和数量
-		data, err := redis.Client.HGetAll(redis.GetContext(), itemKey).Result()
-		if err != nil {
-			if err == redisv9.Nil {
+	quantities := make(map[int]int, len(data))
+	prices := make(map[int]model.Money, len(data))
+	for field, value := range data {
+		field, ok := strings.CutPrefix(field, prefix)
+		if !ok {
+			continue
+		}
+		if idField, ok := strings.CutSuffix(field, cartPriceFieldSuffix); ok {
+			id, err := strconv.Atoi(idField)
+			if err != nil {
 				continue
 			}
-			return nil, fmt.Errorf("获取购物车项失败: %w", err)
-		}
-
-		if len(data) == 0 {
+			price, err := model.ParseMoney(value)
+			if err != nil {
+				continue
+			}
+			prices[id] = price
 			continue
 		}
-
-		var productID, quantity int
-		if _, err := fmt.Sscanf(data["product_id"], "%d", &productID); err != nil {
+		id, err := strconv.Atoi(field)
+		if err != nil {
 			continue
 		}
-		if _, err := fmt.Sscanf(data["quantity"], "%d", &quantity); err != nil {
+		quantity, err := strconv.Atoi(value)
+		if err != nil || quantity <= 0 {
 			continue
 		}
-
-		// 获取商品信息（从数据库）
-		product, err := GetProductByID(fmt.Sprintf("%d", productID))
-		if err != nil || product == nil {
-			// 如果商品不存在，删除该购物车项
-			redis.Client.Del(redis.GetContext(), itemKey)
-			continue
+		quantities[id] = quantity
+	}
+	for id := range prices {
+		if _, ok := quantities[id]; !ok {
+			delete(prices, id)
 		}
+	}
+	return quantities, prices, nil
+}
 
-		item := model.CartItem{
-			UserID:    userID,
-			ProductID: productID,
-			Quantity:  quantity,
-			Product:   *product,
-		}
-		items = append(items, item)
+// getCartQuantities 读取购物车Hash中各商品的数量
+func getCartQuantities(key string) (map[int]int, error) {
+	quantities, _, err := getCartLines(key, "")
+	return quantities, err
+}
+
+// addedPrice 返回加入购物车时的单价，未记录时返回nil
+func addedPrice(prices map[int]model.Money, id int) *model.Money {
+	price, ok := prices[id]
+	if !ok {
+		return nil
 	}
+	return &price
+}
 
-	return items, nil
+// formatCartPrice 单价保存为两位小数的字符串
+func formatCartPrice(price model.Money) string {
+	return price.String()
 }
 
-// GetCartItemByUserAndProductFromRedis 从Redis获取用户和商品的购物车项
-func GetCartItemByUserAndProductFromRedis(userID, productID int) (*model.CartItem, error) {
-	itemKey := getCartItemKey(userID, productID)
+// cartField 商品（或套装）在购物车Hash中的数量字段，prefix 为空表示商品行
+func cartField(prefix string, id int) string {
+	return prefix + strconv.Itoa(id)
+}
 
-	data, err := redis.Client.HGetAll(redis.GetContext(), itemKey).Result()
+// cartFields 商品（或套装）在购物车Hash中的数量字段和单价字段
+func cartFields(prefix string, id int) []string {
+	field := cartField(prefix, id)
+	return []string{field, field + cartPriceFieldSuffix}
+}
+
+// getCartItems 读取购物车Hash并批量加载商品信息，按商品ID排序；已下架商品的购物车项会被删除
+func getCartItems(key string, userID int) ([]model.CartItem, error) {
+	quantities, prices, err := getCartLines(key, "")
 	if err != nil {
-		if err == redisv9.Nil {
-			return nil, nil
-		}
 		return nil, err
 	}
 
-	if len(data) == 0 {
-		return nil, nil
+	productIDs := make([]int, 0, len(quantities))
+	for productID := range quantities {
+		productIDs = append(productIDs, productID)
 	}
+	sort.Ints(productIDs)
 
-	var quantity int
-	if _, err := fmt.Sscanf(data["quantity"], "%d", &quantity); err != nil {
-		return nil, err
+	products, err := GetProductsByIDs(productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("查询商品失败: %w", err)
 	}
 
-	return &model.CartItem{
-		UserID:    userID,
-		ProductID: productID,
-		Quantity:  quantity,
-	}, nil
+	items := make([]model.CartItem, 0, len(productIDs))
+	var removed []string
+	for _, productID := range productIDs {
+		product, ok := products[productID]
+		if !ok {
+			removed = append(removed, cartFields("", productID)...)
+			continue
+		}
+		items = append(items, model.CartItem{
+			UserID:     userID,
+			ProductID:  productID,
+			Quantity:   quantities[productID],
+			AddedPrice: addedPrice(prices, productID),
+			Product:    product,
+		})
+	}
+	if len(removed) > 0 {
+		redis.Client.HDel(redis.GetContext(), key, removed...)
+	}
+	return items, nil
 }
 
-// AddCartItemToRedis 添加购物车项到Redis
-func AddCartItemToRedis(userID, productID, quantity int) error {
-	itemKey := getCartItemKey(userID, productID)
-	cartKey := getCartKey(userID)
-
-	// 使用Hash存储购物车项
-	itemData := map[string]interface{}{
-		"product_id": productID,
-		"quantity":   quantity,
-		"user_id":    userID,
+// getCartQuantity 获取购物车Hash中某个字段的数量，不存在时返回0
+func getCartQuantity(key, field string) (int, error) {
+	quantity, err := redis.Client.HGet(redis.GetContext(), key, field).Int()
+	if err != nil {
+		if err == redisv9.Nil {
+			return 0, nil
+		}
+		return 0, err
 	}
+	return quantity, nil
+}
 
-	// 设置购物车项
-	err := redis.Client.HSet(redis.GetContext(), itemKey, itemData).Err()
+// setCartQuantity 原子地设置购物车Hash中某个字段的数量，并刷新购物车过期时间
+// price 只在购物车中还没有记录单价时写入，已记录的加入时单价保持不变
+func setCartQuantity(key, field string, quantity int, price model.Money) error {
+	err := cartSetScript.Run(redis.GetContext(), redis.Client,
+		[]string{key}, field, quantity, int(CartExpireTime.Seconds()), formatCartPrice(price)).Err()
 	if err != nil {
-		return fmt.Errorf("添加购物车项失败: %w", err)
+		return fmt.Errorf("保存购物车失败: %w", err)
 	}
-
-	// 设置过期时间
-	redis.Client.Expire(redis.GetContext(), itemKey, CartExpireTime)
-	redis.Client.Expire(redis.GetContext(), cartKey, CartExpireTime)
-
 	return nil
 }
 
-// UpdateCartItemQuantityInRedis 更新Redis中购物车项数量
-func UpdateCartItemQuantityInRedis(userID, productID, quantity int) error {
-	itemKey := getCartItemKey(userID, productID)
+// updateCartQuantity 原子地更新购物车中已有商品的数量，存在性、库存和限购在同一个脚本中检查
+// 不在购物车中时返回 ErrCartItemNotFound，超过 stock 或 limit 时返回 ErrCartStockExceeded / ErrCartLimitExceeded
+func updateCartQuantity(key, field string, quantity, stock, limit int) error {
+	result, err := cartSetExistingScript.Run(redis.GetContext(), redis.Client,
+		[]string{key}, field, quantity, stock, limit, int(CartExpireTime.Seconds())).Int()
+	if err != nil {
+		return fmt.Errorf("更新购物车项失败: %w", err)
+	}
+	switch result {
+	case -1:
+		return ErrCartStockExceeded
+	case -2:
+		return ErrCartItemNotFound
+	case -3:
+		return ErrCartLimitExceeded
+	}
+	return nil
+}
 
-	// 检查项是否存在
-	exists, err := redis.Client.Exists(redis.GetContext(), itemKey).Result()
+// incrCartQuantity 原子地增减购物车中商品的数量，返回新数量（删除时为0）；price 为新加入时记录的单价
+// 超过库存或限购时返回尝试设置的数量和 ErrCartStockExceeded / ErrCartLimitExceeded
+func incrCartQuantity(key, field string, delta, stock, limit int, price model.Money) (int, error) {
+	result, err := cartIncrScript.Run(redis.GetContext(), redis.Client,
+		[]string{key}, field, delta, stock, limit, int(CartExpireTime.Seconds()), formatCartPrice(price)).Int64Slice()
 	if err != nil {
-		return err
+		return 0, fmt.Errorf("更新购物车项失败: %w", err)
 	}
-	if exists == 0 {
-		return errors.New("购物车项不存在")
+	if len(result) != 2 {
+		return 0, fmt.Errorf("更新购物车项失败: 脚本返回值无效")
+	}
+
+	quantity := int(result[1])
+	switch result[0] {
+	case -1:
+		return quantity, ErrCartStockExceeded
+	case -2:
+		return 0, ErrCartItemNotFound
+	case -3:
+		return quantity, ErrCartLimitExceeded
 	}
+	return quantity, nil
+}
+
+// GetCartQuantitiesFromRedis 获取用户购物车中各商品的数量（不加载商品信息）
+func GetCartQuantitiesFromRedis(userID int) (map[int]int, error) {
+	return getCartQuantities(getCartKey(userID))
+}
 
-	// 更新数量
-	err = redis.Client.HSet(redis.GetContext(), itemKey, "quantity", quantity).Err()
+// GetCartItemsFromRedis 获取用户的购物车项（包含商品信息）
+func GetCartItemsFromRedis(userID int) ([]model.CartItem, error) {
+	return getCartItems(getCartKey(userID), userID)
+}
+
+// GetCartItemByUserAndProductFromRedis 从Redis获取用户和商品的购物车项
+func GetCartItemByUserAndProductFromRedis(userID, productID int) (*model.CartItem, error) {
+	quantity, err := getCartQuantity(getCartKey(userID), cartField("", productID))
 	if err != nil {
-		return fmt.Errorf("更新购物车项失败: %w", err)
+		return nil, err
+	}
+	if quantity <= 0 {
+		return nil, nil
 	}
 
-	// 更新过期时间
-	redis.Client.Expire(redis.GetContext(), itemKey, CartExpireTime)
+	return &model.CartItem{
+		UserID:    userID,
+		ProductID: productID,
+		Quantity:  quantity,
+	}, nil
+}
 
-	return nil
+// AddCartItemToRedis 设置购物车中商品的数量（不存在时添加并记录加入时单价 price）
+func AddCartItemToRedis(userID, productID, quantity int, price model.Money) error {
+	return setCartQuantity(getCartKey(userID), cartField("", productID), quantity, price)
+}
+
+// UpdateCartItemQuantityInRedis 原子地更新Redis中购物车项数量，购物车项不存在时返回 ErrCartItemNotFound
+// 新数量超过 stock 或 limit 时返回 ErrCartStockExceeded / ErrCartLimitExceeded
+func UpdateCartItemQuantityInRedis(userID, productID, quantity, stock, limit int) error {
+	return updateCartQuantity(getCartKey(userID), cartField("", productID), quantity, stock, limit)
+}
+
+// IncrCartItemInRedis 原子地增减购物车中商品的数量，新加入时记录单价 price，数量减到0时删除该商品
+// 增加数量时新数量不能超过 stock 和 limit，超过时返回尝试设置的数量和 ErrCartStockExceeded / ErrCartLimitExceeded
+func IncrCartItemInRedis(userID, productID, delta, stock, limit int, price model.Money) (int, error) {
+	return incrCartQuantity(getCartKey(userID), cartField("", productID), delta, stock, limit, price)
 }
 
 // DeleteCartItemFromRedis 从Redis删除购物车项
 func DeleteCartItemFromRedis(userID, productID int) error {
-	itemKey := getCartItemKey(userID, productID)
-	return redis.Client.Del(redis.GetContext(), itemKey).Err()
+	return redis.Client.HDel(redis.GetContext(), getCartKey(userID), cartFields("", productID)...).Err()
 }
 
 // GetCartItemByIDFromRedis 根据ID获取购物车项（需要从productID反推）
@@ -174,19 +340,9 @@ func GetCartItemByIDFromRedis(userID, productID int) (*model.CartItem, error) {
 	return GetCartItemByUserAndProductFromRedis(userID, productID)
 }
 
-// ClearCartFromRedis 清空用户的购物车
+// ClearCartFromRedis 清空用户的购物车（商品和套装），同时删除可能还未迁移的旧版套装键
 func ClearCartFromRedis(userID int) error {
-	pattern := fmt.Sprintf("%s%d:product:*", CartKeyPrefix, userID)
-	keys, err := redis.Client.Keys(redis.GetContext(), pattern).Result()
-	if err != nil {
-		return err
-	}
-
-	if len(keys) > 0 {
-		return redis.Client.Del(redis.GetContext(), keys...).Err()
-	}
-
-	return nil
+	return redis.Client.Del(redis.GetContext(), getCartKey(userID), getLegacyCartBundlesKey(userID)).Err()
 }
 
 // GetCartItemWithProductFromRedis 从Redis获取购物车项及其商品信息
@@ -211,3 +367,58 @@ func GetCartItemWithProductFromRedis(userID, productID int) (*model.CartItem, *m
 
 	return item, product, nil
 }
+
+// GetCartBundlesFromRedis 获取用户购物车中的套装行（不加载套装信息），按套装ID排序
+func GetCartBundlesFromRedis(userID int) ([]model.CartBundle, error) {
+	quantities, prices, err := getCartLines(getCartKey(userID), cartBundleFieldPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	bundles := []model.CartBundle{}
+	for bundleID, quantity := range quantities {
+		bundles = append(bundles, model.CartBundle{
+			UserID:     userID,
+			BundleID:   bundleID,
+			Quantity:   quantity,
+			AddedPrice: addedPrice(prices, bundleID),
+		})
+	}
+	sort.Slice(bundles, func(i, j int) bool {
+		return bundles[i].BundleID < bundles[j].BundleID
+	})
+	return bundles, nil
+}
+
+// GetCartBundleFromRedis 获取购物车中的套装行，不存在时返回nil
+func GetCartBundleFromRedis(userID, bundleID int) (*model.CartBundle, error) {
+	quantity, err := getCartQuantity(getCartKey(userID), cartField(cartBundleFieldPrefix, bundleID))
+	if err != nil {
+		return nil, err
+	}
+	if quantity <= 0 {
+		return nil, nil
+	}
+	return &model.CartBundle{
+		UserID:   userID,
+		BundleID: bundleID,
+		Quantity: quantity,
+	}, nil
+}
+
+// IncrCartBundleInRedis 原子地增加购物车套装行的数量，新加入时记录套装价 price
+// 新数量不能超过 stock（成员库存最多能凑出的套数），超过时返回尝试设置的数量和 ErrCartStockExceeded
+func IncrCartBundleInRedis(userID, bundleID, delta, stock int, price model.Money) (int, error) {
+	return incrCartQuantity(getCartKey(userID), cartField(cartBundleFieldPrefix, bundleID), delta, stock, stock, price)
+}
+
+// UpdateCartBundleQuantityInRedis 原子地更新购物车中已有套装行的数量，不存在时返回 ErrCartItemNotFound
+// 新数量超过 stock 时返回 ErrCartStockExceeded
+func UpdateCartBundleQuantityInRedis(userID, bundleID, quantity, stock int) error {
+	return updateCartQuantity(getCartKey(userID), cartField(cartBundleFieldPrefix, bundleID), quantity, stock, stock)
+}
+
+// DeleteCartBundleFromRedis 从购物车删除套装行
+func DeleteCartBundleFromRedis(userID, bundleID int) error {
+	return redis.Client.HDel(redis.GetContext(), getCartKey(userID), cartFields(cartBundleFieldPrefix, bundleID)...).Err()
+}
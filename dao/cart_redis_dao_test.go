@@ -0,0 +1,165 @@
+package dao
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"shop/global/db"
+	"shop/global/db/dbtest"
+	"shop/global/redis"
+	"shop/global/redis/redistest"
+	"shop/model"
+)
+
+// cartItemsQueries 读取购物车执行的查询数：所有商品一次 WHERE id IN 查询，与购物车行数无关
+const cartItemsQueries = 1
+
+// BenchmarkGetCartItemsFromRedis 读取 1、10、100 行的购物车，查询次数必须保持为 cartItemsQueries
+func BenchmarkGetCartItemsFromRedis(b *testing.B) {
+	for _, lines := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("lines=%d", lines), func(b *testing.B) {
+			conn := dbtest.Open(b, &model.Product{})
+			redistest.Open(b)
+
+			const userID = 1
+			for i := 0; i < lines; i++ {
+				product := model.Product{Name: fmt.Sprintf("商品%d", i+1), Price: model.Cents(6900), Stock: 100}
+				if err := db.DB.Create(&product).Error; err != nil {
+					b.Fatalf("创建商品失败: %v", err)
+				}
+				if err := AddCartItemToRedis(userID, product.ID, 1, product.Price); err != nil {
+					b.Fatalf("加入购物车失败: %v", err)
+				}
+			}
+
+			queries := dbtest.CountQueries(b, conn)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				items, err := GetCartItemsFromRedis(userID)
+				if err != nil {
+					b.Fatalf("读取购物车失败: %v", err)
+				}
+				if len(items) != lines {
+					b.Fatalf("读取到 %d 行，预期 %d 行", len(items), lines)
+				}
+			}
+			b.StopTimer()
+
+			if got := queries.Count(); got != int64(cartItemsQueries*b.N) {
+				b.Fatalf("%d 行购物车每次读取执行了 %.1f 次查询，预期 %d 次", lines, float64(got)/float64(b.N), cartItemsQueries)
+			}
+		})
+	}
+}
+
+// TestCartBundlesShareUserHash 套装行与商品行保存在同一个用户Hash中，ID 相同也互不影响
+func TestCartBundlesShareUserHash(t *testing.T) {
+	server := redistest.Open(t)
+	const userID, id = 1, 7
+
+	if _, err := IncrCartItemInRedis(userID, id, 2, 10, 10, model.Cents(6900)); err != nil {
+		t.Fatalf("加入商品失败: %v", err)
+	}
+	if _, err := IncrCartBundleInRedis(userID, id, 3, 5, model.Cents(19900)); err != nil {
+		t.Fatalf("加入套装失败: %v", err)
+	}
+	if keys := server.Keys(); len(keys) != 1 || keys[0] != getCartKey(userID) {
+		t.Fatalf("购物车键为 %v，预期只有 %s", keys, getCartKey(userID))
+	}
+
+	quantities, err := GetCartQuantitiesFromRedis(userID)
+	if err != nil || len(quantities) != 1 || quantities[id] != 2 {
+		t.Fatalf("商品行为 %v（%v），预期只有商品 %d 数量 2", quantities, err, id)
+	}
+	bundles, err := GetCartBundlesFromRedis(userID)
+	if err != nil || len(bundles) != 1 || bundles[0].BundleID != id || bundles[0].Quantity != 3 {
+		t.Fatalf("套装行为 %+v（%v），预期只有套装 %d 数量 3", bundles, err, id)
+	}
+	if bundles[0].AddedPrice == nil || *bundles[0].AddedPrice != model.Cents(19900) {
+		t.Fatalf("套装加入时价格为 %v，预期 199.00", bundles[0].AddedPrice)
+	}
+
+	if quantity, err := IncrCartBundleInRedis(userID, id, 3, 5, model.Cents(19900)); !errors.Is(err, ErrCartStockExceeded) || quantity != 6 {
+		t.Fatalf("超过可售套数时返回 %d、%v，预期 6 和 ErrCartStockExceeded", quantity, err)
+	}
+	if err := DeleteCartBundleFromRedis(userID, id); err != nil {
+		t.Fatalf("删除套装失败: %v", err)
+	}
+	if item, err := GetCartItemByUserAndProductFromRedis(userID, id); err != nil || item == nil || item.Quantity != 2 {
+		t.Fatalf("删除套装后商品行为 %+v（%v），预期数量 2", item, err)
+	}
+}
+
+// TestUpdateCartItemQuantityInRedis 存在性、库存和限购在更新脚本中检查，失败时数量不变
+func TestUpdateCartItemQuantityInRedis(t *testing.T) {
+	redistest.Open(t)
+	const userID, productID = 1, 3
+
+	if err := UpdateCartItemQuantityInRedis(userID, productID, 1, 10, 10); !errors.Is(err, ErrCartItemNotFound) {
+		t.Fatalf("购物车项不存在时返回 %v，预期 ErrCartItemNotFound", err)
+	}
+	if _, err := IncrCartItemInRedis(userID, productID, 1, 10, 10, model.Cents(6900)); err != nil {
+		t.Fatalf("加入商品失败: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		quantity int
+		want     error
+	}{
+		{"超过库存", 6, ErrCartStockExceeded},
+		{"超过限购", 4, ErrCartLimitExceeded},
+		{"成功", 3, nil},
+	}
+	for _, tt := range tests {
+		if err := UpdateCartItemQuantityInRedis(userID, productID, tt.quantity, 5, 3); !errors.Is(err, tt.want) {
+			t.Fatalf("%s: 返回 %v，预期 %v", tt.name, err, tt.want)
+		}
+	}
+	if item, err := GetCartItemByUserAndProductFromRedis(userID, productID); err != nil || item == nil || item.Quantity != 3 {
+		t.Fatalf("购物车项为 %+v（%v），预期数量 3", item, err)
+	}
+}
+
+// TestMigrateLegacyCartBundles 旧版套装键合并到用户购物车Hash，已有字段不被覆盖，迁移后删除旧键
+func TestMigrateLegacyCartBundles(t *testing.T) {
+	server := redistest.Open(t)
+	ctx := redis.GetContext()
+	const userID = 1
+
+	server.HSet(getLegacyCartBundlesKey(userID), "5", "2", "5:price", "199.00", "6", "1")
+	server.HSet(CartKeyPrefix+"2:bundle:8", "quantity", "4")
+	if _, err := IncrCartBundleInRedis(userID, 6, 3, 10, model.Cents(9900)); err != nil {
+		t.Fatalf("加入套装失败: %v", err)
+	}
+	server.Set(cartLayoutVersionKey, "2")
+
+	migrated, err := MigrateLegacyCartKeys()
+	if err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	if migrated != 3 {
+		t.Fatalf("迁移了 %d 行，预期 3 行", migrated)
+	}
+	if server.Exists(getLegacyCartBundlesKey(userID)) || server.Exists(CartKeyPrefix+"2:bundle:8") {
+		t.Fatal("迁移后旧版套装键仍然存在")
+	}
+
+	bundles, err := GetCartBundlesFromRedis(userID)
+	if err != nil || len(bundles) != 2 {
+		t.Fatalf("用户 %d 的套装行为 %+v（%v），预期 2 行", userID, bundles, err)
+	}
+	if bundles[0].BundleID != 5 || bundles[0].Quantity != 2 || bundles[0].AddedPrice == nil || *bundles[0].AddedPrice != model.Cents(19900) {
+		t.Fatalf("套装 5 迁移后为 %+v", bundles[0])
+	}
+	if bundles[1].BundleID != 6 || bundles[1].Quantity != 3 {
+		t.Fatalf("已有的套装 6 被覆盖: %+v", bundles[1])
+	}
+	if bundles, err := GetCartBundlesFromRedis(2); err != nil || len(bundles) != 1 || bundles[0].Quantity != 4 {
+		t.Fatalf("用户 2 的套装行为 %+v（%v），预期套装 8 数量 4", bundles, err)
+	}
+	if version, err := redis.Client.Get(ctx, cartLayoutVersionKey).Int(); err != nil || version != cartLayoutVersion {
+		t.Fatalf("存储版本为 %d（%v），预期 %d", version, err, cartLayoutVersion)
+	}
+}
@@ -0,0 +1,52 @@
+package dao
+
+import (
+	"shop/global/db"
+	"shop/model"
+)
+
+// purchasedQuantity 按商品汇总的购买件数
+type purchasedQuantity struct {
+	ProductID int
+	Quantity  int
+}
+
+// GetPurchasedQuantities 汇总用户购买过的商品件数，excludeStatuses 中状态的订单不计入
+func GetPurchasedQuantities(userID int, excludeStatuses []string) (map[int]int, error) {
+	var rows []purchasedQuantity
+	err := db.DB.Model(&model.OrderItem{}).
+		Select("order_items.product_id, SUM(order_items.quantity) AS quantity").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.user_id = ? AND orders.status NOT IN ?", userID, excludeStatuses).
+		Group("order_items.product_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int]int, len(rows))
+	for _, row := range rows {
+		result[row.ProductID] = row.Quantity
+	}
+	return result, nil
+}
+
+// GetProductsByIDsUnscoped 批量获取商品，包含已删除商品
+func GetProductsByIDsUnscoped(productIDs []int) ([]model.Product, error) {
+	var products []model.Product
+	if len(productIDs) == 0 {
+		return products, nil
+	}
+	err := db.DB.Unscoped().Where("id IN ?", productIDs).Find(&products).Error
+	return products, err
+}
+
+// GetProductsBySeries 获取指定系列中在售的商品
+func GetProductsBySeries(series []string) ([]model.Product, error) {
+	var products []model.Product
+	if len(series) == 0 {
+		return products, nil
+	}
+	err := db.DB.Where("series IN ?", series).Order("id").Find(&products).Error
+	return products, err
+}
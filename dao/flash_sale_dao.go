@@ -0,0 +1,115 @@
+package dao
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"shop/global/db"
+	"shop/model"
+)
+
+// CreateFlashSale 创建限时抢购
+func CreateFlashSale(sale *model.FlashSale) error {
+	return db.DB.Create(sale).Error
+}
+
+// GetFlashSaleByID 根据ID获取限时抢购（包含商品）
+func GetFlashSaleByID(saleID int) (*model.FlashSale, error) {
+	var sale model.FlashSale
+	err := db.DB.Preload("Product", func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped()
+	}).First(&sale, saleID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &sale, nil
+}
+
+// GetFlashSales 获取限时抢购列表，statuses 为空时不过滤
+func GetFlashSales(statuses []string) ([]model.FlashSale, error) {
+	var sales []model.FlashSale
+	query := db.DB.Preload("Product", func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped()
+	})
+	if len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	}
+	err := query.Order("start_at DESC, id DESC").Find(&sales).Error
+	return sales, err
+}
+
+// CountOpenFlashSales 统计商品未结束（未开始或进行中）的抢购数量
+func CountOpenFlashSales(productID int) (int64, error) {
+	var count int64
+	err := db.DB.Model(&model.FlashSale{}).
+		Where("product_id = ? AND status IN ?", productID,
+			[]string{model.FlashSaleStatusScheduled, model.FlashSaleStatusActive}).
+		Count(&count).Error
+	return count, err
+}
+
+// GetActiveFlashSaleProductIDs 获取正在抢购中的商品ID
+func GetActiveFlashSaleProductIDs() (map[int]bool, error) {
+	var ids []int
+	err := db.DB.Model(&model.FlashSale{}).
+		Where("status = ?", model.FlashSaleStatusActive).
+		Pluck("product_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		result[id] = true
+	}
+	return result, nil
+}
+
+// GetDueFlashSales 获取到达开始时间的未开始抢购
+func GetDueFlashSales(now time.Time) ([]model.FlashSale, error) {
+	var sales []model.FlashSale
+	err := db.DB.Where("status = ? AND start_at <= ?", model.FlashSaleStatusScheduled, now).
+		Order("start_at").
+		Find(&sales).Error
+	return sales, err
+}
+
+// GetEndedActiveFlashSales 获取到达结束时间但仍为进行中的抢购
+func GetEndedActiveFlashSales(now time.Time) ([]model.FlashSale, error) {
+	var sales []model.FlashSale
+	err := db.DB.Where("status = ? AND end_at <= ?", model.FlashSaleStatusActive, now).Find(&sales).Error
+	return sales, err
+}
+
+// UpdateFlashSaleStatus 条件更新抢购状态（当前状态为 from 时才更新），返回是否更新成功
+func UpdateFlashSaleStatus(saleID int, from, to string, updates map[string]interface{}) (bool, error) {
+	values := map[string]interface{}{"status": to}
+	for k, v := range updates {
+		values[k] = v
+	}
+	result := db.DB.Model(&model.FlashSale{}).
+		Where("id = ? AND status = ?", saleID, from).
+		Updates(values)
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetFlashSaleOrderByTicket 根据票据获取抢购订单
+func GetFlashSaleOrderByTicket(ticket string) (*model.FlashSaleOrder, error) {
+	var record model.FlashSaleOrder
+	err := db.DB.Where("ticket = ?", ticket).First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// CreateFlashSaleOrder 在事务中记录抢购票据对应的订单
+func CreateFlashSaleOrder(tx *gorm.DB, record *model.FlashSaleOrder) error {
+	return tx.Create(record).Error
+}
@@ -0,0 +1,233 @@
+package dao
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"shop/global/redis"
+	"shop/model"
+
+	redisv9 "github.com/redis/go-redis/v9"
+)
+
+const (
+	// FlashSaleQueueKey 待下单的抢购请求队列（列表，左进右出）
+	FlashSaleQueueKey = "flashsale:queue"
+	// FlashSaleProcessingKey 正在处理的抢购请求，处理完成后移除，服务重启时放回队列
+	FlashSaleProcessingKey = "flashsale:processing"
+	// FlashSaleTicketExpireTime 票据结果保存时间
+	FlashSaleTicketExpireTime = 24 * time.Hour
+	// flashSaleKeyGrace 抢购结束后Redis键的保留时间
+	flashSaleKeyGrace = 24 * time.Hour
+)
+
+var (
+	// ErrFlashSaleNotActive 抢购未开始或已结束
+	ErrFlashSaleNotActive = errors.New("抢购未开始或已结束")
+	// ErrFlashSaleSoldOut 抢购库存不足
+	ErrFlashSaleSoldOut = errors.New("抢购库存不足")
+	// ErrFlashSaleLimitExceeded 超过每人限购数量
+	ErrFlashSaleLimitExceeded = errors.New("超过每人限购数量")
+)
+
+// FlashSaleQueueItem 队列中的抢购请求
+type FlashSaleQueueItem struct {
+	Ticket      string `json:"ticket"`
+	FlashSaleID int    `json:"flash_sale_id"`
+	UserID      int    `json:"user_id"`
+	Quantity    int    `json:"quantity"`
+}
+
+// getFlashSaleStockKey 抢购剩余库存键
+func getFlashSaleStockKey(saleID int) string {
+	return fmt.Sprintf("flashsale:%d:stock", saleID)
+}
+
+// getFlashSaleUsersKey 抢购用户已购数量键（哈希，字段为用户ID）
+func getFlashSaleUsersKey(saleID int) string {
+	return fmt.Sprintf("flashsale:%d:users", saleID)
+}
+
+// getFlashSaleTicketKey 抢购票据键
+func getFlashSaleTicketKey(ticket string) string {
+	return "flashsale:ticket:" + ticket
+}
+
+// flashSaleReserveScript 原子地校验限购、扣减库存、写入票据并入队
+// 返回剩余库存；-1 库存不足，-2 超过限购，-3 抢购未开始或已结束
+var flashSaleReserveScript = redisv9.NewScript(`
+local stock = redis.call('GET', KEYS[1])
+if not stock then
+	return -3
+end
+local quantity = tonumber(ARGV[2])
+local bought = tonumber(redis.call('HGET', KEYS[2], ARGV[1]) or '0')
+if bought + quantity > tonumber(ARGV[3]) then
+	return -2
+end
+if tonumber(stock) < quantity then
+	return -1
+end
+local left = redis.call('DECRBY', KEYS[1], quantity)
+redis.call('HINCRBY', KEYS[2], ARGV[1], quantity)
+redis.call('SET', KEYS[4], ARGV[5], 'EX', ARGV[6])
+redis.call('LPUSH', KEYS[3], ARGV[4])
+return left
+`)
+
+// flashSaleReleaseScript 下单失败时退回抢购库存和用户已购数量，抢购已结束（库存键不存在）时不退回库存
+var flashSaleReleaseScript = redisv9.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	redis.call('INCRBY', KEYS[1], ARGV[2])
+end
+local bought = redis.call('HINCRBY', KEYS[2], ARGV[1], -tonumber(ARGV[2]))
+if bought <= 0 then
+	redis.call('HDEL', KEYS[2], ARGV[1])
+end
+return 1
+`)
+
+// LoadFlashSaleStock 预载抢购库存，已预载时不覆盖，键在抢购结束后保留一段时间自动过期
+func LoadFlashSaleStock(saleID, stock int, endAt time.Time) error {
+	ttl := time.Until(endAt) + flashSaleKeyGrace
+	return redis.Client.SetNX(redis.GetContext(), getFlashSaleStockKey(saleID), stock, ttl).Err()
+}
+
+// GetFlashSaleStock 获取抢购剩余库存，未预载时返回 -1
+func GetFlashSaleStock(saleID int) (int, error) {
+	stock, err := redis.Client.Get(redis.GetContext(), getFlashSaleStockKey(saleID)).Int()
+	if err == redisv9.Nil {
+		return -1, nil
+	}
+	return stock, err
+}
+
+// CloseFlashSaleStock 删除抢购库存键，之后的抢购请求返回未开始或已结束
+func CloseFlashSaleStock(saleID int) error {
+	return redis.Client.Del(redis.GetContext(), getFlashSaleStockKey(saleID)).Err()
+}
+
+// ReserveFlashSale 扣减抢购库存并将请求入队，返回剩余库存
+func ReserveFlashSale(item *FlashSaleQueueItem, perUserLimit int, endAt time.Time) (int, error) {
+	payload, err := json.Marshal(item)
+	if err != nil {
+		return 0, err
+	}
+	ticket, err := json.Marshal(model.FlashSaleTicket{
+		Ticket:      item.Ticket,
+		FlashSaleID: item.FlashSaleID,
+		UserID:      item.UserID,
+		Quantity:    item.Quantity,
+		Status:      model.FlashSaleTicketQueued,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	usersKey := getFlashSaleUsersKey(item.FlashSaleID)
+	left, err := flashSaleReserveScript.Run(redis.GetContext(), redis.Client,
+		[]string{getFlashSaleStockKey(item.FlashSaleID), usersKey, FlashSaleQueueKey, getFlashSaleTicketKey(item.Ticket)},
+		item.UserID, item.Quantity, perUserLimit, payload, ticket, int(FlashSaleTicketExpireTime.Seconds()),
+	).Int()
+	if err != nil {
+		return 0, err
+	}
+	switch left {
+	case -1:
+		return 0, ErrFlashSaleSoldOut
+	case -2:
+		return 0, ErrFlashSaleLimitExceeded
+	case -3:
+		return 0, ErrFlashSaleNotActive
+	}
+	redis.Client.Expire(redis.GetContext(), usersKey, time.Until(endAt)+flashSaleKeyGrace)
+	return left, nil
+}
+
+// ReleaseFlashSale 退回抢购库存和用户已购数量
+func ReleaseFlashSale(item *FlashSaleQueueItem) error {
+	return flashSaleReleaseScript.Run(redis.GetContext(), redis.Client,
+		[]string{getFlashSaleStockKey(item.FlashSaleID), getFlashSaleUsersKey(item.FlashSaleID)},
+		item.UserID, item.Quantity,
+	).Err()
+}
+
+// GetFlashSaleTicket 获取抢购票据，不存在时返回 nil
+func GetFlashSaleTicket(ticket string) (*model.FlashSaleTicket, error) {
+	data, err := redis.Client.Get(redis.GetContext(), getFlashSaleTicketKey(ticket)).Bytes()
+	if err != nil {
+		if err == redisv9.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var record model.FlashSaleTicket
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// SaveFlashSaleTicket 保存抢购票据结果
+func SaveFlashSaleTicket(record *model.FlashSaleTicket) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return redis.Client.Set(redis.GetContext(), getFlashSaleTicketKey(record.Ticket), data, FlashSaleTicketExpireTime).Err()
+}
+
+// PopFlashSaleQueue 从队列取出一个请求并放入处理中列表，队列为空时返回 nil
+func PopFlashSaleQueue() (*FlashSaleQueueItem, string, error) {
+	raw, err := redis.Client.RPopLPush(redis.GetContext(), FlashSaleQueueKey, FlashSaleProcessingKey).Result()
+	if err != nil {
+		if err == redisv9.Nil {
+			return nil, "", nil
+		}
+		return nil, "", err
+	}
+	var item FlashSaleQueueItem
+	if err := json.Unmarshal([]byte(raw), &item); err != nil {
+		// 无法解析的请求直接丢弃
+		redis.Client.LRem(redis.GetContext(), FlashSaleProcessingKey, 1, raw)
+		return nil, "", fmt.Errorf("invalid flash sale queue item %q: %w", raw, err)
+	}
+	return &item, raw, nil
+}
+
+// AckFlashSaleQueue 请求处理完成，从处理中列表移除
+func AckFlashSaleQueue(raw string) error {
+	return redis.Client.LRem(redis.GetContext(), FlashSaleProcessingKey, 1, raw).Err()
+}
+
+// RequeueFlashSaleProcessing 将处理中列表的请求全部放回队列（服务重启时恢复未完成的请求）
+func RequeueFlashSaleProcessing() (int, error) {
+	count := 0
+	for {
+		_, err := redis.Client.RPopLPush(redis.GetContext(), FlashSaleProcessingKey, FlashSaleQueueKey).Result()
+		if err == redisv9.Nil {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+}
+
+// GetFlashSaleQueueLength 获取排队中的请求数
+func GetFlashSaleQueueLength() (int64, error) {
+	return redis.Client.LLen(redis.GetContext(), FlashSaleQueueKey).Result()
+}
+
+// RetryFlashSaleQueue 将处理失败的请求从处理中列表移回队列尾部，下次优先处理
+func RetryFlashSaleQueue(raw string) error {
+	pipe := redis.Client.TxPipeline()
+	pipe.LRem(redis.GetContext(), FlashSaleProcessingKey, 1, raw)
+	pipe.RPush(redis.GetContext(), FlashSaleQueueKey, raw)
+	_, err := pipe.Exec(redis.GetContext())
+	return err
+}
@@ -0,0 +1,53 @@
+package dao
+
+import (
+	"shop/global/redis"
+	"shop/model"
+)
+
+// getGuestCartKey 获取游客购物车Redis键
+func getGuestCartKey(cartID string) string {
+	return GuestCartKeyPrefix + cartID
+}
+
+// GetGuestCartQuantities 获取游客购物车中各商品的数量
+func GetGuestCartQuantities(cartID string) (map[int]int, error) {
+	return getCartQuantities(getGuestCartKey(cartID))
+}
+
+// GetGuestCartItems 获取游客购物车项（包含商品信息），已下架商品的购物车项会被删除
+func GetGuestCartItems(cartID string) ([]model.CartItem, error) {
+	return getCartItems(getGuestCartKey(cartID), 0)
+}
+
+// GetGuestCartLines 获取游客购物车中各商品的数量和加入时的单价
+func GetGuestCartLines(cartID string) (map[int]int, map[int]model.Money, error) {
+	return getCartLines(getGuestCartKey(cartID), "")
+}
+
+// GetGuestCartItemQuantity 获取游客购物车中商品的数量，不存在时返回0
+func GetGuestCartItemQuantity(cartID string, productID int) (int, error) {
+	return getCartQuantity(getGuestCartKey(cartID), cartField("", productID))
+}
+
+// UpdateGuestCartItemQuantity 原子地更新游客购物车中已有商品的数量，商品不在购物车中时返回 ErrCartItemNotFound
+// 新数量超过 stock 时返回 ErrCartStockExceeded
+func UpdateGuestCartItemQuantity(cartID string, productID, quantity, stock int) error {
+	return updateCartQuantity(getGuestCartKey(cartID), cartField("", productID), quantity, stock, stock)
+}
+
+// IncrGuestCartItem 原子地增减游客购物车中商品的数量，新加入时记录单价 price，数量减到0时删除该商品
+// 增加数量时新数量不能超过 stock，超过时返回尝试设置的数量和 ErrCartStockExceeded
+func IncrGuestCartItem(cartID string, productID, delta, stock int, price model.Money) (int, error) {
+	return incrCartQuantity(getGuestCartKey(cartID), cartField("", productID), delta, stock, stock, price)
+}
+
+// DeleteGuestCartItem 从游客购物车删除商品
+func DeleteGuestCartItem(cartID string, productID int) error {
+	return redis.Client.HDel(redis.GetContext(), getGuestCartKey(cartID), cartFields("", productID)...).Err()
+}
+
+// DeleteGuestCart 删除整个游客购物车
+func DeleteGuestCart(cartID string) error {
+	return redis.Client.Del(redis.GetContext(), getGuestCartKey(cartID)).Err()
+}
@@ -0,0 +1,87 @@
+package dao
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"shop/global/redis"
+
+	redisv9 "github.com/redis/go-redis/v9"
+)
+
+const (
+	// IdempotencyKeyPrefix 幂等键前缀，键为 idempotency:{user_id}:{Idempotency-Key}
+	IdempotencyKeyPrefix = "idempotency:"
+	// IdempotencyExpireTime 已完成请求的响应保存时间（24小时）
+	IdempotencyExpireTime = 24 * time.Hour
+	// IdempotencyLockTime 请求处理中的占位时间，处理进程异常退出后到期自动释放
+	IdempotencyLockTime = time.Minute
+)
+
+// 幂等记录状态
+const (
+	IdempotencyStateProcessing = "processing"
+	IdempotencyStateCompleted  = "completed"
+)
+
+// IdempotencyRecord 幂等记录，完成后保存首次响应用于重放
+type IdempotencyRecord struct {
+	State       string `json:"state"`
+	Fingerprint string `json:"fingerprint"` // 请求方法、路径和请求体的摘要
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// getIdempotencyKey 获取幂等Redis键
+func getIdempotencyKey(userID int, key string) string {
+	return fmt.Sprintf("%s%d:%s", IdempotencyKeyPrefix, userID, key)
+}
+
+// AcquireIdempotencyKey 占用幂等键，成功时返回 true；已被占用时返回已有记录（记录恰好过期时为 nil）
+func AcquireIdempotencyKey(userID int, key, fingerprint string) (bool, *IdempotencyRecord, error) {
+	data, err := json.Marshal(IdempotencyRecord{
+		State:       IdempotencyStateProcessing,
+		Fingerprint: fingerprint,
+	})
+	if err != nil {
+		return false, nil, err
+	}
+
+	redisKey := getIdempotencyKey(userID, key)
+	ok, err := redis.Client.SetNX(redis.GetContext(), redisKey, data, IdempotencyLockTime).Result()
+	if err != nil {
+		return false, nil, fmt.Errorf("占用幂等键失败: %w", err)
+	}
+	if ok {
+		return true, nil, nil
+	}
+
+	raw, err := redis.Client.Get(redis.GetContext(), redisKey).Bytes()
+	if err != nil {
+		if err == redisv9.Nil {
+			return false, nil, nil
+		}
+		return false, nil, fmt.Errorf("读取幂等记录失败: %w", err)
+	}
+	var record IdempotencyRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return false, nil, fmt.Errorf("解析幂等记录失败: %w", err)
+	}
+	return false, &record, nil
+}
+
+// SaveIdempotencyResponse 保存已完成请求的响应
+func SaveIdempotencyResponse(userID int, key string, record *IdempotencyRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return redis.Client.Set(redis.GetContext(), getIdempotencyKey(userID, key), data, IdempotencyExpireTime).Err()
+}
+
+// ReleaseIdempotencyKey 释放幂等键，允许使用相同的键重试
+func ReleaseIdempotencyKey(userID int, key string) error {
+	return redis.Client.Del(redis.GetContext(), getIdempotencyKey(userID, key)).Err()
+}
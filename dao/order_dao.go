@@ -2,17 +2,19 @@ package dao
 
 import (
 	"errors"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"shop/global/db"
 	"shop/model"
 )
 
 // CreateOrder 创建订单
-func CreateOrder(userID int, totalPrice float64) (*model.Order, error) {
+func CreateOrder(userID int, totalPrice model.Money) (*model.Order, error) {
 	order := model.Order{
 		UserID:     userID,
 		TotalPrice: totalPrice,
-		Status:     "pending",
+		Status:     model.OrderStatusPendingPayment,
 	}
 	err := db.DB.Create(&order).Error
 	if err != nil {
@@ -22,7 +24,7 @@ func CreateOrder(userID int, totalPrice float64) (*model.Order, error) {
 }
 
 // CreateOrderItem 创建订单项
-func CreateOrderItem(orderID, productID, quantity int, price float64) error {
+func CreateOrderItem(orderID, productID, quantity int, price model.Money) error {
 	orderItem := model.OrderItem{
 		OrderID:   orderID,
 		ProductID: productID,
@@ -32,10 +34,21 @@ func CreateOrderItem(orderID, productID, quantity int, price float64) error {
 	return db.DB.Create(&orderItem).Error
 }
 
-// GetOrdersByUserID 获取用户的订单列表
-func GetOrdersByUserID(userID int) ([]model.Order, error) {
+// GetOrdersWithItemsByUserID 获取用户的订单列表并预加载订单项（包含已下架的商品和盲盒抽取结果）和订单优惠
+// 每一级关联只执行一次 WHERE ... IN 查询，查询次数不随订单数和订单项数增长
+func GetOrdersWithItemsByUserID(userID int) ([]model.Order, error) {
 	var orders []model.Order
-	err := db.DB.Where("user_id = ?", userID).
+	err := db.DB.Preload("Items", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("id")
+	}).Preload("Items.Product", func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped()
+	}).Preload("Items.Draws", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("sequence")
+	}).Preload("Items.Draws.Figure").
+		Preload("Discounts", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("id")
+		}).
+		Where("user_id = ?", userID).
 		Order("created_at DESC").
 		Find(&orders).Error
 	return orders, err
@@ -54,10 +67,14 @@ func GetOrderByID(orderID, userID int) (*model.Order, error) {
 	return &order, nil
 }
 
-// GetOrderItems 获取订单项
+// GetOrderItems 获取订单项（包含已下架的商品和盲盒抽取结果）
 func GetOrderItems(orderID int) ([]model.OrderItem, error) {
 	var items []model.OrderItem
-	err := db.DB.Preload("Product").Where("order_id = ?", orderID).Find(&items).Error
+	err := db.DB.Preload("Product", func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped()
+	}).Preload("Draws", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("sequence")
+	}).Preload("Draws.Figure").Where("order_id = ?", orderID).Find(&items).Error
 	return items, err
 }
 
@@ -73,3 +90,84 @@ func GetCartItemWithProduct(cartItemID, userID int) (*model.CartItem, *model.Pro
 	}
 	return &cartItem, &cartItem.Product, nil
 }
+
+// GetOrderByIDForUpdate 在事务中锁定并获取订单
+func GetOrderByIDForUpdate(tx *gorm.DB, orderID int) (*model.Order, error) {
+	var order model.Order
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+// UpdateOrderStatus 在事务中更新订单状态及附带字段
+func UpdateOrderStatus(tx *gorm.DB, orderID int, status string, extra map[string]interface{}) error {
+	updates := map[string]interface{}{"status": status}
+	for k, v := range extra {
+		updates[k] = v
+	}
+	return tx.Model(&model.Order{}).Where("id = ?", orderID).Updates(updates).Error
+}
+
+// CreateOrderStatusHistory 在事务中记录订单状态变更
+func CreateOrderStatusHistory(tx *gorm.DB, history *model.OrderStatusHistory) error {
+	return tx.Create(history).Error
+}
+
+// GetOrderStatusHistory 获取订单状态变更记录
+func GetOrderStatusHistory(orderID int) ([]model.OrderStatusHistory, error) {
+	var history []model.OrderStatusHistory
+	err := db.DB.Where("order_id = ?", orderID).Order("id").Find(&history).Error
+	return history, err
+}
+
+// GetOrdersForAdmin 分页获取订单列表（管理后台），status 为空时不过滤
+func GetOrdersForAdmin(status string, page, pageSize int) ([]model.Order, int64, error) {
+	var orders []model.Order
+	var total int64
+
+	query := db.DB.Model(&model.Order{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&orders).Error
+	return orders, total, err
+}
+
+// GetOrderByIDForAdmin 根据ID获取订单（不限用户）
+func GetOrderByIDForAdmin(orderID int) (*model.Order, error) {
+	var order model.Order
+	err := db.DB.First(&order, orderID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+// GetOrderItemsTx 在事务中获取订单项（不加载商品）
+func GetOrderItemsTx(tx *gorm.DB, orderID int) ([]model.OrderItem, error) {
+	var items []model.OrderItem
+	err := tx.Where("order_id = ?", orderID).Order("product_id").Find(&items).Error
+	return items, err
+}
+
+// GetOrdersByStatus 获取指定状态的全部订单
+func GetOrdersByStatus(status string) ([]model.Order, error) {
+	var orders []model.Order
+	err := db.DB.Where("status = ?", status).Find(&orders).Error
+	return orders, err
+}
@@ -0,0 +1,87 @@
+package dao
+
+import (
+	"fmt"
+	"testing"
+
+	"shop/global/db"
+	"shop/global/db/dbtest"
+	"shop/model"
+)
+
+// orderListQueries 订单列表执行的查询数：订单、订单项、商品、盲盒抽取结果、款式、订单优惠各一次，与订单数无关
+const orderListQueries = 6
+
+// BenchmarkGetOrdersWithItemsByUserID 查询 1、10、100 个订单的订单列表，查询次数必须保持为 orderListQueries
+func BenchmarkGetOrdersWithItemsByUserID(b *testing.B) {
+	for _, orders := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("orders=%d", orders), func(b *testing.B) {
+			conn := dbtest.Open(b, &model.Product{}, &model.Order{}, &model.OrderItem{}, &model.OrderDiscount{},
+				&model.BlindBoxFigure{}, &model.BlindBoxDraw{})
+
+			const userID = 1
+			seedOrders(b, userID, orders)
+
+			queries := dbtest.CountQueries(b, conn)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				list, err := GetOrdersWithItemsByUserID(userID)
+				if err != nil {
+					b.Fatalf("查询订单列表失败: %v", err)
+				}
+				if len(list) != orders {
+					b.Fatalf("查询到 %d 个订单，预期 %d 个", len(list), orders)
+				}
+			}
+			b.StopTimer()
+
+			if got := queries.Count(); got != int64(orderListQueries*b.N) {
+				b.Fatalf("%d 个订单每次查询执行了 %.1f 次查询，预期 %d 次", orders, float64(got)/float64(b.N), orderListQueries)
+			}
+		})
+	}
+}
+
+// seedOrders 为用户创建 n 个订单，每个订单包含一个普通商品、一个已抽取的盲盒和一项优惠，覆盖订单列表预加载的所有关联
+func seedOrders(b *testing.B, userID, n int) {
+	b.Helper()
+
+	product := model.Product{Name: "拉布布 心动马卡龙", Price: model.Cents(9900), Stock: 1000}
+	blindBox := model.Product{Name: "拉布布 盲盒", Price: model.Cents(6900), Stock: 1000}
+	if err := db.DB.Create(&[]*model.Product{&product, &blindBox}).Error; err != nil {
+		b.Fatalf("创建商品失败: %v", err)
+	}
+	figure := model.BlindBoxFigure{SeriesID: 1, Name: "款式A", Weight: 1, Stock: 1000}
+	if err := db.DB.Create(&figure).Error; err != nil {
+		b.Fatalf("创建款式失败: %v", err)
+	}
+
+	for i := 0; i < n; i++ {
+		order := model.Order{
+			UserID:         userID,
+			TotalPrice:     model.Cents(15800),
+			DiscountAmount: model.Cents(1000),
+			Status:         model.OrderStatusPendingPayment,
+			Items: []model.OrderItem{
+				{ProductID: product.ID, Quantity: 1, Price: product.Price},
+				{ProductID: blindBox.ID, Quantity: 1, Price: blindBox.Price},
+			},
+			Discounts: []model.OrderDiscount{{PromotionID: 1, Name: "满100减10", Amount: model.Cents(1000)}},
+		}
+		if err := db.DB.Create(&order).Error; err != nil {
+			b.Fatalf("创建订单失败: %v", err)
+		}
+		draw := model.BlindBoxDraw{
+			OrderID:     order.ID,
+			OrderItemID: order.Items[1].ID,
+			UserID:      userID,
+			SeriesID:    figure.SeriesID,
+			FigureID:    figure.ID,
+			TotalWeight: 1,
+			Pool:        fmt.Sprintf("%d:1", figure.ID),
+		}
+		if err := db.DB.Create(&draw).Error; err != nil {
+			b.Fatalf("创建抽取记录失败: %v", err)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package dao
+
+import (
+	"strconv"
+	"time"
+
+	"shop/global/redis"
+
+	redisv9 "github.com/redis/go-redis/v9"
+)
+
+// OrderExpiryKey 未支付订单延迟队列（有序集合，成员为订单ID，分数为支付截止时间戳）
+const OrderExpiryKey = "order:expire"
+
+// ScheduleOrderExpiry 将订单加入延迟队列，已存在时不覆盖截止时间
+func ScheduleOrderExpiry(orderID int, deadline time.Time) error {
+	return redis.Client.ZAddNX(redis.GetContext(), OrderExpiryKey, redisv9.Z{
+		Score:  float64(deadline.Unix()),
+		Member: orderID,
+	}).Err()
+}
+
+// RescheduleOrderExpiry 重新设置订单的截止时间（处理失败后延后重试）
+func RescheduleOrderExpiry(orderID int, deadline time.Time) error {
+	return redis.Client.ZAdd(redis.GetContext(), OrderExpiryKey, redisv9.Z{
+		Score:  float64(deadline.Unix()),
+		Member: orderID,
+	}).Err()
+}
+
+// RemoveOrderExpiry 将订单移出延迟队列
+func RemoveOrderExpiry(orderID int) error {
+	return redis.Client.ZRem(redis.GetContext(), OrderExpiryKey, orderID).Err()
+}
+
+// GetDueOrderExpiries 获取截止时间已到的订单ID
+func GetDueOrderExpiries(now time.Time, limit int64) ([]int, error) {
+	members, err := redis.Client.ZRangeByScore(redis.GetContext(), OrderExpiryKey, &redisv9.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.Unix(), 10),
+		Count: limit,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	orderIDs := make([]int, 0, len(members))
+	for _, member := range members {
+		if orderID, err := strconv.Atoi(member); err == nil {
+			orderIDs = append(orderIDs, orderID)
+		}
+	}
+	return orderIDs, nil
+}
+
+// ClaimOrderExpiry 从队列中移除订单，返回 true 表示由当前实例处理（多实例部署时避免重复处理）
+func ClaimOrderExpiry(orderID int) (bool, error) {
+	n, err := redis.Client.ZRem(redis.GetContext(), OrderExpiryKey, orderID).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,76 @@
+package dao
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"shop/global/db"
+	"shop/model"
+)
+
+// CreatePayment 创建支付记录
+func CreatePayment(payment *model.Payment) error {
+	return db.DB.Create(payment).Error
+}
+
+// UpdatePayment 更新支付记录字段
+func UpdatePayment(paymentID int, updates map[string]interface{}) error {
+	return db.DB.Model(&model.Payment{}).Where("id = ?", paymentID).Updates(updates).Error
+}
+
+// UpdatePaymentTx 在事务中更新支付记录字段
+func UpdatePaymentTx(tx *gorm.DB, paymentID int, updates map[string]interface{}) error {
+	return tx.Model(&model.Payment{}).Where("id = ?", paymentID).Updates(updates).Error
+}
+
+// GetPendingPayment 获取订单在指定渠道上进行中的支付
+func GetPendingPayment(orderID int, provider string) (*model.Payment, error) {
+	var payment model.Payment
+	err := db.DB.Where("order_id = ? AND provider = ? AND status = ? AND provider_ref IS NOT NULL",
+		orderID, provider, model.PaymentStatusPending).
+		Order("id DESC").
+		First(&payment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetPaymentByProviderRef 根据渠道流水号获取支付记录
+func GetPaymentByProviderRef(provider, providerRef string) (*model.Payment, error) {
+	var payment model.Payment
+	err := db.DB.Where("provider = ? AND provider_ref = ?", provider, providerRef).First(&payment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetPaymentByProviderRefForUpdate 在事务中锁定并获取支付记录
+func GetPaymentByProviderRefForUpdate(tx *gorm.DB, provider, providerRef string) (*model.Payment, error) {
+	var payment model.Payment
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("provider = ? AND provider_ref = ?", provider, providerRef).
+		First(&payment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetPaymentsByOrderID 获取订单的全部支付记录
+func GetPaymentsByOrderID(orderID int) ([]model.Payment, error) {
+	var payments []model.Payment
+	err := db.DB.Where("order_id = ?", orderID).Order("id").Find(&payments).Error
+	return payments, err
+}
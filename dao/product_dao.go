@@ -2,18 +2,90 @@ package dao
 
 import (
 	"errors"
+	"strings"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"shop/global/db"
 	"shop/model"
 )
 
-// GetProducts 获取所有商品
-func GetProducts() ([]model.Product, error) {
+// ErrInsufficientStock 库存不足以完成扣减
+var ErrInsufficientStock = errors.New("库存不足")
+
+// likeEscaper 转义 LIKE 通配符
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// applyProductFilters 应用商品列表过滤条件
+func applyProductFilters(query *gorm.DB, q *model.ProductListQuery) *gorm.DB {
+	if q.Series != "" {
+		query = query.Where("series = ?", q.Series)
+	}
+	if q.MinPrice != nil {
+		query = query.Where("price >= ?", *q.MinPrice)
+	}
+	if q.MaxPrice != nil {
+		query = query.Where("price <= ?", *q.MaxPrice)
+	}
+	if q.InStock {
+		query = query.Where("stock > 0")
+	}
+	if q.Keyword != "" {
+		pattern := "%" + likeEscaper.Replace(q.Keyword) + "%"
+		query = query.Where("name LIKE ? OR description LIKE ? OR series LIKE ?", pattern, pattern, pattern)
+	}
+	return query
+}
+
+// applyProductSort 应用排序和游标条件，排序字段相同时按ID保证顺序稳定
+func applyProductSort(query *gorm.DB, sort string, cursor *model.ProductCursor) *gorm.DB {
+	switch sort {
+	case model.ProductSortPriceAsc:
+		if cursor != nil {
+			query = query.Where("price > ? OR (price = ? AND id > ?)", cursor.Price, cursor.Price, cursor.ID)
+		}
+		return query.Order("price ASC, id ASC")
+	case model.ProductSortPriceDesc:
+		if cursor != nil {
+			query = query.Where("price < ? OR (price = ? AND id < ?)", cursor.Price, cursor.Price, cursor.ID)
+		}
+		return query.Order("price DESC, id DESC")
+	case model.ProductSortNewest:
+		if cursor != nil && cursor.CreatedAt != nil {
+			query = query.Where("created_at < ? OR (created_at = ? AND id < ?)", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+		}
+		return query.Order("created_at DESC, id DESC")
+	case model.ProductSortBestSelling:
+		if cursor != nil {
+			query = query.Where("sales_count < ? OR (sales_count = ? AND id < ?)", cursor.SalesCount, cursor.SalesCount, cursor.ID)
+		}
+		return query.Order("sales_count DESC, id DESC")
+	default:
+		if cursor != nil {
+			query = query.Where("id < ?", cursor.ID)
+		}
+		return query.Order("id DESC")
+	}
+}
+
+// GetProductList 按条件查询商品列表，cursor 非空时从游标位置之后开始
+func GetProductList(q *model.ProductListQuery, cursor *model.ProductCursor, offset, limit int) ([]model.Product, error) {
 	var products []model.Product
-	err := db.DB.Order("id DESC").Find(&products).Error
+	query := applyProductFilters(db.DB.Model(&model.Product{}), q)
+	err := applyProductSort(query, q.Sort, cursor).
+		Offset(offset).
+		Limit(limit).
+		Find(&products).Error
 	return products, err
 }
 
+// CountProducts 统计满足过滤条件的商品数量
+func CountProducts(q *model.ProductListQuery) (int64, error) {
+	var total int64
+	err := applyProductFilters(db.DB.Model(&model.Product{}), q).Count(&total).Error
+	return total, err
+}
+
 // GetProductByID 根据ID获取商品
 func GetProductByID(id string) (*model.Product, error) {
 	var product model.Product
@@ -27,6 +99,32 @@ func GetProductByID(id string) (*model.Product, error) {
 	return &product, nil
 }
 
+// GetProductsByIDs 批量获取在售商品（一次 WHERE id IN 查询），返回商品ID到商品的映射，已删除的商品不在结果中
+func GetProductsByIDs(productIDs []int) (map[int]model.Product, error) {
+	result := make(map[int]model.Product, len(productIDs))
+	if len(productIDs) == 0 {
+		return result, nil
+	}
+	var products []model.Product
+	if err := db.DB.Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range products {
+		result[p.ID] = p
+	}
+	return result, nil
+}
+
+// GetProductIDsBySeries 获取系列下所有在售商品的ID，按ID升序，不分页
+func GetProductIDsBySeries(series string) ([]int, error) {
+	var ids []int
+	err := db.DB.Model(&model.Product{}).
+		Where("series = ?", series).
+		Order("id").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
 // GetProductStock 获取商品库存
 func GetProductStock(productID int) (int, error) {
 	var product model.Product
@@ -37,9 +135,147 @@ func GetProductStock(productID int) (int, error) {
 	return product.Stock, nil
 }
 
-// UpdateProductStock 更新商品库存
-func UpdateProductStock(productID, quantity int) error {
+// GetProductStockTx 在事务中获取商品库存
+func GetProductStockTx(tx *gorm.DB, productID int) (int, error) {
+	var product model.Product
+	err := tx.Select("stock").First(&product, productID).Error
+	if err != nil {
+		return 0, err
+	}
+	return product.Stock, nil
+}
+
+// GetProductByIDTx 在事务中根据ID获取商品
+func GetProductByIDTx(tx *gorm.DB, productID int) (*model.Product, error) {
+	var product model.Product
+	err := tx.First(&product, productID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &product, nil
+}
+
+// GetProductByIDForUpdate 在事务中根据ID获取商品并加行锁（SELECT ... FOR UPDATE），锁持有到事务结束
+func GetProductByIDForUpdate(tx *gorm.DB, productID int) (*model.Product, error) {
+	var product model.Product
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &product, nil
+}
+
+// DecreaseProductStock 条件扣减库存（stock >= quantity 才会扣减），库存不足时返回 ErrInsufficientStock
+// 扣减和库存判断在同一条UPDATE中完成，并发下单不会超卖
+func DecreaseProductStock(tx *gorm.DB, productID, quantity int) error {
+	result := tx.Model(&model.Product{}).
+		Where("id = ? AND stock >= ?", productID, quantity).
+		Update("stock", gorm.Expr("stock - ?", quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+// IncreaseProductStock 在事务中归还库存（包含已下架商品，下架后重新上架时库存依然准确）
+func IncreaseProductStock(tx *gorm.DB, productID, quantity int) error {
+	return tx.Unscoped().Model(&model.Product{}).
+		Where("id = ?", productID).
+		Update("stock", gorm.Expr("stock + ?", quantity)).Error
+}
+
+// IncreaseProductSales 在事务中调整商品销量，delta 为负数时表示订单取消或退款，销量最低为0
+// 使用 CASE WHEN 而不是 MySQL 特有的 GREATEST，SQLite 测试库执行的是同一条语句
+func IncreaseProductSales(tx *gorm.DB, productID, delta int) error {
+	return tx.Unscoped().Model(&model.Product{}).
+		Where("id = ?", productID).
+		Update("sales_count", gorm.Expr("CASE WHEN sales_count + ? < 0 THEN 0 ELSE sales_count + ? END", delta, delta)).Error
+}
+
+// GetProductsForAdmin 获取商品列表（管理后台），可包含已删除商品
+func GetProductsForAdmin(includeDeleted bool) ([]model.Product, error) {
+	var products []model.Product
+	query := db.DB.Order("id DESC")
+	if includeDeleted {
+		query = query.Unscoped()
+	}
+	err := query.Find(&products).Error
+	return products, err
+}
+
+// GetProductByIDUnscoped 根据ID获取商品，包含已删除商品
+func GetProductByIDUnscoped(productID int) (*model.Product, error) {
+	var product model.Product
+	err := db.DB.Unscoped().First(&product, productID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &product, nil
+}
+
+// CreateProduct 创建商品
+func CreateProduct(product *model.Product) error {
+	return db.DB.Create(product).Error
+}
+
+// UpdateProduct 更新商品字段
+func UpdateProduct(productID int, updates map[string]interface{}) error {
 	return db.DB.Model(&model.Product{}).
 		Where("id = ?", productID).
-		Update("stock", gorm.Expr("stock - ?", quantity)).Error
+		Updates(updates).Error
+}
+
+// DeleteProduct 软删除商品
+func DeleteProduct(productID int) error {
+	return db.DB.Delete(&model.Product{}, productID).Error
+}
+
+// AdjustProductStock 调整商品库存并记录调整原因，库存不允许调整为负数
+func AdjustProductStock(adjustment *model.StockAdjustment) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		return AdjustProductStockTx(tx, adjustment)
+	})
+}
+
+// AdjustProductStockTx 在事务中调整商品库存并记录调整原因
+func AdjustProductStockTx(tx *gorm.DB, adjustment *model.StockAdjustment) error {
+	result := tx.Model(&model.Product{}).
+		Where("id = ? AND stock + ? >= 0", adjustment.ProductID, adjustment.Delta).
+		Update("stock", gorm.Expr("stock + ?", adjustment.Delta))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+
+	var product model.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("stock").First(&product, adjustment.ProductID).Error; err != nil {
+		return err
+	}
+	adjustment.StockAfter = product.Stock
+
+	return tx.Create(adjustment).Error
+}
+
+// GetStockAdjustments 获取商品的库存调整记录
+func GetStockAdjustments(productID int) ([]model.StockAdjustment, error) {
+	var adjustments []model.StockAdjustment
+	err := db.DB.Where("product_id = ?", productID).
+		Order("id DESC").
+		Find(&adjustments).Error
+	return adjustments, err
 }
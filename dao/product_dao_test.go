@@ -0,0 +1,42 @@
+package dao
+
+import (
+	"testing"
+
+	"shop/global/db"
+	"shop/global/db/dbtest"
+	"shop/model"
+)
+
+func TestIncreaseProductSales(t *testing.T) {
+	dbtest.Open(t, &model.Product{})
+
+	product := model.Product{Name: "拉布布 心动马卡龙", Price: model.Cents(9900), Stock: 10, SalesCount: 3}
+	if err := db.DB.Create(&product).Error; err != nil {
+		t.Fatalf("创建商品失败: %v", err)
+	}
+
+	// 依次调整销量，扣减超过现有销量时停在0
+	steps := []struct {
+		delta int
+		want  int
+	}{
+		{delta: 2, want: 5},
+		{delta: -4, want: 1},
+		{delta: -3, want: 0},
+		{delta: 0, want: 0},
+		{delta: 1, want: 1},
+	}
+	for _, step := range steps {
+		if err := IncreaseProductSales(db.DB, product.ID, step.delta); err != nil {
+			t.Fatalf("调整销量 %+d 失败: %v", step.delta, err)
+		}
+		var got model.Product
+		if err := db.DB.Select("sales_count").First(&got, product.ID).Error; err != nil {
+			t.Fatalf("查询商品失败: %v", err)
+		}
+		if got.SalesCount != step.want {
+			t.Fatalf("调整销量 %+d 后为 %d，预期 %d", step.delta, got.SalesCount, step.want)
+		}
+	}
+}
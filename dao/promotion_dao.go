@@ -0,0 +1,179 @@
+package dao
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"shop/global/db"
+	"shop/model"
+)
+
+// CreatePromotion 创建促销
+func CreatePromotion(promotion *model.Promotion) error {
+	return db.DB.Create(promotion).Error
+}
+
+// GetPromotionByID 根据ID获取促销
+func GetPromotionByID(promotionID int) (*model.Promotion, error) {
+	var promotion model.Promotion
+	err := db.DB.First(&promotion, promotionID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// GetPromotions 获取全部促销（管理后台）
+func GetPromotions() ([]model.Promotion, error) {
+	var promotions []model.Promotion
+	err := db.DB.Order("id DESC").Find(&promotions).Error
+	return promotions, err
+}
+
+// GetActivePromotions 获取 now 时刻生效的促销，autoOnly 为 true 时只返回自动应用的促销
+func GetActivePromotions(tx *gorm.DB, now time.Time, autoOnly bool) ([]model.Promotion, error) {
+	var promotions []model.Promotion
+	query := tx.Where("active = ? AND start_at <= ? AND end_at > ?", true, now, now)
+	if autoOnly {
+		query = query.Where("auto_apply = ?", true)
+	}
+	err := query.Order("id").Find(&promotions).Error
+	return promotions, err
+}
+
+// UpdatePromotion 更新促销字段
+func UpdatePromotion(promotionID int, updates map[string]interface{}) error {
+	return db.DB.Model(&model.Promotion{}).Where("id = ?", promotionID).Updates(updates).Error
+}
+
+// CreateCoupon 创建优惠码
+func CreateCoupon(coupon *model.Coupon) error {
+	return db.DB.Create(coupon).Error
+}
+
+// GetCouponByID 根据ID获取优惠码（包含促销）
+func GetCouponByID(couponID int) (*model.Coupon, error) {
+	var coupon model.Coupon
+	err := db.DB.Preload("Promotion").First(&coupon, couponID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+// GetCoupons 获取优惠码列表（包含促销），promotionID 为0时不过滤
+func GetCoupons(promotionID int) ([]model.Coupon, error) {
+	var coupons []model.Coupon
+	query := db.DB.Preload("Promotion")
+	if promotionID != 0 {
+		query = query.Where("promotion_id = ?", promotionID)
+	}
+	err := query.Order("id DESC").Find(&coupons).Error
+	return coupons, err
+}
+
+// GetCouponByCode 根据优惠码获取优惠码（包含促销），lock 为 true 时锁定优惠码行
+func GetCouponByCode(tx *gorm.DB, code string, lock bool) (*model.Coupon, error) {
+	var coupon model.Coupon
+	query := tx.Where("code = ?", code)
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	if err := query.First(&coupon).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var promotion model.Promotion
+	if err := tx.First(&promotion, coupon.PromotionID).Error; err != nil {
+		return nil, err
+	}
+	coupon.Promotion = promotion
+	return &coupon, nil
+}
+
+// UpdateCoupon 更新优惠码字段
+func UpdateCoupon(couponID int, updates map[string]interface{}) error {
+	return db.DB.Model(&model.Coupon{}).Where("id = ?", couponID).Updates(updates).Error
+}
+
+// IncreaseCouponUsedCount 在事务中调整优惠码已使用次数，释放时最低减到0（CASE WHEN 在 MySQL 和 SQLite 上都可用）
+func IncreaseCouponUsedCount(tx *gorm.DB, couponID, delta int) error {
+	return tx.Model(&model.Coupon{}).Where("id = ?", couponID).
+		Update("used_count", gorm.Expr("CASE WHEN used_count + ? < 0 THEN 0 ELSE used_count + ? END", delta, delta)).Error
+}
+
+// CountCouponRedemptions 统计用户对优惠码的有效使用次数（不含已释放的）
+func CountCouponRedemptions(tx *gorm.DB, couponID, userID int) (int64, error) {
+	var count int64
+	err := tx.Model(&model.CouponRedemption{}).
+		Where("coupon_id = ? AND user_id = ? AND status = ?", couponID, userID, model.CouponRedemptionApplied).
+		Count(&count).Error
+	return count, err
+}
+
+// CreateCouponRedemption 在事务中记录优惠码使用
+func CreateCouponRedemption(tx *gorm.DB, redemption *model.CouponRedemption) error {
+	return tx.Create(redemption).Error
+}
+
+// GetAppliedCouponRedemptions 在事务中获取订单的有效优惠码使用记录
+func GetAppliedCouponRedemptions(tx *gorm.DB, orderID int) ([]model.CouponRedemption, error) {
+	var redemptions []model.CouponRedemption
+	err := tx.Where("order_id = ? AND status = ?", orderID, model.CouponRedemptionApplied).
+		Order("id").Find(&redemptions).Error
+	return redemptions, err
+}
+
+// UpdateCouponRedemptionStatus 在事务中更新优惠码使用记录状态
+func UpdateCouponRedemptionStatus(tx *gorm.DB, redemptionID int, status string) error {
+	return tx.Model(&model.CouponRedemption{}).Where("id = ?", redemptionID).Update("status", status).Error
+}
+
+// CreateOrderDiscounts 在事务中记录订单使用的优惠
+func CreateOrderDiscounts(tx *gorm.DB, discounts []model.OrderDiscount) error {
+	if len(discounts) == 0 {
+		return nil
+	}
+	return tx.Create(&discounts).Error
+}
+
+// GetOrderDiscounts 获取订单使用的优惠
+func GetOrderDiscounts(orderID int) ([]model.OrderDiscount, error) {
+	var discounts []model.OrderDiscount
+	err := db.DB.Where("order_id = ?", orderID).Order("id").Find(&discounts).Error
+	return discounts, err
+}
+
+// UpdateOrderTotals 在事务中更新订单应付金额和优惠金额
+func UpdateOrderTotals(tx *gorm.DB, orderID int, totalPrice, discountAmount model.Money) error {
+	return tx.Model(&model.Order{}).Where("id = ?", orderID).Updates(map[string]interface{}{
+		"total_price":     totalPrice,
+		"discount_amount": discountAmount,
+	}).Error
+}
+
+// GetProductSeriesTx 在事务中批量获取商品所属系列（包含已删除商品）
+func GetProductSeriesTx(tx *gorm.DB, productIDs []int) (map[int]string, error) {
+	result := make(map[int]string, len(productIDs))
+	if len(productIDs) == 0 {
+		return result, nil
+	}
+	var products []model.Product
+	if err := tx.Unscoped().Select("id", "series").Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range products {
+		result[p.ID] = p.Series
+	}
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package dao
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"shop/model"
+)
+
+// purchaseRecord 用户的一条有效购买记录（订单项）
+type purchaseRecord struct {
+	ProductID int
+	Quantity  int
+	CreatedAt time.Time
+}
+
+// GetPurchaseUsage 汇总用户对指定商品的有效购买量，excludeStatuses 中状态的订单不计入，dayStart 之后下的单计入今日购买量
+// 逐行读取订单项后在内存中汇总，不对聚合查询加锁；lock 为 true 时对读取的订单项和订单加行锁（锁定读），
+// 读到的是其他事务已提交的最新数据而不是事务快照，用于下单事务中的最终校验。
+// 锁定读不能阻止并发事务插入新的订单项，调用方需要先持有商品行锁，使同一商品的下单按顺序执行
+func GetPurchaseUsage(tx *gorm.DB, userID int, productIDs []int, excludeStatuses []string, dayStart time.Time, lock bool) (map[int]model.PurchaseUsage, error) {
+	result := make(map[int]model.PurchaseUsage, len(productIDs))
+	if len(productIDs) == 0 {
+		return result, nil
+	}
+
+	query := tx.Model(&model.OrderItem{}).
+		Select("order_items.product_id, order_items.quantity, orders.created_at").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.user_id = ? AND order_items.product_id IN ? AND orders.status NOT IN ?",
+			userID, productIDs, excludeStatuses)
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var records []purchaseRecord
+	if err := query.Scan(&records).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		u := result[r.ProductID]
+		u.ProductID = r.ProductID
+		u.Lifetime += r.Quantity
+		if !r.CreatedAt.Before(dayStart) {
+			u.Today += r.Quantity
+		}
+		if u.LastOrderAt == nil || r.CreatedAt.After(*u.LastOrderAt) {
+			createdAt := r.CreatedAt
+			u.LastOrderAt = &createdAt
+		}
+		result[r.ProductID] = u
+	}
+	return result, nil
+}
@@ -0,0 +1,173 @@
+package dao
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"shop/global/db"
+	"shop/model"
+)
+
+// CreateRaffle 创建抽签
+func CreateRaffle(raffle *model.Raffle) error {
+	return db.DB.Create(raffle).Error
+}
+
+// GetRaffleByID 根据ID获取抽签（包含商品）
+func GetRaffleByID(raffleID int) (*model.Raffle, error) {
+	var raffle model.Raffle
+	err := db.DB.Preload("Product", func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped()
+	}).First(&raffle, raffleID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &raffle, nil
+}
+
+// GetRaffleForUpdate 在事务中获取并锁定抽签
+func GetRaffleForUpdate(tx *gorm.DB, raffleID int) (*model.Raffle, error) {
+	var raffle model.Raffle
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&raffle, raffleID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &raffle, nil
+}
+
+// GetRaffles 获取抽签列表，statuses 为空时不过滤
+func GetRaffles(statuses []string) ([]model.Raffle, error) {
+	var raffles []model.Raffle
+	query := db.DB.Preload("Product", func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped()
+	})
+	if len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	}
+	err := query.Order("entry_end_at DESC, id DESC").Find(&raffles).Error
+	return raffles, err
+}
+
+// CountOpenRaffles 统计商品未开奖或中签者仍可购买的抽签数量
+func CountOpenRaffles(productID int, now time.Time) (int64, error) {
+	var count int64
+	err := db.DB.Model(&model.Raffle{}).
+		Where("product_id = ? AND (status = ? OR (status = ? AND checkout_deadline > ?))",
+			productID, model.RaffleStatusOpen, model.RaffleStatusDrawn, now).
+		Count(&count).Error
+	return count, err
+}
+
+// GetReservedRaffleProductIDs 获取被抽签占用的商品ID（报名中、等待开奖或中签者仍可购买）
+func GetReservedRaffleProductIDs(now time.Time) (map[int]bool, error) {
+	var ids []int
+	err := db.DB.Model(&model.Raffle{}).
+		Where("status = ? OR (status = ? AND checkout_deadline > ?)",
+			model.RaffleStatusOpen, model.RaffleStatusDrawn, now).
+		Pluck("product_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		result[id] = true
+	}
+	return result, nil
+}
+
+// GetDueRaffleIDs 获取报名已截止但尚未开奖的抽签ID
+func GetDueRaffleIDs(now time.Time) ([]int, error) {
+	var ids []int
+	err := db.DB.Model(&model.Raffle{}).
+		Where("status = ? AND entry_end_at <= ?", model.RaffleStatusOpen, now).
+		Order("entry_end_at").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// UpdateRaffle 在事务中更新抽签字段
+func UpdateRaffle(tx *gorm.DB, raffleID int, updates map[string]interface{}) error {
+	return tx.Model(&model.Raffle{}).Where("id = ?", raffleID).Updates(updates).Error
+}
+
+// CountRaffleEntries 统计抽签报名人数
+func CountRaffleEntries(raffleID int) (int64, error) {
+	var count int64
+	err := db.DB.Model(&model.RaffleEntry{}).Where("raffle_id = ?", raffleID).Count(&count).Error
+	return count, err
+}
+
+// CreateRaffleEntry 在事务中创建报名，用户已报名时不插入，返回是否新建
+func CreateRaffleEntry(tx *gorm.DB, entry *model.RaffleEntry) (bool, error) {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(entry)
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetRaffleEntry 获取用户在抽签中的报名
+func GetRaffleEntry(raffleID, userID int) (*model.RaffleEntry, error) {
+	var entry model.RaffleEntry
+	err := db.DB.Where("raffle_id = ? AND user_id = ?", raffleID, userID).First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetRaffleEntryForUpdate 在事务中获取并锁定用户的报名
+func GetRaffleEntryForUpdate(tx *gorm.DB, raffleID, userID int) (*model.RaffleEntry, error) {
+	var entry model.RaffleEntry
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("raffle_id = ? AND user_id = ?", raffleID, userID).
+		First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetRaffleEntryIDs 获取抽签全部报名ID（升序）
+func GetRaffleEntryIDs(tx *gorm.DB, raffleID int) ([]int, error) {
+	var ids []int
+	err := tx.Model(&model.RaffleEntry{}).
+		Where("raffle_id = ?", raffleID).
+		Order("id").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// UpdateRaffleEntries 在事务中批量更新报名
+func UpdateRaffleEntries(tx *gorm.DB, entryIDs []int, updates map[string]interface{}) error {
+	if len(entryIDs) == 0 {
+		return nil
+	}
+	return tx.Model(&model.RaffleEntry{}).Where("id IN ?", entryIDs).Updates(updates).Error
+}
+
+// MarkRaffleLosers 在事务中将抽签中仍为已报名状态的报名标记为未中签
+func MarkRaffleLosers(tx *gorm.DB, raffleID int) error {
+	return tx.Model(&model.RaffleEntry{}).
+		Where("raffle_id = ? AND status = ?", raffleID, model.RaffleEntryEntered).
+		Update("status", model.RaffleEntryLost).Error
+}
+
+// ExpireRaffleWins 将超过购买截止时间仍未下单的中签报名标记为已过期，返回更新数量
+func ExpireRaffleWins(now time.Time) (int64, error) {
+	result := db.DB.Model(&model.RaffleEntry{}).
+		Where("status = ? AND checkout_deadline <= ?", model.RaffleEntryWon, now).
+		Update("status", model.RaffleEntryExpired)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,145 @@
+package dao
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"shop/global/redis"
+	"shop/model"
+)
+
+const (
+	// SessionKeyPrefix 会话键前缀，键为 session:{sid}
+	SessionKeyPrefix = "session:"
+	// UserSessionsKeyPrefix 用户会话索引键前缀，集合中保存用户的所有会话ID
+	UserSessionsKeyPrefix = "session:user:"
+)
+
+// getSessionKey 获取会话Redis键
+func getSessionKey(sessionID string) string {
+	return SessionKeyPrefix + sessionID
+}
+
+// getUserSessionsKey 获取用户会话索引Redis键
+func getUserSessionsKey(userID int) string {
+	return fmt.Sprintf("%s%d", UserSessionsKeyPrefix, userID)
+}
+
+// CreateSession 创建会话并加入用户会话索引
+func CreateSession(session *model.Session, ttl time.Duration) error {
+	key := getSessionKey(session.ID)
+	userKey := getUserSessionsKey(session.UserID)
+
+	pipe := redis.Client.TxPipeline()
+	pipe.HSet(redis.GetContext(), key, map[string]interface{}{
+		"user_id":      session.UserID,
+		"device":       session.Device,
+		"user_agent":   session.UserAgent,
+		"ip":           session.IP,
+		"created_at":   session.CreatedAt.Unix(),
+		"last_seen_at": session.LastSeenAt.Unix(),
+	})
+	pipe.Expire(redis.GetContext(), key, ttl)
+	pipe.SAdd(redis.GetContext(), userKey, session.ID)
+	pipe.Expire(redis.GetContext(), userKey, ttl)
+	if _, err := pipe.Exec(redis.GetContext()); err != nil {
+		return fmt.Errorf("创建会话失败: %w", err)
+	}
+	return nil
+}
+
+// GetSession 获取会话，不存在（已注销或已过期）时返回nil
+func GetSession(sessionID string) (*model.Session, error) {
+	data, err := redis.Client.HGetAll(redis.GetContext(), getSessionKey(sessionID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	userID, err := strconv.Atoi(data["user_id"])
+	if err != nil {
+		return nil, nil
+	}
+	createdAt, _ := strconv.ParseInt(data["created_at"], 10, 64)
+	lastSeenAt, _ := strconv.ParseInt(data["last_seen_at"], 10, 64)
+
+	return &model.Session{
+		ID:         sessionID,
+		UserID:     userID,
+		Device:     data["device"],
+		UserAgent:  data["user_agent"],
+		IP:         data["ip"],
+		CreatedAt:  time.Unix(createdAt, 0),
+		LastSeenAt: time.Unix(lastSeenAt, 0),
+	}, nil
+}
+
+// TouchSession 更新会话最后活跃时间并续期
+func TouchSession(userID int, sessionID string, ttl time.Duration) error {
+	key := getSessionKey(sessionID)
+	pipe := redis.Client.TxPipeline()
+	pipe.HSet(redis.GetContext(), key, "last_seen_at", time.Now().Unix())
+	pipe.Expire(redis.GetContext(), key, ttl)
+	pipe.Expire(redis.GetContext(), getUserSessionsKey(userID), ttl)
+	_, err := pipe.Exec(redis.GetContext())
+	return err
+}
+
+// SessionExists 检查会话是否有效
+func SessionExists(sessionID string) (bool, error) {
+	n, err := redis.Client.Exists(redis.GetContext(), getSessionKey(sessionID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// GetSessionsByUserID 获取用户的所有有效会话，顺带清理索引中已过期的会话ID
+func GetSessionsByUserID(userID int) ([]model.Session, error) {
+	userKey := getUserSessionsKey(userID)
+	sessionIDs, err := redis.Client.SMembers(redis.GetContext(), userKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("获取会话列表失败: %w", err)
+	}
+
+	sessions := []model.Session{}
+	for _, sessionID := range sessionIDs {
+		session, err := GetSession(sessionID)
+		if err != nil {
+			return nil, err
+		}
+		if session == nil {
+			redis.Client.SRem(redis.GetContext(), userKey, sessionID)
+			continue
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, nil
+}
+
+// DeleteSession 删除会话
+func DeleteSession(userID int, sessionID string) error {
+	pipe := redis.Client.TxPipeline()
+	pipe.Del(redis.GetContext(), getSessionKey(sessionID))
+	pipe.SRem(redis.GetContext(), getUserSessionsKey(userID), sessionID)
+	_, err := pipe.Exec(redis.GetContext())
+	return err
+}
+
+// DeleteAllSessions 删除用户的所有会话
+func DeleteAllSessions(userID int) error {
+	userKey := getUserSessionsKey(userID)
+	sessionIDs, err := redis.Client.SMembers(redis.GetContext(), userKey).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{userKey}
+	for _, sessionID := range sessionIDs {
+		keys = append(keys, getSessionKey(sessionID))
+	}
+	return redis.Client.Del(redis.GetContext(), keys...).Err()
+}
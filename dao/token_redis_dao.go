@@ -0,0 +1,83 @@
+package dao
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"shop/global/redis"
+
+	redisv9 "github.com/redis/go-redis/v9"
+)
+
+// RefreshTokenKeyPrefix 刷新令牌键前缀（键中保存令牌摘要，不保存明文）
+const RefreshTokenKeyPrefix = "auth:refresh:"
+
+// RefreshTokenRecord 刷新令牌记录
+type RefreshTokenRecord struct {
+	UserID    int
+	SessionID string // 同一次登录轮换出的令牌属于同一个会话
+	Used      bool   // 已被轮换过，再次出现说明令牌泄露
+}
+
+// consumeRefreshTokenScript 原子地读取刷新令牌并标记为已使用
+var consumeRefreshTokenScript = redisv9.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return false
+end
+local data = redis.call('HMGET', KEYS[1], 'user_id', 'session_id', 'used')
+if data[3] ~= '1' then
+	redis.call('HSET', KEYS[1], 'used', '1')
+end
+return data
+`)
+
+// getRefreshTokenKey 获取刷新令牌Redis键
+func getRefreshTokenKey(tokenHash string) string {
+	return RefreshTokenKeyPrefix + tokenHash
+}
+
+// SaveRefreshToken 保存刷新令牌
+func SaveRefreshToken(tokenHash string, userID int, sessionID string, ttl time.Duration) error {
+	key := getRefreshTokenKey(tokenHash)
+	pipe := redis.Client.TxPipeline()
+	pipe.HSet(redis.GetContext(), key, map[string]interface{}{
+		"user_id":    userID,
+		"session_id": sessionID,
+		"used":       0,
+	})
+	pipe.Expire(redis.GetContext(), key, ttl)
+	if _, err := pipe.Exec(redis.GetContext()); err != nil {
+		return fmt.Errorf("保存刷新令牌失败: %w", err)
+	}
+	return nil
+}
+
+// ConsumeRefreshToken 消费刷新令牌，不存在时返回nil
+// 已使用的令牌会保留到过期，用于识别重放
+func ConsumeRefreshToken(tokenHash string) (*RefreshTokenRecord, error) {
+	res, err := consumeRefreshTokenScript.Run(redis.GetContext(), redis.Client, []string{getRefreshTokenKey(tokenHash)}).Slice()
+	if err != nil {
+		if err == redisv9.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取刷新令牌失败: %w", err)
+	}
+	if len(res) != 3 {
+		return nil, nil
+	}
+
+	userIDStr, _ := res[0].(string)
+	sessionID, _ := res[1].(string)
+	used, _ := res[2].(string)
+	userID, err := strconv.Atoi(userIDStr)
+	if err != nil {
+		return nil, nil
+	}
+
+	return &RefreshTokenRecord{
+		UserID:    userID,
+		SessionID: sessionID,
+		Used:      used == "1",
+	}, nil
+}
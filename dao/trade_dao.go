@@ -0,0 +1,220 @@
+package dao
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"shop/global/db"
+	"shop/model"
+)
+
+// preloadOwnedItem 预加载物品的商品（包含已下架商品）和盲盒款式
+func preloadOwnedItem(query *gorm.DB, prefix string) *gorm.DB {
+	return query.Preload(prefix+"Product", func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped()
+	}).Preload(prefix + "Figure")
+}
+
+// GetUnsyncedOrderItemsForUpdate 在事务中获取并锁定用户已完成订单中尚未进入库存的订单项（包含盲盒抽取结果）
+// 订单行同时被锁定，避免与退款并发时为已退款订单写入库存
+func GetUnsyncedOrderItemsForUpdate(tx *gorm.DB, userID int) ([]model.OrderItem, error) {
+	var items []model.OrderItem
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Draws").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.user_id = ? AND orders.status = ?", userID, model.OrderStatusCompleted).
+		Where("NOT EXISTS (SELECT 1 FROM owned_items WHERE owned_items.order_item_id = order_items.id)").
+		Order("order_items.id").
+		Find(&items).Error
+	return items, err
+}
+
+// CreateOwnedItems 在事务中批量写入库存物品，已存在的（订单项, 件序号）会被忽略，并发同步时不会重复
+func CreateOwnedItems(tx *gorm.DB, items []model.OwnedItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&items).Error
+}
+
+// GetOwnedItems 获取用户的库存物品
+func GetOwnedItems(userID int) ([]model.OwnedItem, error) {
+	var items []model.OwnedItem
+	err := preloadOwnedItem(db.DB, "").
+		Where("user_id = ?", userID).
+		Order("product_id, id").
+		Find(&items).Error
+	return items, err
+}
+
+// GetOwnedItemByID 根据ID获取库存物品
+func GetOwnedItemByID(itemID int) (*model.OwnedItem, error) {
+	var item model.OwnedItem
+	err := preloadOwnedItem(db.DB, "").First(&item, itemID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+// GetOwnedItemsByIDs 批量获取库存物品
+func GetOwnedItemsByIDs(itemIDs []int) ([]model.OwnedItem, error) {
+	var items []model.OwnedItem
+	err := db.DB.Where("id IN ?", itemIDs).Find(&items).Error
+	return items, err
+}
+
+// UpdateOwnedItemListed 更新物品是否挂出交换
+func UpdateOwnedItemListed(itemID int, listed bool) error {
+	return db.DB.Model(&model.OwnedItem{}).Where("id = ?", itemID).Update("listed", listed).Error
+}
+
+// GetListedItems 分页获取其他用户挂出的物品，productID 为0、series 为空时不过滤
+func GetListedItems(excludeUserID, productID int, series string, page, pageSize int) ([]model.OwnedItem, int64, error) {
+	var items []model.OwnedItem
+	var total int64
+
+	query := db.DB.Model(&model.OwnedItem{}).
+		Where("owned_items.listed = ? AND owned_items.user_id <> ?", true, excludeUserID)
+	if productID != 0 {
+		query = query.Where("owned_items.product_id = ?", productID)
+	}
+	if series != "" {
+		query = query.Joins("JOIN products ON products.id = owned_items.product_id").
+			Where("products.series = ?", series)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := preloadOwnedItem(query, "").
+		Select("owned_items.*").
+		Order("owned_items.updated_at DESC, owned_items.id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&items).Error
+	return items, total, err
+}
+
+// GetOwnedItemsForUpdate 在事务中按ID顺序锁定物品
+func GetOwnedItemsForUpdate(tx *gorm.DB, itemIDs []int) ([]model.OwnedItem, error) {
+	var items []model.OwnedItem
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", itemIDs).
+		Order("id").
+		Find(&items).Error
+	return items, err
+}
+
+// TransferOwnedItems 在事务中将物品转给新的拥有者，转让后取消挂出
+func TransferOwnedItems(tx *gorm.DB, itemIDs []int, toUserID int) error {
+	return tx.Model(&model.OwnedItem{}).Where("id IN ?", itemIDs).Updates(map[string]interface{}{
+		"user_id": toUserID,
+		"listed":  false,
+		"source":  model.OwnedItemSourceTrade,
+	}).Error
+}
+
+// GetOwnedItemsByOrderForUpdate 在事务中锁定订单进入库存的物品
+func GetOwnedItemsByOrderForUpdate(tx *gorm.DB, orderID int) ([]model.OwnedItem, error) {
+	var items []model.OwnedItem
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ?", orderID).
+		Order("id").
+		Find(&items).Error
+	return items, err
+}
+
+// DeleteOwnedItemsByOrder 在事务中收回订单进入库存的物品（软删除）
+func DeleteOwnedItemsByOrder(tx *gorm.DB, orderID int) error {
+	return tx.Where("order_id = ?", orderID).Delete(&model.OwnedItem{}).Error
+}
+
+// CreateTradeOffer 在事务中创建报价及其物品
+func CreateTradeOffer(tx *gorm.DB, offer *model.TradeOffer, items []model.TradeOfferItem) error {
+	if err := tx.Omit("Items", "Events").Create(offer).Error; err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].OfferID = offer.ID
+	}
+	return tx.Omit("OwnedItem").Create(&items).Error
+}
+
+// preloadTradeOffer 预加载报价物品，已收回的物品也会加载
+func preloadTradeOffer(query *gorm.DB) *gorm.DB {
+	query = query.Preload("Items.OwnedItem", func(tx *gorm.DB) *gorm.DB {
+		return tx.Unscoped()
+	})
+	return preloadOwnedItem(query, "Items.OwnedItem.")
+}
+
+// GetTradeOfferByID 获取报价详情（包含物品和状态变更记录）
+func GetTradeOfferByID(offerID int) (*model.TradeOffer, error) {
+	var offer model.TradeOffer
+	err := preloadTradeOffer(db.DB).
+		Preload("Events", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("id")
+		}).
+		First(&offer, offerID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &offer, nil
+}
+
+// GetTradeOfferForUpdate 在事务中锁定报价并加载物品
+func GetTradeOfferForUpdate(tx *gorm.DB, offerID int) (*model.TradeOffer, error) {
+	var offer model.TradeOffer
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&offer, offerID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &offer, nil
+}
+
+// UpdateTradeOfferStatus 在事务中更新报价状态
+func UpdateTradeOfferStatus(tx *gorm.DB, offerID int, status string) error {
+	return tx.Model(&model.TradeOffer{}).Where("id = ?", offerID).Update("status", status).Error
+}
+
+// CreateTradeOfferEvent 在事务中记录报价状态变更
+func CreateTradeOfferEvent(tx *gorm.DB, event *model.TradeOfferEvent) error {
+	return tx.Create(event).Error
+}
+
+// GetTradeOffers 分页获取用户收到（incoming）或发出（outgoing）的报价，status 为空时不过滤
+func GetTradeOffers(userID int, box, status string, page, pageSize int) ([]model.TradeOffer, int64, error) {
+	var offers []model.TradeOffer
+	var total int64
+
+	query := db.DB.Model(&model.TradeOffer{})
+	if box == "incoming" {
+		query = query.Where("to_user_id = ?", userID)
+	} else {
+		query = query.Where("from_user_id = ?", userID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := preloadTradeOffer(query).
+		Order("id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&offers).Error
+	return offers, total, err
+}
@@ -33,6 +33,7 @@ func CreateUser(username, password, email string) (int64, error) {
 		Username: username,
 		Password: password,
 		Email:    email,
+		Role:     model.RoleCustomer,
 	}
 	err := db.DB.Create(&user).Error
 	if err != nil {
@@ -40,3 +41,45 @@ func CreateUser(username, password, email string) (int64, error) {
 	}
 	return int64(user.ID), nil
 }
+
+// GetUserByID 根据ID获取用户
+func GetUserByID(userID int) (*model.User, error) {
+	var user model.User
+	err := db.DB.First(&user, userID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUsers 分页获取用户列表
+func GetUsers(page, pageSize int) ([]model.User, int64, error) {
+	var users []model.User
+	var total int64
+	if err := db.DB.Model(&model.User{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := db.DB.Order("id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&users).Error
+	return users, total, err
+}
+
+// UpdateUserRole 更新用户角色
+func UpdateUserRole(userID int, role string) error {
+	return db.DB.Model(&model.User{}).
+		Where("id = ?", userID).
+		Update("role", role).Error
+}
+
+// UpdateUserRoleByUsername 根据用户名更新用户角色，返回受影响的行数
+func UpdateUserRoleByUsername(username, role string) (int64, error) {
+	result := db.DB.Model(&model.User{}).
+		Where("username = ?", username).
+		Update("role", role)
+	return result.RowsAffected, result.Error
+}
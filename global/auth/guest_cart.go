@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+// guestCartTokenPurpose 签名时附加的用途前缀，避免与其他签名数据混用
+const guestCartTokenPurpose = "guest-cart:"
+
+// NewGuestCartToken 生成游客购物车ID和签名令牌，令牌格式为 {kid}.{cartID}.{签名}
+func NewGuestCartToken() (token, cartID string, err error) {
+	cartID, err = randomString(16)
+	if err != nil {
+		return "", "", err
+	}
+	return activeKeyID + "." + cartID + "." + signGuestCart(signingKeys[activeKeyID], cartID), cartID, nil
+}
+
+// ParseGuestCartToken 校验游客购物车令牌签名，返回购物车ID；签名密钥轮换后旧令牌在旧密钥保留期间仍然有效
+func ParseGuestCartToken(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[1] == "" {
+		return "", ErrTokenInvalid
+	}
+	key, ok := signingKeys[parts[0]]
+	if !ok {
+		return "", ErrTokenInvalid
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(signGuestCart(key, parts[1]))) {
+		return "", ErrTokenInvalid
+	}
+	return parts[1], nil
+}
+
+// signGuestCart 计算游客购物车ID的签名
+func signGuestCart(key []byte, cartID string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(guestCartTokenPurpose + cartID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"shop/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minKeyLength HMAC密钥最小长度（字节）
+const minKeyLength = 32
+
+var (
+	// ErrTokenExpired 令牌已过期
+	ErrTokenExpired = errors.New("token已过期")
+	// ErrTokenInvalid 令牌无效（签名错误、格式错误或声明不匹配）
+	ErrTokenInvalid = errors.New("无效的token")
+)
+
+var (
+	signingKeys     map[string][]byte
+	activeKeyID     string
+	issuer          string
+	audience        string
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+)
+
+// Claims 访问令牌声明
+type Claims struct {
+	UserID    int    `json:"uid"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid"` // 会话ID，注销后该会话签发的令牌全部失效
+	jwt.RegisteredClaims
+}
+
+// InitAuth 初始化令牌签名配置
+func InitAuth(cfg config.AuthConfig) error {
+	if len(cfg.SigningKeys) == 0 {
+		return errors.New("auth.signing_keys 不能为空")
+	}
+	if _, ok := cfg.SigningKeys[cfg.ActiveKeyID]; !ok {
+		return fmt.Errorf("auth.active_key_id %q 不在 auth.signing_keys 中", cfg.ActiveKeyID)
+	}
+
+	keys := make(map[string][]byte, len(cfg.SigningKeys))
+	for kid, secret := range cfg.SigningKeys {
+		if len(secret) < minKeyLength {
+			return fmt.Errorf("auth.signing_keys.%s 长度不足 %d 字节", kid, minKeyLength)
+		}
+		keys[kid] = []byte(secret)
+	}
+
+	signingKeys = keys
+	activeKeyID = cfg.ActiveKeyID
+	issuer = cfg.Issuer
+	audience = cfg.Audience
+	accessTokenTTL = cfg.AccessTokenTTL
+	refreshTokenTTL = cfg.RefreshTokenTTL
+	return nil
+}
+
+// IssueAccessToken 使用当前密钥签发访问令牌
+func IssueAccessToken(userID int, role, sessionID string) (string, *Claims, error) {
+	jti, err := randomString(16)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	claims := &Claims{
+		UserID:    userID,
+		Role:      role,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    issuer,
+			Subject:   strconv.Itoa(userID),
+			Audience:  jwt.ClaimStrings{audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTokenTTL)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = activeKeyID
+	signed, err := token.SignedString(signingKeys[activeKeyID])
+	if err != nil {
+		return "", nil, fmt.Errorf("签发token失败: %w", err)
+	}
+	return signed, claims, nil
+}
+
+// ParseAccessToken 验证签名、有效期、签发者和受众，返回令牌声明
+func ParseAccessToken(tokenString string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, lookupKey,
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, ErrTokenInvalid
+	}
+	if claims.UserID == 0 || claims.SessionID == "" {
+		return nil, ErrTokenInvalid
+	}
+	return &claims, nil
+}
+
+// lookupKey 根据令牌头中的 kid 选择验签密钥
+func lookupKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := signingKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+// NewSessionID 生成随机会话ID
+func NewSessionID() (string, error) {
+	return randomString(16)
+}
+
+// NewRefreshToken 生成随机刷新令牌
+func NewRefreshToken() (string, error) {
+	return randomString(32)
+}
+
+// HashRefreshToken 计算刷新令牌的摘要，Redis中只保存摘要
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// AccessTokenTTL 访问令牌有效期
+func AccessTokenTTL() time.Duration {
+	return accessTokenTTL
+}
+
+// RefreshTokenTTL 刷新令牌有效期
+func RefreshTokenTTL() time.Duration {
+	return refreshTokenTTL
+}
+
+// randomString 生成URL安全的随机字符串
+func randomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,127 @@
+// Package dbtest 为测试和基准测试提供独立的数据库
+//
+// 默认使用临时目录中的 SQLite 数据库，按传入的模型 AutoMigrate 建表；
+// 设置环境变量 SHOP_TEST_MYSQL_DSN 时改用该 MySQL 库并执行全部迁移（会清空该库，只能指向专用的测试库）
+package dbtest
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"shop/global/db"
+)
+
+// MySQLDSNEnv 指定测试用 MySQL 库的环境变量
+const MySQLDSNEnv = "SHOP_TEST_MYSQL_DSN"
+
+// Open 打开测试数据库并设置为 db.DB，测试结束时关闭并恢复原来的连接
+func Open(tb testing.TB, models ...interface{}) *gorm.DB {
+	tb.Helper()
+
+	var conn *gorm.DB
+	if dsn := os.Getenv(MySQLDSNEnv); dsn != "" {
+		conn = openMySQL(tb, dsn)
+	} else {
+		conn = openSQLite(tb, models)
+	}
+
+	prev := db.DB
+	db.DB = conn
+	tb.Cleanup(func() {
+		db.DB = prev
+		if sqlDB, err := conn.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return conn
+}
+
+func openMySQL(tb testing.TB, dsn string) *gorm.DB {
+	conn, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		tb.Fatalf("打开测试数据库失败: %v", err)
+	}
+
+	prev := db.DB
+	db.DB = conn
+	defer func() { db.DB = prev }()
+	if err := db.ResetDatabase(); err != nil {
+		tb.Fatalf("初始化测试数据库失败: %v", err)
+	}
+	return conn
+}
+
+func openSQLite(tb testing.TB, models []interface{}) *gorm.DB {
+	// WAL 允许读写并发；写事务使用 BEGIN IMMEDIATE 加锁，并发事务排队等待而不是立即返回 SQLITE_BUSY
+	dsn := "file:" + filepath.Join(tb.TempDir(), "shop.db") +
+		"?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+	conn, err := gorm.Open(sqliteDialector{sqlite.Open(dsn)}, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		tb.Fatalf("打开测试数据库失败: %v", err)
+	}
+	if err := conn.AutoMigrate(models...); err != nil {
+		tb.Fatalf("创建测试表失败: %v", err)
+	}
+	return conn
+}
+
+// sqliteDialector 不创建索引的 SQLite 方言
+// SQLite 的索引名在整个库内唯一，模型中各表同名的索引（如 idx_order_id）会冲突；测试数据量小，不需要索引
+// 唯一索引同样不会创建，依赖唯一约束的测试需要设置 SHOP_TEST_MYSQL_DSN 使用 MySQL
+type sqliteDialector struct {
+	gorm.Dialector
+}
+
+// Migrator 返回跳过索引创建的迁移器
+func (d sqliteDialector) Migrator(conn *gorm.DB) gorm.Migrator {
+	return sqliteMigrator{d.Dialector.Migrator(conn)}
+}
+
+type sqliteMigrator struct {
+	gorm.Migrator
+}
+
+// CreateIndex 跳过索引创建
+func (sqliteMigrator) CreateIndex(interface{}, string) error {
+	return nil
+}
+
+// QueryCounter 统计 conn 上执行的查询语句数
+type QueryCounter struct {
+	n atomic.Int64
+}
+
+// CountQueries 在 conn 上注册回调，统计之后执行的 SELECT（包括 Raw、Row 和 Preload 产生的查询）
+func CountQueries(tb testing.TB, conn *gorm.DB) *QueryCounter {
+	tb.Helper()
+
+	c := &QueryCounter{}
+	count := func(*gorm.DB) { c.n.Add(1) }
+	callbacks := conn.Callback()
+	for _, err := range []error{
+		callbacks.Query().After("gorm:query").Register("dbtest:count_query", count),
+		callbacks.Row().After("gorm:row").Register("dbtest:count_row", count),
+		callbacks.Raw().After("gorm:raw").Register("dbtest:count_raw", count),
+	} {
+		if err != nil {
+			tb.Fatalf("注册查询计数回调失败: %v", err)
+		}
+	}
+	return c
+}
+
+// Count 已统计的查询数
+func (c *QueryCounter) Count() int64 {
+	return c.n.Load()
+}
+
+// Reset 清零计数
+func (c *QueryCounter) Reset() {
+	c.n.Store(0)
+}
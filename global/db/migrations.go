@@ -1,82 +1,327 @@
 package db
 
 import (
+	"embed"
+	"fmt"
 	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
-	"shop/model"
+	"gorm.io/gorm"
 )
 
-// CreateTables 创建数据库表
-func CreateTables() error {
-	// 如果表已存在但结构不匹配，先删除外键约束和表（仅开发环境）
-	// 生产环境请谨慎使用，建议手动迁移
-	if err := dropTablesIfExists(); err != nil {
-		log.Printf("Warning: Failed to drop existing tables: %v", err)
-		// 继续执行，让 AutoMigrate 尝试修复
-	}
-
-	err := DB.AutoMigrate(
-		&model.User{},
-		&model.Product{},
-		&model.CartItem{},
-		&model.Order{},
-		&model.OrderItem{},
-	)
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationFilePattern 迁移文件名格式：{版本号}_{名称}.{up|down}.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration 一个版本的迁移脚本
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus 迁移状态
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// schemaMigration schema_migrations 表中的记录
+type schemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName 指定表名
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// loadMigrations 读取内嵌的迁移脚本，按版本号升序返回
+func loadMigrations() ([]Migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		matches := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		version, _ := strconv.ParseInt(matches[1], 10, 64)
+		content, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = m
+		} else if m.Name != matches[2] {
+			return nil, fmt.Errorf("migration version %d has conflicting names: %s, %s", version, m.Name, matches[2])
+		}
+
+		if matches[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s must have both up and down scripts", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
 	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// ensureMigrationsTable 创建 schema_migrations 表
+func ensureMigrationsTable() error {
+	return DB.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    applied_at DATETIME(3) NOT NULL,
+    PRIMARY KEY (version)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`).Error
+}
+
+// appliedMigrations 查询已执行的迁移
+func appliedMigrations() (map[int64]schemaMigration, error) {
+	if err := ensureMigrationsTable(); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var records []schemaMigration
+	if err := DB.Order("version").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+
+	applied := make(map[int64]schemaMigration, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// splitStatements 按行尾分号拆分SQL语句，迁移脚本中每条语句必须以分号结尾
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
 
-	log.Println("Database tables created successfully")
+// execScript 逐条执行迁移脚本（MySQL的DDL无法回滚，失败时需人工处理）
+func execScript(script string) error {
+	for _, stmt := range splitStatements(script) {
+		if err := DB.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("%w\nstatement: %s", err, stmt)
+		}
+	}
 	return nil
 }
 
-// dropTablesIfExists 删除已存在的表（用于解决类型不匹配问题）
-func dropTablesIfExists() error {
-	tables := []string{"order_items", "cart_items", "orders", "products", "users"}
+// legacyColumn 迁移系统引入前由 AutoMigrate 创建的表中缺少的列
+type legacyColumn struct {
+	table  string
+	column string
+	alter  []string
+}
 
-	for _, table := range tables {
-		// 检查表是否存在
-		var exists bool
-		err := DB.Raw("SELECT COUNT(*) > 0 FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?", table).Scan(&exists).Error
-		if err != nil {
+// legacyColumns 0001_init 中新增、但旧版 AutoMigrate 建表时没有的列
+// 0001 使用 CREATE TABLE IF NOT EXISTS，在已有表上不会补充这些列，需要单独 ALTER
+var legacyColumns = []legacyColumn{
+	{"users", "role", []string{
+		"ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'customer' AFTER email",
+	}},
+	{"products", "deleted_at", []string{
+		"ALTER TABLE products ADD COLUMN deleted_at DATETIME(3) NULL AFTER updated_at",
+		"ALTER TABLE products ADD INDEX idx_products_deleted_at (deleted_at)",
+	}},
+}
+
+// upgradeLegacySchema 为迁移系统引入前由 AutoMigrate 创建的数据库补齐 0001_init 的列
+// 只处理已存在但缺少列的表，新库和已经补齐的库上为空操作；每次执行迁移前都会检查，
+// 因此在此之前已经把 0001 记录为已执行的旧库也会被修复
+func upgradeLegacySchema() error {
+	for _, c := range legacyColumns {
+		var tables, columns int64
+		if err := DB.Raw("SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?",
+			c.table).Scan(&tables).Error; err != nil {
+			return err
+		}
+		if tables == 0 {
+			continue
+		}
+		if err := DB.Raw("SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?",
+			c.table, c.column).Scan(&columns).Error; err != nil {
+			return err
+		}
+		if columns > 0 {
 			continue
 		}
 
-		if exists {
-			// 删除表（会同时删除外键约束）
-			if err := DB.Exec("DROP TABLE IF EXISTS " + table).Error; err != nil {
-				log.Printf("Warning: Failed to drop table %s: %v", table, err)
-			} else {
-				log.Printf("Dropped existing table: %s", table)
+		log.Printf("Upgrading legacy table %s: adding column %s", c.table, c.column)
+		for _, stmt := range c.alter {
+			if err := DB.Exec(stmt).Error; err != nil {
+				return fmt.Errorf("%w\nstatement: %s", err, stmt)
 			}
 		}
 	}
-
 	return nil
 }
 
-// SeedProducts 初始化商品数据
-func SeedProducts() error {
-	products := []model.Product{
-		{Name: "拉布布 经典款", Description: "经典拉布布盲盒，随机款式", Price: 59.00, Image: "https://via.placeholder.com/300x300?text=拉布布经典款", Stock: 100, Series: "拉布布"},
-		{Name: "拉布布 限定款", Description: "限定版拉布布盲盒，稀有款式", Price: 89.00, Image: "https://via.placeholder.com/300x300?text=拉布布限定款", Stock: 50, Series: "拉布布"},
-		{Name: "拉布布 隐藏款", Description: "隐藏款拉布布盲盒，超稀有", Price: 199.00, Image: "https://via.placeholder.com/300x300?text=拉布布隐藏款", Stock: 10, Series: "拉布布"},
-		{Name: "拉布布 套装", Description: "拉布布系列套装，包含多个款式", Price: 299.00, Image: "https://via.placeholder.com/300x300?text=拉布布套装", Stock: 30, Series: "拉布布"},
-		{Name: "拉布布 特别版", Description: "特别版拉布布盲盒", Price: 129.00, Image: "https://via.placeholder.com/300x300?text=拉布布特别版", Stock: 25, Series: "拉布布"},
-	}
-
-	for _, p := range products {
-		// 使用 FirstOrCreate 避免重复插入
-		var existingProduct model.Product
-		result := DB.Where("name = ?", p.Name).First(&existingProduct)
-		if result.Error != nil {
-			// 如果不存在则创建
-			if err := DB.Create(&p).Error; err != nil {
-				log.Printf("Error seeding product %s: %v", p.Name, err)
+// MigrateUp 执行所有未执行的迁移，返回执行的数量
+func MigrateUp() (int, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	applied, err := appliedMigrations()
+	if err != nil {
+		return 0, err
+	}
+	if err := upgradeLegacySchema(); err != nil {
+		return 0, fmt.Errorf("failed to upgrade legacy schema: %w", err)
+	}
+
+	count := 0
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		log.Printf("Applying migration %04d_%s", m.Version, m.Name)
+		if err := execScript(m.Up); err != nil {
+			return count, fmt.Errorf("migration %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+		record := schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}
+		if err := DB.Create(&record).Error; err != nil {
+			return count, fmt.Errorf("failed to record migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		count++
+	}
+
+	log.Printf("Database migrations up to date (%d applied)", count)
+	return count, nil
+}
+
+// MigrateDown 回滚最近执行的 steps 个迁移，返回回滚的数量
+func MigrateDown(steps int) (int, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	applied, err := appliedMigrations()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(migrations) - 1; i >= 0 && count < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+
+		log.Printf("Reverting migration %04d_%s", m.Version, m.Name)
+		if err := execScript(m.Down); err != nil {
+			return count, fmt.Errorf("revert %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+		if err := DB.Delete(&schemaMigration{}, m.Version).Error; err != nil {
+			return count, fmt.Errorf("failed to remove migration record %04d_%s: %w", m.Version, m.Name, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// GetMigrationStatus 获取所有迁移的执行状态
+func GetMigrationStatus() ([]MigrationStatus, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status := MigrationStatus{Version: m.Version, Name: m.Name}
+		if r, ok := applied[m.Version]; ok {
+			appliedAt := r.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// ResetDatabase 删除当前库中的所有表后重新执行迁移（仅限开发环境，会清空所有数据）
+func ResetDatabase() error {
+	var tables []string
+	if err := DB.Raw("SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE()").
+		Scan(&tables).Error; err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	// 外键检查开关是会话级的，必须在同一个连接上执行
+	err := DB.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SET FOREIGN_KEY_CHECKS = 0").Error; err != nil {
+			return err
+		}
+		defer conn.Exec("SET FOREIGN_KEY_CHECKS = 1")
+
+		for _, table := range tables {
+			if err := conn.Exec("DROP TABLE IF EXISTS `" + table + "`").Error; err != nil {
+				return fmt.Errorf("failed to drop table %s: %w", table, err)
 			}
+			log.Printf("Dropped table: %s", table)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	log.Println("Products seeded successfully")
-	return nil
+	_, err = MigrateUp()
+	return err
 }
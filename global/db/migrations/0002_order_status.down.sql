@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS order_status_history;
+ALTER TABLE orders DROP INDEX idx_orders_status;
+ALTER TABLE orders DROP COLUMN tracking_number;
+ALTER TABLE orders MODIFY status VARCHAR(20) DEFAULT 'pending';
+UPDATE orders SET status = 'pending' WHERE status = 'pending_payment';
@@ -0,0 +1,18 @@
+-- 订单状态机：pending 统一改为 pending_payment，新增物流单号和状态变更记录
+UPDATE orders SET status = 'pending_payment' WHERE status = 'pending' OR status IS NULL;
+ALTER TABLE orders MODIFY status VARCHAR(20) NOT NULL DEFAULT 'pending_payment';
+ALTER TABLE orders ADD COLUMN tracking_number VARCHAR(64) NULL AFTER status;
+ALTER TABLE orders ADD INDEX idx_orders_status (status);
+
+CREATE TABLE order_status_history (
+    id INT NOT NULL AUTO_INCREMENT,
+    order_id INT NOT NULL,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    operator_id INT NOT NULL DEFAULT 0,
+    reason VARCHAR(255),
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_order_id (order_id),
+    CONSTRAINT fk_order_status_history_order FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,20 @@
+-- 支付记录：每次发起支付一条，记录渠道、金额、渠道流水号和结果
+CREATE TABLE payments (
+    id INT NOT NULL AUTO_INCREMENT,
+    order_id INT NOT NULL,
+    user_id INT NOT NULL,
+    provider VARCHAR(32) NOT NULL,
+    provider_ref VARCHAR(128) NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    pay_url VARCHAR(500),
+    failure_reason VARCHAR(255),
+    event_id VARCHAR(128),
+    paid_at DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_order_id (order_id),
+    UNIQUE INDEX idx_provider_ref (provider, provider_ref),
+    CONSTRAINT fk_payments_order FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
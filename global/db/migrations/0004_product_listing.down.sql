@@ -0,0 +1,6 @@
+ALTER TABLE products
+    DROP INDEX idx_products_series,
+    DROP INDEX idx_products_price,
+    DROP INDEX idx_products_created_at,
+    DROP INDEX idx_products_sales_count;
+ALTER TABLE products DROP COLUMN sales_count;
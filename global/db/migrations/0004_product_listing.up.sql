@@ -0,0 +1,18 @@
+-- 商品列表：新增销量字段（按有效订单回填）和过滤排序用的索引
+ALTER TABLE products ADD COLUMN sales_count INT NOT NULL DEFAULT 0 AFTER series;
+
+UPDATE products p
+JOIN (
+    SELECT oi.product_id, SUM(oi.quantity) AS sold
+    FROM order_items oi
+    JOIN orders o ON o.id = oi.order_id
+    WHERE o.status NOT IN ('cancelled', 'refunded')
+    GROUP BY oi.product_id
+) s ON s.product_id = p.id
+SET p.sales_count = s.sold;
+
+ALTER TABLE products
+    ADD INDEX idx_products_series (series),
+    ADD INDEX idx_products_price (price),
+    ADD INDEX idx_products_created_at (created_at),
+    ADD INDEX idx_products_sales_count (sales_count);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS blind_box_draws;
+ALTER TABLE order_items DROP COLUMN draw_seed;
+DROP TABLE IF EXISTS blind_box_figures;
+DROP TABLE IF EXISTS blind_box_series;
@@ -0,0 +1,49 @@
+-- 盲盒：系列绑定盲盒商品，款式有抽取权重和独立库存，支付后抽取结果记录到订单项
+CREATE TABLE blind_box_series (
+    id INT NOT NULL AUTO_INCREMENT,
+    product_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_product_id (product_id),
+    CONSTRAINT fk_blind_box_series_product FOREIGN KEY (product_id) REFERENCES products (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE blind_box_figures (
+    id INT NOT NULL AUTO_INCREMENT,
+    series_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    image VARCHAR(500),
+    weight INT NOT NULL,
+    stock INT NOT NULL DEFAULT 0,
+    is_hidden TINYINT(1) NOT NULL DEFAULT 0,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_series_id (series_id),
+    CONSTRAINT fk_blind_box_figures_series FOREIGN KEY (series_id) REFERENCES blind_box_series (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+ALTER TABLE order_items ADD COLUMN draw_seed VARCHAR(64) NULL AFTER price;
+
+CREATE TABLE blind_box_draws (
+    id INT NOT NULL AUTO_INCREMENT,
+    order_id INT NOT NULL,
+    order_item_id INT NOT NULL,
+    user_id INT NOT NULL,
+    series_id INT NOT NULL,
+    figure_id INT NOT NULL,
+    sequence INT NOT NULL,
+    roll INT NOT NULL,
+    total_weight INT NOT NULL,
+    pool TEXT NOT NULL,
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_order_id (order_id),
+    INDEX idx_order_item_id (order_item_id),
+    INDEX idx_user_id (user_id),
+    CONSTRAINT fk_blind_box_draws_order_item FOREIGN KEY (order_item_id) REFERENCES order_items (id) ON DELETE CASCADE,
+    CONSTRAINT fk_blind_box_draws_figure FOREIGN KEY (figure_id) REFERENCES blind_box_figures (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+ALTER TABLE order_items DROP INDEX idx_bundle_id, DROP COLUMN bundle_id;
+DROP TABLE IF EXISTS bundle_items;
+DROP TABLE IF EXISTS bundles;
@@ -0,0 +1,26 @@
+-- 套装：一组商品以套装价整体购买，下单时套装价分摊到各成员订单项
+CREATE TABLE bundles (
+    id INT NOT NULL AUTO_INCREMENT,
+    name VARCHAR(200) NOT NULL,
+    series VARCHAR(50),
+    description TEXT,
+    price DECIMAL(10,2) NOT NULL,
+    active TINYINT(1) NOT NULL DEFAULT 1,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_series (series)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE bundle_items (
+    id INT NOT NULL AUTO_INCREMENT,
+    bundle_id INT NOT NULL,
+    product_id INT NOT NULL,
+    quantity INT NOT NULL,
+    PRIMARY KEY (id),
+    INDEX idx_bundle_id (bundle_id),
+    CONSTRAINT fk_bundle_items_bundle FOREIGN KEY (bundle_id) REFERENCES bundles (id) ON DELETE CASCADE,
+    CONSTRAINT fk_bundle_items_product FOREIGN KEY (product_id) REFERENCES products (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+ALTER TABLE order_items ADD COLUMN bundle_id INT NULL AFTER price, ADD INDEX idx_bundle_id (bundle_id);
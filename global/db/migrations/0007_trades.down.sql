@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS trade_offer_events;
+DROP TABLE IF EXISTS trade_offer_items;
+DROP TABLE IF EXISTS trade_offers;
+DROP TABLE IF EXISTS owned_items;
@@ -0,0 +1,60 @@
+-- 交易：已完成订单的订单项按件进入用户库存，用户之间用库存物品发起交换报价
+CREATE TABLE owned_items (
+    id INT NOT NULL AUTO_INCREMENT,
+    user_id INT NOT NULL,
+    product_id INT NOT NULL,
+    order_id INT NOT NULL,
+    order_item_id INT NOT NULL,
+    unit_index INT NOT NULL,
+    figure_id INT NULL,
+    listed TINYINT(1) NOT NULL DEFAULT 0,
+    source VARCHAR(20) NOT NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_order_item_unit (order_item_id, unit_index),
+    INDEX idx_user_id (user_id),
+    INDEX idx_order_id (order_id),
+    INDEX idx_owned_items_deleted_at (deleted_at),
+    CONSTRAINT fk_owned_items_product FOREIGN KEY (product_id) REFERENCES products (id),
+    CONSTRAINT fk_owned_items_order_item FOREIGN KEY (order_item_id) REFERENCES order_items (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE trade_offers (
+    id INT NOT NULL AUTO_INCREMENT,
+    from_user_id INT NOT NULL,
+    to_user_id INT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    message VARCHAR(255),
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_from_user_id (from_user_id),
+    INDEX idx_to_user_id (to_user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE trade_offer_items (
+    id INT NOT NULL AUTO_INCREMENT,
+    offer_id INT NOT NULL,
+    owned_item_id INT NOT NULL,
+    side VARCHAR(20) NOT NULL,
+    PRIMARY KEY (id),
+    INDEX idx_offer_id (offer_id),
+    INDEX idx_owned_item_id (owned_item_id),
+    CONSTRAINT fk_trade_offer_items_offer FOREIGN KEY (offer_id) REFERENCES trade_offers (id) ON DELETE CASCADE,
+    CONSTRAINT fk_trade_offer_items_owned_item FOREIGN KEY (owned_item_id) REFERENCES owned_items (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE trade_offer_events (
+    id INT NOT NULL AUTO_INCREMENT,
+    offer_id INT NOT NULL,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    actor_id INT NOT NULL DEFAULT 0,
+    note VARCHAR(255),
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_offer_id (offer_id),
+    CONSTRAINT fk_trade_offer_events_offer FOREIGN KEY (offer_id) REFERENCES trade_offers (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
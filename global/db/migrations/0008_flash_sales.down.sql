@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS flash_sale_orders;
+DROP TABLE IF EXISTS flash_sales;
@@ -0,0 +1,32 @@
+-- 限时抢购：开始时库存预载到Redis，抢购请求排队异步下单，票据与订单一一对应
+CREATE TABLE flash_sales (
+    id INT NOT NULL AUTO_INCREMENT,
+    product_id INT NOT NULL,
+    price DECIMAL(10,2) NOT NULL,
+    stock INT NOT NULL,
+    per_user_limit INT NOT NULL,
+    start_at DATETIME(3) NOT NULL,
+    end_at DATETIME(3) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_product_id (product_id),
+    INDEX idx_status_start (status, start_at),
+    CONSTRAINT fk_flash_sales_product FOREIGN KEY (product_id) REFERENCES products (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE flash_sale_orders (
+    id INT NOT NULL AUTO_INCREMENT,
+    ticket VARCHAR(64) NOT NULL,
+    flash_sale_id INT NOT NULL,
+    user_id INT NOT NULL,
+    order_id INT NOT NULL,
+    quantity INT NOT NULL,
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_ticket (ticket),
+    INDEX idx_flash_sale_id (flash_sale_id),
+    CONSTRAINT fk_flash_sale_orders_sale FOREIGN KEY (flash_sale_id) REFERENCES flash_sales (id),
+    CONSTRAINT fk_flash_sale_orders_order FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS raffle_entries;
+DROP TABLE IF EXISTS raffles;
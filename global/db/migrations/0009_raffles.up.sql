@@ -0,0 +1,36 @@
+-- 抽签发售：报名截止后用创建时公布哈希的种子抽取中签者，中签者限时购买
+CREATE TABLE raffles (
+    id INT NOT NULL AUTO_INCREMENT,
+    product_id INT NOT NULL,
+    price DECIMAL(10,2) NOT NULL,
+    quantity INT NOT NULL,
+    entry_start_at DATETIME(3) NOT NULL,
+    entry_end_at DATETIME(3) NOT NULL,
+    checkout_minutes INT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    seed_hash VARCHAR(64) NOT NULL,
+    seed VARCHAR(64) NOT NULL,
+    winners INT NOT NULL DEFAULT 0,
+    drawn_at DATETIME(3) NULL,
+    checkout_deadline DATETIME(3) NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_product_id (product_id),
+    INDEX idx_status_end (status, entry_end_at),
+    CONSTRAINT fk_raffles_product FOREIGN KEY (product_id) REFERENCES products (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE raffle_entries (
+    id INT NOT NULL AUTO_INCREMENT,
+    raffle_id INT NOT NULL,
+    user_id INT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'entered',
+    checkout_deadline DATETIME(3) NULL,
+    order_id INT NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_raffle_user (raffle_id, user_id),
+    CONSTRAINT fk_raffle_entries_raffle FOREIGN KEY (raffle_id) REFERENCES raffles (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
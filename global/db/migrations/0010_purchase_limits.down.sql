@@ -0,0 +1,5 @@
+ALTER TABLE products
+    DROP COLUMN cooldown_minutes,
+    DROP COLUMN limit_lifetime,
+    DROP COLUMN limit_per_day,
+    DROP COLUMN limit_per_order;
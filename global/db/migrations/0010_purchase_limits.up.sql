@@ -0,0 +1,6 @@
+-- 商品限购：每单、每人每天、每人累计限购件数和下单冷却时间，0 表示不限制
+ALTER TABLE products
+    ADD COLUMN limit_per_order INT NOT NULL DEFAULT 0 AFTER sales_count,
+    ADD COLUMN limit_per_day INT NOT NULL DEFAULT 0 AFTER limit_per_order,
+    ADD COLUMN limit_lifetime INT NOT NULL DEFAULT 0 AFTER limit_per_day,
+    ADD COLUMN cooldown_minutes INT NOT NULL DEFAULT 0 AFTER limit_lifetime;
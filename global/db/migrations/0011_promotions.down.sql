@@ -0,0 +1,5 @@
+ALTER TABLE orders DROP COLUMN discount_amount;
+DROP TABLE IF EXISTS order_discounts;
+DROP TABLE IF EXISTS coupon_redemptions;
+DROP TABLE IF EXISTS coupons;
+DROP TABLE IF EXISTS promotions;
@@ -0,0 +1,44 @@
+-- 金额改为以分为单位的 BIGINT：先扩大精度避免乘以100时溢出，换算后再改为整数列
+ALTER TABLE products MODIFY price DECIMAL(14,2) NOT NULL;
+UPDATE products SET price = price * 100;
+ALTER TABLE products MODIFY price BIGINT NOT NULL;
+
+ALTER TABLE orders
+    MODIFY total_price DECIMAL(14,2) NOT NULL,
+    MODIFY discount_amount DECIMAL(14,2) NOT NULL DEFAULT 0;
+UPDATE orders SET total_price = total_price * 100, discount_amount = discount_amount * 100;
+ALTER TABLE orders
+    MODIFY total_price BIGINT NOT NULL,
+    MODIFY discount_amount BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE order_items MODIFY price DECIMAL(14,2) NOT NULL;
+UPDATE order_items SET price = price * 100;
+ALTER TABLE order_items MODIFY price BIGINT NOT NULL;
+
+ALTER TABLE payments MODIFY amount DECIMAL(14,2) NOT NULL;
+UPDATE payments SET amount = amount * 100;
+ALTER TABLE payments MODIFY amount BIGINT NOT NULL;
+
+ALTER TABLE bundles MODIFY price DECIMAL(14,2) NOT NULL;
+UPDATE bundles SET price = price * 100;
+ALTER TABLE bundles MODIFY price BIGINT NOT NULL;
+
+ALTER TABLE flash_sales MODIFY price DECIMAL(14,2) NOT NULL;
+UPDATE flash_sales SET price = price * 100;
+ALTER TABLE flash_sales MODIFY price BIGINT NOT NULL;
+
+ALTER TABLE raffles MODIFY price DECIMAL(14,2) NOT NULL;
+UPDATE raffles SET price = price * 100;
+ALTER TABLE raffles MODIFY price BIGINT NOT NULL;
+
+ALTER TABLE promotions
+    MODIFY amount_off DECIMAL(14,2) NOT NULL DEFAULT 0,
+    MODIFY min_amount DECIMAL(14,2) NOT NULL DEFAULT 0;
+UPDATE promotions SET amount_off = amount_off * 100, min_amount = min_amount * 100;
+ALTER TABLE promotions
+    MODIFY amount_off BIGINT NOT NULL DEFAULT 0,
+    MODIFY min_amount BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE order_discounts MODIFY amount DECIMAL(14,2) NOT NULL;
+UPDATE order_discounts SET amount = amount * 100;
+ALTER TABLE order_discounts MODIFY amount BIGINT NOT NULL;
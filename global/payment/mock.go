@@ -0,0 +1,104 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"shop/model"
+)
+
+// MockProviderName 内置模拟支付渠道名称
+const MockProviderName = "mock"
+
+// MockProvider 本地模拟支付渠道，用于在没有真实支付服务商的情况下端到端测试支付流程
+// 模拟支付完成时，会像真实渠道一样对事件签名并回调 webhook 地址
+type MockProvider struct {
+	secret      []byte
+	callbackURL string
+	client      *http.Client
+}
+
+// NewMockProvider 创建模拟支付渠道
+func NewMockProvider(secret, callbackURL string) *MockProvider {
+	return &MockProvider{
+		secret:      []byte(secret),
+		callbackURL: callbackURL,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name 渠道名称
+func (p *MockProvider) Name() string {
+	return MockProviderName
+}
+
+// CreatePayment 生成模拟流水号，支付地址指向模拟收银台
+func (p *MockProvider) CreatePayment(ctx context.Context, req *CreateRequest) (*CreateResult, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	ref := "mock_" + hex.EncodeToString(buf)
+	return &CreateResult{
+		ProviderRef: ref,
+		PayURL:      "/api/payments/mock/" + ref + "/complete",
+	}, nil
+}
+
+// VerifyWebhook 校验签名和时间戳并解析事件
+func (p *MockProvider) VerifyWebhook(payload []byte, signature string) (*WebhookEvent, error) {
+	if err := VerifySignature(p.secret, payload, signature); err != nil {
+		return nil, err
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	if err := checkTimestamp(event.Timestamp); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Simulate 模拟用户在渠道侧完成（或失败）支付：生成签名事件并回调 webhook
+func (p *MockProvider) Simulate(ctx context.Context, providerRef string, amount model.Money, status string) error {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	event := WebhookEvent{
+		EventID:     "evt_" + hex.EncodeToString(buf),
+		ProviderRef: providerRef,
+		Status:      status,
+		Amount:      amount,
+		Timestamp:   time.Now().Unix(),
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.callbackURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(p.secret, payload))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook delivery failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
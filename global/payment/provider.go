@@ -0,0 +1,138 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"shop/config"
+	"shop/model"
+)
+
+// 支付结果状态
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// SignatureHeader 回调签名请求头，格式为 sha256={hex}
+const SignatureHeader = "X-Signature"
+
+// webhookTolerance 回调时间戳允许的最大偏差，超出视为重放
+const webhookTolerance = 5 * time.Minute
+
+var (
+	// ErrInvalidSignature 回调签名错误
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrStaleWebhook 回调时间戳过期
+	ErrStaleWebhook = errors.New("webhook timestamp out of tolerance")
+)
+
+// CreateRequest 发起支付请求
+type CreateRequest struct {
+	PaymentID int
+	OrderID   int
+	Amount    model.Money
+	Subject   string
+}
+
+// CreateResult 渠道创建支付的结果
+type CreateResult struct {
+	ProviderRef string // 渠道流水号
+	PayURL      string // 用户完成支付的地址
+}
+
+// WebhookEvent 渠道回调事件
+type WebhookEvent struct {
+	EventID     string      `json:"event_id"`
+	ProviderRef string      `json:"provider_ref"`
+	Status      string      `json:"status"`
+	Amount      model.Money `json:"amount"` // 以元为单位的十进制数字，最多两位小数
+	Timestamp   int64       `json:"timestamp"`
+	Message     string      `json:"message,omitempty"`
+}
+
+// PaymentProvider 支付渠道
+type PaymentProvider interface {
+	// Name 渠道名称，用于回调地址 /api/payments/webhook/{name}
+	Name() string
+	// CreatePayment 在渠道侧创建支付
+	CreatePayment(ctx context.Context, req *CreateRequest) (*CreateResult, error)
+	// VerifyWebhook 校验回调签名并解析事件，签名错误时返回 ErrInvalidSignature
+	VerifyWebhook(payload []byte, signature string) (*WebhookEvent, error)
+}
+
+var (
+	mu              sync.RWMutex
+	providers       = map[string]PaymentProvider{}
+	defaultProvider string
+)
+
+// InitPayment 根据配置注册支付渠道
+func InitPayment(cfg config.PaymentConfig) {
+	if cfg.Mock.Enabled {
+		Register(NewMockProvider(cfg.Mock.WebhookSecret, cfg.Mock.CallbackURL))
+	}
+
+	mu.Lock()
+	defaultProvider = cfg.DefaultProvider
+	mu.Unlock()
+	if GetProvider(cfg.DefaultProvider) == nil {
+		log.Printf("Warning: default payment provider %q is not enabled, payments will be unavailable", cfg.DefaultProvider)
+	}
+}
+
+// Register 注册支付渠道
+func Register(p PaymentProvider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[p.Name()] = p
+}
+
+// GetProvider 获取支付渠道，未注册时返回 nil
+func GetProvider(name string) PaymentProvider {
+	mu.RLock()
+	defer mu.RUnlock()
+	return providers[name]
+}
+
+// DefaultProviderName 未指定渠道时使用的支付渠道名称
+func DefaultProviderName() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultProvider
+}
+
+// Sign 使用 HMAC-SHA256 对回调内容签名
+func Sign(secret, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature 以常数时间比较签名
+func VerifySignature(secret, payload []byte, signature string) error {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(Sign(secret, payload)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// checkTimestamp 校验回调时间戳
+func checkTimestamp(ts int64) error {
+	diff := time.Since(time.Unix(ts, 0))
+	if diff > webhookTolerance || diff < -webhookTolerance {
+		return fmt.Errorf("%w: %d", ErrStaleWebhook, ts)
+	}
+	return nil
+}
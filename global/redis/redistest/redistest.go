@@ -0,0 +1,26 @@
+// Package redistest 为测试和基准测试提供内存中的 Redis（miniredis）
+package redistest
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	redisv9 "github.com/redis/go-redis/v9"
+	"shop/global/redis"
+)
+
+// Open 启动 miniredis 并设置为 redis.Client，测试结束时关闭并恢复原来的客户端
+func Open(tb testing.TB) *miniredis.Miniredis {
+	tb.Helper()
+
+	server := miniredis.RunT(tb)
+	client := redisv9.NewClient(&redisv9.Options{Addr: server.Addr()})
+
+	prev := redis.Client
+	redis.Client = client
+	tb.Cleanup(func() {
+		redis.Client = prev
+		client.Close()
+	})
+	return server
+}
@@ -0,0 +1,239 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"shop/dao"
+	"shop/model"
+)
+
+const (
+	// maxProductPriceCents 商品价格上限（分），与改为以分保存前的 decimal(10,2) 相同，单价 × 数量不会超出 int64
+	maxProductPriceCents = 9999999999
+	// maxProductStock 单次设置或调整的库存上限
+	maxProductStock = 1000000
+)
+
+// validateProductName 校验商品名称
+func validateProductName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return newValidationError("商品名称不能为空")
+	}
+	if utf8.RuneCountInString(name) > 200 {
+		return newValidationError("商品名称不能超过200个字符")
+	}
+	return nil
+}
+
+// validateProductPrice 校验商品价格（大于0，不超过上限），两位小数在解析请求时已经保证
+func validateProductPrice(price model.Money) error {
+	if !price.IsPositive() {
+		return newValidationError("商品价格必须大于0")
+	}
+	if price.Cents() > maxProductPriceCents {
+		return newValidationError("商品价格不能超过 %s", model.Cents(maxProductPriceCents))
+	}
+	return nil
+}
+
+// validateProductImage 校验商品图片地址（可为空，非空时必须是 http/https 绝对地址）
+func validateProductImage(image string) error {
+	if image == "" {
+		return nil
+	}
+	if len(image) > 500 {
+		return newValidationError("图片地址不能超过500个字符")
+	}
+	u, err := url.Parse(image)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return newValidationError("图片地址必须是有效的 http/https URL")
+	}
+	return nil
+}
+
+// validateProductSeries 校验商品系列
+func validateProductSeries(series string) error {
+	if utf8.RuneCountInString(series) > 50 {
+		return newValidationError("系列名称不能超过50个字符")
+	}
+	return nil
+}
+
+// AdminGetProducts 获取商品列表（管理后台）
+func AdminGetProducts(includeDeleted bool) ([]model.Product, error) {
+	return dao.GetProductsForAdmin(includeDeleted)
+}
+
+// CreateProduct 创建商品
+func CreateProduct(req *model.CreateProductRequest) (*model.Product, error) {
+	if err := validateProductName(req.Name); err != nil {
+		return nil, err
+	}
+	if err := validateProductPrice(req.Price); err != nil {
+		return nil, err
+	}
+	if err := validateProductImage(req.Image); err != nil {
+		return nil, err
+	}
+	if err := validateProductSeries(req.Series); err != nil {
+		return nil, err
+	}
+	if req.Stock < 0 || req.Stock > maxProductStock {
+		return nil, newValidationError("初始库存必须在 0 到 %d 之间", maxProductStock)
+	}
+	if err := validatePurchaseLimits(&req.PurchaseLimits); err != nil {
+		return nil, err
+	}
+
+	product := &model.Product{
+		Name:           strings.TrimSpace(req.Name),
+		Description:    req.Description,
+		Price:          req.Price,
+		Image:          req.Image,
+		Stock:          req.Stock,
+		Series:         req.Series,
+		PurchaseLimits: req.PurchaseLimits,
+	}
+	if product.Series == "" {
+		product.Series = "拉布布"
+	}
+
+	if err := dao.CreateProduct(product); err != nil {
+		return nil, fmt.Errorf("创建商品失败: %w", err)
+	}
+	return product, nil
+}
+
+// UpdateProduct 更新商品信息（不包含库存）
+func UpdateProduct(productID int, req *model.UpdateProductRequest) (*model.Product, error) {
+	product, err := dao.GetProductByIDUnscoped(productID)
+	if err != nil {
+		return nil, fmt.Errorf("查询商品失败: %w", err)
+	}
+	if product == nil || product.DeletedAt.Valid {
+		return nil, fmt.Errorf("商品不存在")
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		if err := validateProductName(*req.Name); err != nil {
+			return nil, err
+		}
+		updates["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.Price != nil {
+		if err := validateProductPrice(*req.Price); err != nil {
+			return nil, err
+		}
+		updates["price"] = *req.Price
+	}
+	if req.Image != nil {
+		if err := validateProductImage(*req.Image); err != nil {
+			return nil, err
+		}
+		updates["image"] = *req.Image
+	}
+	if req.Series != nil {
+		if err := validateProductSeries(*req.Series); err != nil {
+			return nil, err
+		}
+		updates["series"] = *req.Series
+	}
+	limits := product.PurchaseLimits
+	if req.LimitPerOrder != nil {
+		limits.LimitPerOrder = *req.LimitPerOrder
+		updates["limit_per_order"] = *req.LimitPerOrder
+	}
+	if req.LimitPerDay != nil {
+		limits.LimitPerDay = *req.LimitPerDay
+		updates["limit_per_day"] = *req.LimitPerDay
+	}
+	if req.LimitLifetime != nil {
+		limits.LimitLifetime = *req.LimitLifetime
+		updates["limit_lifetime"] = *req.LimitLifetime
+	}
+	if req.CooldownMinutes != nil {
+		limits.CooldownMinutes = *req.CooldownMinutes
+		updates["cooldown_minutes"] = *req.CooldownMinutes
+	}
+	if err := validatePurchaseLimits(&limits); err != nil {
+		return nil, err
+	}
+	if len(updates) == 0 {
+		return nil, newValidationError("没有需要更新的字段")
+	}
+
+	if err := dao.UpdateProduct(productID, updates); err != nil {
+		return nil, fmt.Errorf("更新商品失败: %w", err)
+	}
+	return dao.GetProductByIDUnscoped(productID)
+}
+
+// DeleteProduct 软删除商品，历史订单仍能展示该商品
+func DeleteProduct(productID int) error {
+	product, err := dao.GetProductByIDUnscoped(productID)
+	if err != nil {
+		return fmt.Errorf("查询商品失败: %w", err)
+	}
+	if product == nil || product.DeletedAt.Valid {
+		return fmt.Errorf("商品不存在")
+	}
+	return dao.DeleteProduct(productID)
+}
+
+// AdjustStock 按原因调整商品库存
+func AdjustStock(operatorID, productID int, req *model.AdjustStockRequest) (*model.StockAdjustment, error) {
+	if req.Delta == 0 {
+		return nil, newValidationError("调整数量不能为0")
+	}
+	if req.Delta > maxProductStock || req.Delta < -maxProductStock {
+		return nil, newValidationError("调整数量超出范围（-%d 到 %d）", maxProductStock, maxProductStock)
+	}
+	if !model.IsValidStockReason(req.Reason) {
+		return nil, newValidationError("无效的调整原因: %s", req.Reason)
+	}
+
+	product, err := dao.GetProductByIDUnscoped(productID)
+	if err != nil {
+		return nil, fmt.Errorf("查询商品失败: %w", err)
+	}
+	if product == nil || product.DeletedAt.Valid {
+		return nil, fmt.Errorf("商品不存在")
+	}
+	// 盲盒商品的库存等于可售款式库存之和，只能通过调整款式库存同步修改
+	series, err := dao.GetBlindBoxSeriesByProductID(productID)
+	if err != nil {
+		return nil, fmt.Errorf("查询盲盒系列失败: %w", err)
+	}
+	if series != nil {
+		return nil, newValidationError("盲盒商品的库存由款式库存决定，请通过 POST /api/admin/blind-box-figures/:id/stock 调整款式库存")
+	}
+
+	adjustment := &model.StockAdjustment{
+		ProductID:  productID,
+		Delta:      req.Delta,
+		Reason:     req.Reason,
+		Note:       req.Note,
+		OperatorID: operatorID,
+	}
+	if err := dao.AdjustProductStock(adjustment); err != nil {
+		if errors.Is(err, dao.ErrInsufficientStock) {
+			return nil, newValidationError("库存不足，当前库存: %d", product.Stock)
+		}
+		return nil, fmt.Errorf("调整库存失败: %w", err)
+	}
+	return adjustment, nil
+}
+
+// GetStockAdjustments 获取商品的库存调整记录
+func GetStockAdjustments(productID int) ([]model.StockAdjustment, error) {
+	return dao.GetStockAdjustments(productID)
+}
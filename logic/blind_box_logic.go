@@ -0,0 +1,443 @@
+package logic
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	mathrand "math/rand/v2"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"shop/dao"
+	"shop/global/db"
+	"shop/model"
+)
+
+// maxFigureWeight 款式权重上限
+const maxFigureWeight = 1000000
+
+// errBlindBoxSoldOut 奖池中剩余款式不足以完成抽取
+var errBlindBoxSoldOut = errors.New("盲盒款式已售罄")
+
+// validateFigureName 校验款式名称
+func validateFigureName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return newValidationError("款式名称不能为空")
+	}
+	if utf8.RuneCountInString(name) > 100 {
+		return newValidationError("款式名称不能超过100个字符")
+	}
+	return nil
+}
+
+// validateFigureWeight 校验款式权重
+func validateFigureWeight(weight int) error {
+	if weight < 1 || weight > maxFigureWeight {
+		return newValidationError("款式权重必须在 1 到 %d 之间", maxFigureWeight)
+	}
+	return nil
+}
+
+// GetBlindBoxSeries 获取盲盒商品的系列和款式概率，隐藏款不展示名称和图片
+func GetBlindBoxSeries(productID int) (*model.BlindBoxSeriesView, error) {
+	series, err := dao.GetBlindBoxSeriesByProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if series == nil {
+		return nil, fmt.Errorf("盲盒系列不存在")
+	}
+
+	totalWeight := 0
+	for _, f := range series.Figures {
+		if f.Stock > 0 {
+			totalWeight += f.Weight
+		}
+	}
+
+	view := &model.BlindBoxSeriesView{
+		ID:          series.ID,
+		ProductID:   series.ProductID,
+		Name:        series.Name,
+		Description: series.Description,
+		Figures:     make([]model.BlindBoxFigureView, 0, len(series.Figures)),
+	}
+	for _, f := range series.Figures {
+		fv := model.BlindBoxFigureView{
+			ID:       f.ID,
+			Name:     f.Name,
+			Image:    f.Image,
+			IsHidden: f.IsHidden,
+			SoldOut:  f.Stock <= 0,
+		}
+		if f.IsHidden {
+			fv.Name = "隐藏款"
+			fv.Image = ""
+		}
+		if f.Stock > 0 && totalWeight > 0 {
+			fv.Probability = float64(f.Weight) / float64(totalWeight)
+		}
+		view.Figures = append(view.Figures, fv)
+	}
+	return view, nil
+}
+
+// GetMyDraws 分页获取用户的抽取记录
+func GetMyDraws(userID, page, pageSize int) ([]model.BlindBoxDraw, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return dao.GetBlindBoxDrawsByUser(userID, page, pageSize)
+}
+
+// drawFigures 使用种子为 quantity 个盒子依次抽取款式
+// 随机数序列为 ChaCha8(seed)，第 i 个盒子 roll = IntN(奖池总权重)，奖池为剩余库存大于0的款式（按ID升序），
+// 依次累加权重，第一个累计权重大于 roll 的款式即为结果；抽中后该款式库存减1再抽下一个
+func drawFigures(seed [32]byte, figures []model.BlindBoxFigure, quantity int) ([]model.BlindBoxDraw, error) {
+	rng := mathrand.New(mathrand.NewChaCha8(seed))
+	remaining := make([]int, len(figures))
+	for i, f := range figures {
+		remaining[i] = f.Stock
+	}
+
+	draws := make([]model.BlindBoxDraw, 0, quantity)
+	for seq := 0; seq < quantity; seq++ {
+		total := 0
+		pool := make([]string, 0, len(figures))
+		for i, f := range figures {
+			if remaining[i] > 0 && f.Weight > 0 {
+				total += f.Weight
+				pool = append(pool, strconv.Itoa(f.ID)+":"+strconv.Itoa(f.Weight))
+			}
+		}
+		if total == 0 {
+			return nil, errBlindBoxSoldOut
+		}
+
+		roll := rng.IntN(total)
+		acc := 0
+		for i, f := range figures {
+			if remaining[i] <= 0 || f.Weight <= 0 {
+				continue
+			}
+			acc += f.Weight
+			if roll < acc {
+				remaining[i]--
+				draws = append(draws, model.BlindBoxDraw{
+					SeriesID:    f.SeriesID,
+					FigureID:    f.ID,
+					Sequence:    seq,
+					Roll:        roll,
+					TotalWeight: total,
+					Pool:        strings.Join(pool, ","),
+				})
+				break
+			}
+		}
+	}
+	return draws, nil
+}
+
+// drawBlindBoxesTx 为订单中尚未抽取的盲盒订单项抽取款式，返回因款式售罄未能抽取的订单项数量
+// 每个订单项使用独立的随机种子并保存在订单项上，抽取记录保存奖池快照，可以事后重放校验
+func drawBlindBoxesTx(tx *gorm.DB, order *model.Order) (int, error) {
+	items, err := dao.GetOrderItemsTx(tx, order.ID)
+	if err != nil {
+		return 0, fmt.Errorf("查询订单项失败: %w", err)
+	}
+
+	undrawn := 0
+	for _, item := range items {
+		if item.DrawSeed != "" {
+			continue
+		}
+		series, err := dao.GetBlindBoxSeriesByProductIDTx(tx, item.ProductID)
+		if err != nil {
+			return 0, fmt.Errorf("查询盲盒系列失败: %w", err)
+		}
+		if series == nil {
+			continue
+		}
+
+		figures, err := dao.GetBlindBoxFiguresForUpdate(tx, series.ID)
+		if err != nil {
+			return 0, fmt.Errorf("查询盲盒款式失败: %w", err)
+		}
+		var seed [32]byte
+		if _, err := rand.Read(seed[:]); err != nil {
+			return 0, fmt.Errorf("生成抽取种子失败: %w", err)
+		}
+		draws, err := drawFigures(seed, figures, item.Quantity)
+		if errors.Is(err, errBlindBoxSoldOut) {
+			// 不阻塞支付，运营补货后通过管理接口重新抽取
+			log.Printf("Warning: Blind box series %d sold out, order item %d left undrawn", series.ID, item.ID)
+			undrawn++
+			continue
+		}
+
+		for i := range draws {
+			draws[i].OrderID = order.ID
+			draws[i].OrderItemID = item.ID
+			draws[i].UserID = order.UserID
+			if err := dao.AdjustBlindBoxFigureStockTx(tx, draws[i].FigureID, -1); err != nil {
+				return 0, fmt.Errorf("扣减款式库存失败: %w", err)
+			}
+		}
+		if err := dao.SetOrderItemDrawSeed(tx, item.ID, hex.EncodeToString(seed[:])); err != nil {
+			return 0, fmt.Errorf("保存抽取种子失败: %w", err)
+		}
+		if err := dao.CreateBlindBoxDraws(tx, draws); err != nil {
+			return 0, fmt.Errorf("保存抽取记录失败: %w", err)
+		}
+	}
+	return undrawn, nil
+}
+
+// restoreDrawnFiguresTx 订单退款时将抽中的款式归还到款式库存
+func restoreDrawnFiguresTx(tx *gorm.DB, orderID int) error {
+	draws, err := dao.GetBlindBoxDrawsByOrderTx(tx, orderID)
+	if err != nil {
+		return fmt.Errorf("查询抽取记录失败: %w", err)
+	}
+	for _, d := range draws {
+		if err := dao.AdjustBlindBoxFigureStockTx(tx, d.FigureID, 1); err != nil {
+			return fmt.Errorf("归还款式库存失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// AdminGetBlindBoxSeriesList 获取全部盲盒系列（管理后台）
+func AdminGetBlindBoxSeriesList() ([]model.BlindBoxSeries, error) {
+	return dao.GetBlindBoxSeriesList()
+}
+
+// AdminGetBlindBoxSeries 获取盲盒系列详情（管理后台）
+func AdminGetBlindBoxSeries(seriesID int) (*model.BlindBoxSeries, error) {
+	series, err := dao.GetBlindBoxSeriesByID(seriesID)
+	if err != nil {
+		return nil, err
+	}
+	if series == nil {
+		return nil, fmt.Errorf("盲盒系列不存在")
+	}
+	return series, nil
+}
+
+// CreateBlindBoxSeries 将商品设置为盲盒，商品库存需为0，之后由款式库存汇总
+func CreateBlindBoxSeries(req *model.CreateBlindBoxSeriesRequest) (*model.BlindBoxSeries, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > 100 {
+		return nil, newValidationError("系列名称不能为空且不能超过100个字符")
+	}
+
+	product, err := dao.GetProductByIDUnscoped(req.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("查询商品失败: %w", err)
+	}
+	if product == nil || product.DeletedAt.Valid {
+		return nil, fmt.Errorf("商品不存在")
+	}
+	if product.Stock != 0 {
+		return nil, newValidationError("请先将商品库存调整为0，盲盒商品的库存由款式库存汇总")
+	}
+	existing, err := dao.GetBlindBoxSeriesByProductID(req.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("查询盲盒系列失败: %w", err)
+	}
+	if existing != nil {
+		return nil, newValidationError("该商品已经是盲盒商品")
+	}
+
+	series := &model.BlindBoxSeries{
+		ProductID:   req.ProductID,
+		Name:        name,
+		Description: req.Description,
+	}
+	if err := dao.CreateBlindBoxSeries(series); err != nil {
+		return nil, fmt.Errorf("创建盲盒系列失败: %w", err)
+	}
+	return series, nil
+}
+
+// adjustFigureStockTx 同时调整款式库存和盲盒商品库存，保证商品库存等于可售的款式库存之和
+func adjustFigureStockTx(tx *gorm.DB, operatorID int, series *model.BlindBoxSeries, figure *model.BlindBoxFigure, delta int, reason, note string) (*model.StockAdjustment, error) {
+	if err := dao.AdjustBlindBoxFigureStockTx(tx, figure.ID, delta); err != nil {
+		return nil, err
+	}
+	adjustment := &model.StockAdjustment{
+		ProductID:  series.ProductID,
+		Delta:      delta,
+		Reason:     reason,
+		Note:       truncate(fmt.Sprintf("款式 %s: %s", figure.Name, note), 255),
+		OperatorID: operatorID,
+	}
+	if err := dao.AdjustProductStockTx(tx, adjustment); err != nil {
+		return nil, err
+	}
+	return adjustment, nil
+}
+
+// AddBlindBoxFigure 为盲盒系列添加款式，初始库存记为补货
+func AddBlindBoxFigure(operatorID, seriesID int, req *model.CreateBlindBoxFigureRequest) (*model.BlindBoxFigure, error) {
+	if err := validateFigureName(req.Name); err != nil {
+		return nil, err
+	}
+	if err := validateFigureWeight(req.Weight); err != nil {
+		return nil, err
+	}
+	if err := validateProductImage(req.Image); err != nil {
+		return nil, err
+	}
+	if req.Stock < 0 || req.Stock > maxProductStock {
+		return nil, newValidationError("初始库存必须在 0 到 %d 之间", maxProductStock)
+	}
+
+	series, err := dao.GetBlindBoxSeriesByID(seriesID)
+	if err != nil {
+		return nil, fmt.Errorf("查询盲盒系列失败: %w", err)
+	}
+	if series == nil {
+		return nil, fmt.Errorf("盲盒系列不存在")
+	}
+
+	figure := &model.BlindBoxFigure{
+		SeriesID: seriesID,
+		Name:     strings.TrimSpace(req.Name),
+		Image:    req.Image,
+		Weight:   req.Weight,
+		IsHidden: req.IsHidden,
+	}
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := dao.CreateBlindBoxFigureTx(tx, figure); err != nil {
+			return err
+		}
+		if req.Stock == 0 {
+			return nil
+		}
+		_, err := adjustFigureStockTx(tx, operatorID, series, figure, req.Stock, model.StockReasonRestock, "新增款式")
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("添加款式失败: %w", err)
+	}
+	figure.Stock = req.Stock
+	return figure, nil
+}
+
+// UpdateBlindBoxFigure 更新款式名称、图片、权重或隐藏款标记
+func UpdateBlindBoxFigure(figureID int, req *model.UpdateBlindBoxFigureRequest) (*model.BlindBoxFigure, error) {
+	figure, err := dao.GetBlindBoxFigureByID(figureID)
+	if err != nil {
+		return nil, fmt.Errorf("查询款式失败: %w", err)
+	}
+	if figure == nil {
+		return nil, fmt.Errorf("款式不存在")
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		if err := validateFigureName(*req.Name); err != nil {
+			return nil, err
+		}
+		updates["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.Image != nil {
+		if err := validateProductImage(*req.Image); err != nil {
+			return nil, err
+		}
+		updates["image"] = *req.Image
+	}
+	if req.Weight != nil {
+		if err := validateFigureWeight(*req.Weight); err != nil {
+			return nil, err
+		}
+		updates["weight"] = *req.Weight
+	}
+	if req.IsHidden != nil {
+		updates["is_hidden"] = *req.IsHidden
+	}
+	if len(updates) == 0 {
+		return nil, newValidationError("没有需要更新的字段")
+	}
+
+	if err := dao.UpdateBlindBoxFigure(figureID, updates); err != nil {
+		return nil, fmt.Errorf("更新款式失败: %w", err)
+	}
+	return dao.GetBlindBoxFigureByID(figureID)
+}
+
+// AdjustBlindBoxFigureStock 按原因调整款式库存，盲盒商品库存同步调整
+func AdjustBlindBoxFigureStock(operatorID, figureID int, req *model.AdjustStockRequest) (*model.StockAdjustment, error) {
+	if req.Delta == 0 {
+		return nil, newValidationError("调整数量不能为0")
+	}
+	if req.Delta > maxProductStock || req.Delta < -maxProductStock {
+		return nil, newValidationError("调整数量超出范围（-%d 到 %d）", maxProductStock, maxProductStock)
+	}
+	if !model.IsValidStockReason(req.Reason) {
+		return nil, newValidationError("无效的调整原因: %s", req.Reason)
+	}
+
+	figure, err := dao.GetBlindBoxFigureByID(figureID)
+	if err != nil {
+		return nil, fmt.Errorf("查询款式失败: %w", err)
+	}
+	if figure == nil {
+		return nil, fmt.Errorf("款式不存在")
+	}
+	series, err := dao.GetBlindBoxSeriesByID(figure.SeriesID)
+	if err != nil {
+		return nil, fmt.Errorf("查询盲盒系列失败: %w", err)
+	}
+
+	var adjustment *model.StockAdjustment
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		adjustment, err = adjustFigureStockTx(tx, operatorID, series, figure, req.Delta, req.Reason, req.Note)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, dao.ErrInsufficientStock) {
+			// 商品库存不足说明部分款式库存已被待支付订单占用
+			return nil, newValidationError("库存不足，款式库存: %d（已下单未抽取的盒子占用的库存不能出库）", figure.Stock)
+		}
+		return nil, fmt.Errorf("调整库存失败: %w", err)
+	}
+	return adjustment, nil
+}
+
+// AdminDrawOrder 为已支付订单中因款式售罄未抽取的盲盒重新抽取
+func AdminDrawOrder(orderID int) (*model.Order, int, error) {
+	var undrawn int
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		order, err := dao.GetOrderByIDForUpdate(tx, orderID)
+		if err != nil {
+			return fmt.Errorf("查询订单失败: %w", err)
+		}
+		if order == nil {
+			return ErrOrderNotFound
+		}
+		switch order.Status {
+		case model.OrderStatusPaid, model.OrderStatusShipped, model.OrderStatusDelivered, model.OrderStatusCompleted:
+		default:
+			return newValidationError("订单状态为 %s，不能抽取", order.Status)
+		}
+		undrawn, err = drawBlindBoxesTx(tx, order)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	order, err := AdminGetOrder(orderID)
+	return order, undrawn, err
+}
@@ -0,0 +1,180 @@
+package logic
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+	"shop/global/db"
+	"shop/global/db/dbtest"
+	"shop/model"
+)
+
+// testFigures 测试用款式：2 号权重为0，3 号没有库存，都不应被抽中
+func testFigures() []model.BlindBoxFigure {
+	return []model.BlindBoxFigure{
+		{ID: 1, SeriesID: 1, Name: "常规款 A", Weight: 60, Stock: 100},
+		{ID: 2, SeriesID: 1, Name: "停售款", Weight: 0, Stock: 100},
+		{ID: 3, SeriesID: 1, Name: "售罄款", Weight: 30, Stock: 0},
+		{ID: 4, SeriesID: 1, Name: "常规款 B", Weight: 30, Stock: 100},
+		{ID: 5, SeriesID: 1, Name: "隐藏款", Weight: 1, Stock: 2, IsHidden: true},
+	}
+}
+
+func TestDrawFiguresDeterministic(t *testing.T) {
+	var seed [32]byte
+	copy(seed[:], "blind-box-determinism-test-seed!")
+
+	first, err := drawFigures(seed, testFigures(), 150)
+	if err != nil {
+		t.Fatalf("抽取失败: %v", err)
+	}
+	second, err := drawFigures(seed, testFigures(), 150)
+	if err != nil {
+		t.Fatalf("抽取失败: %v", err)
+	}
+	for i := range first {
+		if first[i].FigureID != second[i].FigureID || first[i].Roll != second[i].Roll || first[i].Pool != second[i].Pool {
+			t.Fatalf("第 %d 个盒子两次抽取结果不同: %+v / %+v", i, first[i], second[i])
+		}
+	}
+
+	counts := map[int]int{}
+	for i, d := range first {
+		counts[d.FigureID]++
+		if d.Sequence != i || d.Roll < 0 || d.Roll >= d.TotalWeight {
+			t.Fatalf("第 %d 个盒子的抽取记录不正确: %+v", i, d)
+		}
+		if strings.Contains(","+d.Pool, ",2:") || strings.Contains(","+d.Pool, ",3:") {
+			t.Fatalf("第 %d 个盒子的奖池包含权重为0或无库存的款式: %s", i, d.Pool)
+		}
+	}
+	if counts[2] != 0 || counts[3] != 0 {
+		t.Fatalf("抽中了权重为0或无库存的款式: %v", counts)
+	}
+	if counts[5] > 2 {
+		t.Fatalf("库存为2的隐藏款被抽中 %d 次", counts[5])
+	}
+
+	// 不同的种子得到不同的序列
+	seed[0] ^= 1
+	other, err := drawFigures(seed, testFigures(), 150)
+	if err != nil {
+		t.Fatalf("抽取失败: %v", err)
+	}
+	same := true
+	for i := range first {
+		if first[i].Roll != other[i].Roll {
+			same = false
+			break
+		}
+	}
+	if same {
+		t.Fatal("不同种子得到了相同的随机数序列")
+	}
+}
+
+func TestDrawFiguresSoldOut(t *testing.T) {
+	figures := []model.BlindBoxFigure{
+		{ID: 1, SeriesID: 1, Weight: 10, Stock: 2},
+		{ID: 2, SeriesID: 1, Weight: 0, Stock: 10},
+		{ID: 3, SeriesID: 1, Weight: 10, Stock: 0},
+	}
+	var seed [32]byte
+
+	draws, err := drawFigures(seed, figures, 2)
+	if err != nil {
+		t.Fatalf("抽取失败: %v", err)
+	}
+	for _, d := range draws {
+		if d.FigureID != 1 {
+			t.Fatalf("抽中了款式 %d，只有款式1可以抽取", d.FigureID)
+		}
+	}
+	if _, err := drawFigures(seed, figures, 3); !errors.Is(err, errBlindBoxSoldOut) {
+		t.Fatalf("可抽取库存不足时错误为 %v，预期 errBlindBoxSoldOut", err)
+	}
+}
+
+// TestDrawBlindBoxesTxReplay 支付后抽取的结果可以用订单项保存的 draw_seed 和抽取前的款式重放得到
+func TestDrawBlindBoxesTxReplay(t *testing.T) {
+	dbtest.Open(t, &model.Product{}, &model.Order{}, &model.OrderItem{}, &model.OrderStatusHistory{},
+		&model.BlindBoxSeries{}, &model.BlindBoxFigure{}, &model.BlindBoxDraw{})
+
+	product := model.Product{Name: "拉布布 前方高能", Price: model.Cents(6900), Stock: 100}
+	if err := db.DB.Create(&product).Error; err != nil {
+		t.Fatalf("创建商品失败: %v", err)
+	}
+	series := model.BlindBoxSeries{ProductID: product.ID, Name: "前方高能"}
+	if err := db.DB.Create(&series).Error; err != nil {
+		t.Fatalf("创建盲盒系列失败: %v", err)
+	}
+	figures := testFigures()
+	for i := range figures {
+		figures[i].ID = 0
+		figures[i].SeriesID = series.ID
+		if err := db.DB.Create(&figures[i]).Error; err != nil {
+			t.Fatalf("创建款式失败: %v", err)
+		}
+	}
+
+	const quantity = 12
+	var order *model.Order
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if order, err = createOrderTx(tx, 1, []orderLine{{productID: product.ID, quantity: quantity}}); err != nil {
+			return err
+		}
+		_, err = drawBlindBoxesTx(tx, order)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("下单并抽取失败: %v", err)
+	}
+
+	var item model.OrderItem
+	if err := db.DB.Where("order_id = ?", order.ID).First(&item).Error; err != nil {
+		t.Fatalf("查询订单项失败: %v", err)
+	}
+	raw, err := hex.DecodeString(item.DrawSeed)
+	if err != nil || len(raw) != 32 {
+		t.Fatalf("订单项的 draw_seed 无效: %q", item.DrawSeed)
+	}
+	var seed [32]byte
+	copy(seed[:], raw)
+
+	var stored []model.BlindBoxDraw
+	if err := db.DB.Where("order_item_id = ?", item.ID).Order("sequence").Find(&stored).Error; err != nil {
+		t.Fatalf("查询抽取记录失败: %v", err)
+	}
+	replayed, err := drawFigures(seed, figures, quantity)
+	if err != nil {
+		t.Fatalf("重放抽取失败: %v", err)
+	}
+	if len(stored) != quantity || len(replayed) != quantity {
+		t.Fatalf("抽取记录 %d 条、重放 %d 条，预期 %d 条", len(stored), len(replayed), quantity)
+	}
+	drawn := map[int]int{}
+	for i := range stored {
+		if stored[i].FigureID != replayed[i].FigureID || stored[i].Roll != replayed[i].Roll || stored[i].Pool != replayed[i].Pool {
+			t.Fatalf("第 %d 个盒子重放结果不同: 保存 %+v，重放 %+v", i, stored[i], replayed[i])
+		}
+		drawn[stored[i].FigureID]++
+	}
+
+	// 款式库存按抽取结果扣减，权重为0和无库存的款式未被抽中
+	for _, f := range figures {
+		var got model.BlindBoxFigure
+		if err := db.DB.First(&got, f.ID).Error; err != nil {
+			t.Fatalf("查询款式失败: %v", err)
+		}
+		if got.Stock != f.Stock-drawn[f.ID] {
+			t.Fatalf("款式 %s 库存为 %d，预期 %d", f.Name, got.Stock, f.Stock-drawn[f.ID])
+		}
+		if (f.Weight == 0 || f.Stock == 0) && drawn[f.ID] != 0 {
+			t.Fatalf("款式 %s（权重 %d，库存 %d）被抽中 %d 次", f.Name, f.Weight, f.Stock, drawn[f.ID])
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"shop/dao"
+	"shop/model"
+)
+
+// maxBundleQuantity 单个套装成员的最大件数
+const maxBundleQuantity = 100
+
+// GetBundles 获取上架中的套装列表，可按系列过滤
+func GetBundles(series string) ([]model.Bundle, error) {
+	return dao.GetBundles(series, true)
+}
+
+// GetBundle 获取上架中的套装详情
+func GetBundle(bundleID int) (*model.Bundle, error) {
+	bundle, err := dao.GetBundleByID(bundleID)
+	if err != nil {
+		return nil, err
+	}
+	if bundle == nil || !bundle.Active {
+		return nil, fmt.Errorf("套装不存在")
+	}
+	return bundle, nil
+}
+
+// AdminGetBundles 获取全部套装（管理后台）
+func AdminGetBundles(series string) ([]model.Bundle, error) {
+	return dao.GetBundles(series, false)
+}
+
+// buildBundleItems 校验套装成员，items 为空时使用 series 下的全部商品
+func buildBundleItems(series string, reqItems []model.BundleItemRequest) ([]model.BundleItem, error) {
+	if len(reqItems) == 0 {
+		if series == "" {
+			return nil, newValidationError("请指定套装成员或系列")
+		}
+		productIDs, err := dao.GetProductIDsBySeries(series)
+		if err != nil {
+			return nil, fmt.Errorf("查询系列商品失败: %w", err)
+		}
+		for _, id := range productIDs {
+			reqItems = append(reqItems, model.BundleItemRequest{ProductID: id, Quantity: 1})
+		}
+	}
+	if len(reqItems) < 2 {
+		return nil, newValidationError("套装至少包含两件不同的商品")
+	}
+
+	seen := make(map[int]bool, len(reqItems))
+	items := make([]model.BundleItem, 0, len(reqItems))
+	for _, r := range reqItems {
+		if r.Quantity < 1 || r.Quantity > maxBundleQuantity {
+			return nil, newValidationError("成员数量必须在 1 到 %d 之间", maxBundleQuantity)
+		}
+		if seen[r.ProductID] {
+			return nil, newValidationError("套装成员重复: %d", r.ProductID)
+		}
+		seen[r.ProductID] = true
+
+		product, err := dao.GetProductByIDUnscoped(r.ProductID)
+		if err != nil {
+			return nil, fmt.Errorf("查询商品失败: %w", err)
+		}
+		if product == nil || product.DeletedAt.Valid {
+			return nil, newValidationError("商品不存在: %d", r.ProductID)
+		}
+		items = append(items, model.BundleItem{ProductID: r.ProductID, Quantity: r.Quantity})
+	}
+	return items, nil
+}
+
+// CreateBundle 创建套装
+func CreateBundle(req *model.CreateBundleRequest) (*model.Bundle, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > 200 {
+		return nil, newValidationError("套装名称不能为空且不能超过200个字符")
+	}
+	if err := validateProductSeries(req.Series); err != nil {
+		return nil, err
+	}
+	if err := validateProductPrice(req.Price); err != nil {
+		return nil, err
+	}
+	items, err := buildBundleItems(req.Series, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &model.Bundle{
+		Name:        name,
+		Series:      req.Series,
+		Description: req.Description,
+		Price:       req.Price,
+		Active:      true,
+		Items:       items,
+	}
+	if err := dao.CreateBundle(bundle); err != nil {
+		return nil, fmt.Errorf("创建套装失败: %w", err)
+	}
+	return dao.GetBundleByID(bundle.ID)
+}
+
+// UpdateBundle 更新套装，下架通过 active=false 完成
+func UpdateBundle(bundleID int, req *model.UpdateBundleRequest) (*model.Bundle, error) {
+	bundle, err := dao.GetBundleByID(bundleID)
+	if err != nil {
+		return nil, fmt.Errorf("查询套装失败: %w", err)
+	}
+	if bundle == nil {
+		return nil, fmt.Errorf("套装不存在")
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || utf8.RuneCountInString(name) > 200 {
+			return nil, newValidationError("套装名称不能为空且不能超过200个字符")
+		}
+		updates["name"] = name
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.Price != nil {
+		if err := validateProductPrice(*req.Price); err != nil {
+			return nil, err
+		}
+		updates["price"] = *req.Price
+	}
+	if req.Active != nil {
+		updates["active"] = *req.Active
+	}
+
+	var items []model.BundleItem
+	if req.Items != nil {
+		if items, err = buildBundleItems("", req.Items); err != nil {
+			return nil, err
+		}
+	}
+	if len(updates) == 0 && items == nil {
+		return nil, newValidationError("没有需要更新的字段")
+	}
+
+	if err := dao.UpdateBundle(bundleID, updates, items); err != nil {
+		return nil, fmt.Errorf("更新套装失败: %w", err)
+	}
+	return dao.GetBundleByID(bundleID)
+}
+
+// checkBundleAvailable 检查套装可售且每个成员库存足够购买 quantity 套
+func checkBundleAvailable(bundle *model.Bundle, quantity int) error {
+	if !bundle.Active {
+		return newValidationError("套装已下架")
+	}
+	for _, item := range bundle.Items {
+		if item.Product.DeletedAt.Valid {
+			return newValidationError("套装中的商品已下架: %s", item.Product.Name)
+		}
+		if item.Product.Stock < item.Quantity*quantity {
+			return newValidationError("库存不足: %s (需要: %d, 库存: %d)", item.Product.Name, item.Quantity*quantity, item.Product.Stock)
+		}
+	}
+	return nil
+}
+
+// bundleStock 返回成员库存最多能凑出的套数
+func bundleStock(bundle *model.Bundle) int {
+	stock := math.MaxInt
+	for _, item := range bundle.Items {
+		if item.Quantity > 0 {
+			stock = min(stock, item.Product.Stock/item.Quantity)
+		}
+	}
+	return max(stock, 0)
+}
+
+// GetCartBundles 获取购物车中的套装行，已删除的套装会从购物车移除
+func GetCartBundles(userID int) ([]model.CartBundle, error) {
+	lines, err := dao.GetCartBundlesFromRedis(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.CartBundle, 0, len(lines))
+	for _, line := range lines {
+		bundle, err := dao.GetBundleByID(line.BundleID)
+		if err != nil {
+			return nil, err
+		}
+		if bundle == nil {
+			dao.DeleteCartBundleFromRedis(userID, line.BundleID)
+			continue
+		}
+		line.Bundle = *bundle
+		result = append(result, line)
+	}
+	return result, nil
+}
+
+// AddBundleToCart 添加套装到购物车，已在购物车中时累加数量
+func AddBundleToCart(userID int, req *model.AddBundleToCartRequest) error {
+	if req.Quantity < 1 {
+		return newValidationError("数量必须大于0")
+	}
+	bundle, err := dao.GetBundleByID(req.BundleID)
+	if err != nil {
+		return fmt.Errorf("查询套装失败: %w", err)
+	}
+	if bundle == nil {
+		return fmt.Errorf("套装不存在")
+	}
+
+	if err := checkBundleAvailable(bundle, req.Quantity); err != nil {
+		return err
+	}
+
+	// 加上购物车中已有数量后的库存检查在Redis脚本中与增加一起原子地完成
+	quantity, err := dao.IncrCartBundleInRedis(userID, req.BundleID, req.Quantity, bundleStock(bundle), bundle.Price)
+	if errors.Is(err, dao.ErrCartStockExceeded) {
+		// 生成包含成员商品库存的详细错误
+		if stockErr := checkBundleAvailable(bundle, quantity); stockErr != nil {
+			return stockErr
+		}
+	}
+	return err
+}
+
+// UpdateCartBundle 更新购物车中套装的数量
+func UpdateCartBundle(userID, bundleID int, req *model.UpdateCartRequest) error {
+	if req.Quantity < 1 {
+		return newValidationError("数量必须大于0")
+	}
+	bundle, err := dao.GetBundleByID(bundleID)
+	if err != nil {
+		return fmt.Errorf("查询套装失败: %w", err)
+	}
+	if bundle == nil {
+		return fmt.Errorf("套装不存在")
+	}
+	if err := checkBundleAvailable(bundle, req.Quantity); err != nil {
+		return err
+	}
+
+	// 套装行不在购物车中时返回 dao.ErrCartItemNotFound（"购物车项不存在"）
+	return dao.UpdateCartBundleQuantityInRedis(userID, bundleID, req.Quantity, bundleStock(bundle))
+}
+
+// DeleteCartBundle 从购物车删除套装
+func DeleteCartBundle(userID, bundleID int) error {
+	existing, err := dao.GetCartBundleFromRedis(userID, bundleID)
+	if err != nil {
+		return fmt.Errorf("查询购物车失败: %w", err)
+	}
+	if existing == nil {
+		return fmt.Errorf("购物车项不存在")
+	}
+	return dao.DeleteCartBundleFromRedis(userID, bundleID)
+}
+
+// expandBundleTx 在事务中将 quantity 套套装展开为成员下单明细，套装价按成员原价比例分摊（精确到分）
+// 某个成员分摊金额不能被件数整除时拆成两行，保证各行 单价×数量 之和等于套装价
+func expandBundleTx(tx *gorm.DB, bundleID, quantity int) ([]orderLine, error) {
+	bundle, err := dao.GetBundleByIDTx(tx, bundleID)
+	if err != nil {
+		return nil, fmt.Errorf("查询套装失败: %w", err)
+	}
+	if bundle == nil {
+		return nil, fmt.Errorf("套装不存在")
+	}
+	if !bundle.Active {
+		return nil, newValidationError("套装已下架: %s", bundle.Name)
+	}
+
+	units := make([]int64, len(bundle.Items))
+	weights := make([]int64, len(bundle.Items))
+	var totalWeight, totalUnits int64
+	for i, item := range bundle.Items {
+		product, err := dao.GetProductByIDTx(tx, item.ProductID)
+		if err != nil {
+			return nil, fmt.Errorf("查询商品失败: %w", err)
+		}
+		if product == nil {
+			return nil, newValidationError("套装中的商品已下架: %d", item.ProductID)
+		}
+		units[i] = int64(item.Quantity * quantity)
+		weight, err := product.Price.Mul(units[i])
+		if err != nil {
+			return nil, fmt.Errorf("计算套装金额失败: %w", err)
+		}
+		weights[i] = weight.Cents()
+		totalWeight += weights[i]
+		totalUnits += units[i]
+	}
+	if totalWeight == 0 {
+		// 成员原价都为0时按件数分摊
+		copy(weights, units)
+		totalWeight = totalUnits
+	}
+
+	// 按比例向下取整，余下的分计入最后一个成员
+	total, err := bundle.Price.Mul(int64(quantity))
+	if err != nil {
+		return nil, fmt.Errorf("计算套装金额失败: %w", err)
+	}
+	totalCents := total.Cents()
+	allocated := make([]int64, len(bundle.Items))
+	var sum int64
+	for i := range bundle.Items {
+		allocated[i] = totalCents * weights[i] / totalWeight
+		sum += allocated[i]
+	}
+	allocated[len(allocated)-1] += totalCents - sum
+
+	lines := make([]orderLine, 0, len(bundle.Items))
+	for i, item := range bundle.Items {
+		base, rem := allocated[i]/units[i], allocated[i]%units[i]
+		if units[i]-rem > 0 {
+			lines = append(lines, orderLine{
+				productID: item.ProductID,
+				quantity:  int(units[i] - rem),
+				bundleID:  bundle.ID,
+				priced:    true,
+				unitPrice: model.Cents(base),
+			})
+		}
+		if rem > 0 {
+			lines = append(lines, orderLine{
+				productID: item.ProductID,
+				quantity:  int(rem),
+				bundleID:  bundle.ID,
+				priced:    true,
+				unitPrice: model.Cents(base + 1),
+			})
+		}
+	}
+	return lines, nil
+}
+
+// removeOrderedBundlesFromCart 下单成功后从购物车删除已结算的套装
+func removeOrderedBundlesFromCart(userID int, bundles []model.CartBundle) {
+	for _, b := range bundles {
+		if err := dao.DeleteCartBundleFromRedis(userID, b.BundleID); err != nil {
+			log.Printf("Warning: Failed to remove cart bundle %d for user %d: %v", b.BundleID, userID, err)
+		}
+	}
+}
+
+// DeactivateBundle 下架套装，已在购物车中的套装无法再结算
+func DeactivateBundle(bundleID int) error {
+	active := false
+	_, err := UpdateBundle(bundleID, &model.UpdateBundleRequest{Active: &active})
+	return err
+}
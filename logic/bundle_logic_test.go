@@ -0,0 +1,52 @@
+package logic
+
+import (
+	"fmt"
+	"testing"
+
+	"shop/global/db"
+	"shop/global/db/dbtest"
+	"shop/model"
+)
+
+// TestCreateBundleFromLargeSeries 系列商品数超过一页（maxProductPageSize）时，整系列套装包含全部在售商品
+func TestCreateBundleFromLargeSeries(t *testing.T) {
+	dbtest.Open(t, &model.Product{}, &model.Bundle{}, &model.BundleItem{})
+
+	const seriesSize = maxProductPageSize + 50
+	products := make([]model.Product, 0, seriesSize+2)
+	for i := 0; i < seriesSize; i++ {
+		products = append(products, model.Product{Name: fmt.Sprintf("星星人 %03d", i), Series: "星星人", Price: model.Cents(6900), Stock: 10})
+	}
+	products = append(products,
+		model.Product{Name: "拉布布 心动马卡龙", Series: "拉布布", Price: model.Cents(9900), Stock: 10},
+		model.Product{Name: "星星人 已下架", Series: "星星人", Price: model.Cents(6900), Stock: 10},
+	)
+	if err := db.DB.CreateInBatches(&products, 50).Error; err != nil {
+		t.Fatalf("创建商品失败: %v", err)
+	}
+	if err := db.DB.Delete(&products[len(products)-1]).Error; err != nil {
+		t.Fatalf("下架商品失败: %v", err)
+	}
+
+	bundle, err := CreateBundle(&model.CreateBundleRequest{Name: "星星人全家福", Series: "星星人", Price: model.Cents(6900 * seriesSize)})
+	if err != nil {
+		t.Fatalf("创建套装失败: %v", err)
+	}
+	if len(bundle.Items) != seriesSize {
+		t.Fatalf("套装包含 %d 件商品，预期 %d 件", len(bundle.Items), seriesSize)
+	}
+	want := make(map[int]bool, seriesSize)
+	for _, p := range products[:seriesSize] {
+		want[p.ID] = true
+	}
+	for _, item := range bundle.Items {
+		if !want[item.ProductID] || item.Quantity != 1 {
+			t.Fatalf("套装成员不正确: %+v", item)
+		}
+		delete(want, item.ProductID)
+	}
+	if len(want) != 0 {
+		t.Fatalf("套装缺少 %d 件系列商品", len(want))
+	}
+}
@@ -1,9 +1,13 @@
 package logic
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"math"
 
 	"shop/dao"
+	"shop/global/db"
 	"shop/model"
 )
 
@@ -14,58 +18,70 @@ func GetCart(userID int) ([]model.CartItem, error) {
 
 // AddToCart 添加到购物车（使用Redis）
 func AddToCart(userID int, req *model.AddToCartRequest) error {
-	// 检查商品是否存在
-	stock, err := dao.GetProductStock(req.ProductID)
-	if err != nil {
-		return fmt.Errorf("查询商品失败: %w", err)
-	}
-	if stock < req.Quantity {
+	_, err := incrCartItem(userID, req.ProductID, req.Quantity)
+	if errors.Is(err, dao.ErrCartStockExceeded) {
 		return fmt.Errorf("库存不足")
 	}
+	return err
+}
 
-	// 检查购物车中是否已有该商品
-	existingItem, err := dao.GetCartItemByUserAndProductFromRedis(userID, req.ProductID)
-	if err != nil {
-		return fmt.Errorf("查询购物车失败: %w", err)
+// incrCartItem 原子地增减购物车中商品的数量，增加时新数量不能超过库存和用户剩余可购数量
+// 超过库存时返回 dao.ErrCartStockExceeded，超过限购时返回 *PurchaseLimitError
+func incrCartItem(userID, productID, delta int) (int, error) {
+	stock, limit := math.MaxInt, math.MaxInt
+	var price model.Money
+	if delta > 0 {
+		product, err := dao.GetProductByIDTx(db.DB, productID)
+		if err != nil {
+			return 0, fmt.Errorf("查询商品失败: %w", err)
+		}
+		if product == nil {
+			return 0, fmt.Errorf("商品不存在")
+		}
+		stock, price = product.Stock, product.Price
+		if limit, err = maxPurchasableQuantity(userID, product); err != nil {
+			return 0, err
+		}
 	}
 
-	if existingItem != nil {
-		// 更新数量
-		newQuantity := existingItem.Quantity + req.Quantity
-		if newQuantity > stock {
-			return fmt.Errorf("库存不足")
+	quantity, err := dao.IncrCartItemInRedis(userID, productID, delta, stock, limit, price)
+	if errors.Is(err, dao.ErrCartLimitExceeded) {
+		// 生成包含限购类型和已购数量的详细错误
+		if limitErr := checkCartPurchaseLimit(userID, productID, quantity); limitErr != nil {
+			return 0, limitErr
 		}
-		return dao.UpdateCartItemQuantityInRedis(userID, req.ProductID, newQuantity)
 	}
-
-	// 添加新商品到购物车
-	return dao.AddCartItemToRedis(userID, req.ProductID, req.Quantity)
+	if err != nil {
+		return 0, err
+	}
+	return quantity, nil
 }
 
 // UpdateCartItem 更新购物车商品数量（使用Redis）
 // 注意：参数改为 productID 而不是 cartItemID
+// 购物车项是否存在、库存和限购在Redis脚本中与更新一起原子地检查
 func UpdateCartItem(userID, productID int, req *model.UpdateCartRequest) error {
-	// 检查购物车项是否存在
-	item, err := dao.GetCartItemByUserAndProductFromRedis(userID, productID)
+	product, err := dao.GetProductByIDTx(db.DB, productID)
 	if err != nil {
-		return fmt.Errorf("查询购物车项失败: %w", err)
+		return fmt.Errorf("查询商品失败: %w", err)
 	}
-	if item == nil {
-		return fmt.Errorf("购物车项不存在")
+	if product == nil {
+		return fmt.Errorf("商品不存在")
 	}
-
-	// 检查库存
-	stock, err := dao.GetProductStock(productID)
+	limit, err := maxPurchasableQuantity(userID, product)
 	if err != nil {
-		return fmt.Errorf("查询商品失败: %w", err)
+		return err
 	}
 
-	if req.Quantity > stock {
-		return fmt.Errorf("库存不足")
+	// 购物车项不存在时返回 dao.ErrCartItemNotFound（"购物车项不存在"）
+	err = dao.UpdateCartItemQuantityInRedis(userID, productID, req.Quantity, product.Stock, limit)
+	if errors.Is(err, dao.ErrCartLimitExceeded) {
+		// 生成包含限购类型和已购数量的详细错误
+		if limitErr := checkCartPurchaseLimit(userID, productID, req.Quantity); limitErr != nil {
+			return limitErr
+		}
 	}
-
-	// 更新数量
-	return dao.UpdateCartItemQuantityInRedis(userID, productID, req.Quantity)
+	return err
 }
 
 // DeleteCartItem 删除购物车商品（使用Redis）
@@ -82,3 +98,15 @@ func DeleteCartItem(userID, productID int) error {
 
 	return dao.DeleteCartItemFromRedis(userID, productID)
 }
+
+// MigrateLegacyCarts 把旧版（每个商品一个键）的购物车迁移为每个用户一个Hash，失败时下次启动重试
+func MigrateLegacyCarts() {
+	migrated, err := dao.MigrateLegacyCartKeys()
+	if err != nil {
+		log.Printf("Warning: Failed to migrate legacy cart keys (%d migrated): %v", migrated, err)
+		return
+	}
+	if migrated > 0 {
+		log.Printf("Migrated %d legacy cart item(s) to per-user hashes", migrated)
+	}
+}
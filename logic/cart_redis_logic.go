@@ -2,6 +2,8 @@ package logic
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 
 	"shop/dao"
 	"shop/model"
@@ -15,10 +17,14 @@ func GetCartRedis(userID int) ([]model.CartItem, error) {
 // AddToCart 添加到购物车（使用Redis）
 func AddToCartRedis(userID int, req *model.AddToCartRequest) error {
 	// 检查商品是否存在
-	stock, err := dao.GetProductStock(req.ProductID)
+	product, err := dao.GetProductByID(strconv.Itoa(req.ProductID))
 	if err != nil {
 		return fmt.Errorf("查询商品失败: %w", err)
 	}
+	if product == nil {
+		return fmt.Errorf("商品不存在")
+	}
+	stock := product.Stock
 	if stock < req.Quantity {
 		return fmt.Errorf("库存不足")
 	}
@@ -35,11 +41,11 @@ func AddToCartRedis(userID int, req *model.AddToCartRequest) error {
 		if newQuantity > stock {
 			return fmt.Errorf("库存不足")
 		}
-		return dao.UpdateCartItemQuantityInRedis(userID, req.ProductID, newQuantity)
+		return dao.UpdateCartItemQuantityInRedis(userID, req.ProductID, newQuantity, stock, math.MaxInt)
 	}
 
 	// 添加新商品到购物车
-	return dao.AddCartItemToRedis(userID, req.ProductID, req.Quantity)
+	return dao.AddCartItemToRedis(userID, req.ProductID, req.Quantity, product.Price)
 }
 
 // UpdateCartItem 更新购物车商品数量（使用Redis）
@@ -64,7 +70,7 @@ func UpdateCartItemRedis(userID, productID int, req *model.UpdateCartRequest) er
 	}
 
 	// 更新数量
-	return dao.UpdateCartItemQuantityInRedis(userID, productID, req.Quantity)
+	return dao.UpdateCartItemQuantityInRedis(userID, productID, req.Quantity, stock, math.MaxInt)
 }
 
 // DeleteCartItem 删除购物车商品（使用Redis）
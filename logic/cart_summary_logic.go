@@ -0,0 +1,192 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+
+	"shop/dao"
+	"shop/global/db"
+	"shop/model"
+)
+
+// cartShippingFee 运费，目前全场包邮
+var cartShippingFee = model.Cents(0)
+
+// GetCartSummary 计算购物车结算预览：按当前价格和可结算数量计算每行金额和总价，
+// 并标记已售罄、可结算数量减少和价格变动的行；促销和优惠码 couponCode 的优惠计入 Discount，
+// 优惠码不可用时不影响预览，原因放在 CouponError 中
+func GetCartSummary(userID int, couponCode string) (*model.CartSummary, error) {
+	items, err := dao.GetCartItemsFromRedis(userID)
+	if err != nil {
+		return nil, err
+	}
+	bundles, err := GetCartBundles(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &model.CartSummary{
+		Items:       make([]model.CartSummaryItem, 0, len(items)),
+		Bundles:     make([]model.CartSummaryBundle, 0, len(bundles)),
+		CanCheckout: len(items) > 0 || len(bundles) > 0,
+		Promotions:  []model.AppliedPromotion{},
+	}
+	var subtotal, discount model.Money
+	lines := make([]promotionLine, 0, len(items))
+	for _, item := range items {
+		line, err := summarizeCartItem(userID, item)
+		if err != nil {
+			return nil, err
+		}
+		if line.AvailableQuantity < line.Quantity {
+			summary.CanCheckout = false
+		}
+		subtotal = subtotal.Add(line.LineTotal)
+		summary.Items = append(summary.Items, *line)
+		lines = append(lines, promotionLine{
+			productID: line.ProductID,
+			series:    item.Product.Series,
+			quantity:  line.AvailableQuantity,
+			unitPrice: line.UnitPrice,
+		})
+	}
+	for _, b := range bundles {
+		line, err := summarizeCartBundle(b)
+		if err != nil {
+			return nil, err
+		}
+		if line.AvailableQuantity < line.Quantity {
+			summary.CanCheckout = false
+		}
+		subtotal = subtotal.Add(line.LineTotal).Add(line.Discount)
+		discount = discount.Add(line.Discount)
+		summary.Bundles = append(summary.Bundles, line)
+	}
+
+	// 与下单时相同：优惠后至少支付 0.01
+	promotionDiscount, err := summarizeCartPromotions(summary, userID, lines, couponCode, subtotal.Sub(discount).Sub(model.Cents(1)))
+	if err != nil {
+		return nil, err
+	}
+	discount = discount.Add(promotionDiscount)
+
+	summary.Subtotal = subtotal
+	summary.Discount = discount
+	summary.Shipping = cartShippingFee
+	summary.Total = subtotal.Sub(discount).Add(cartShippingFee)
+	return summary, nil
+}
+
+// summarizeCartPromotions 计算购物车可享受的促销和优惠码优惠，写入 summary.Promotions
+// 优惠码不存在或不可用时记录到 summary.CouponError，只计算自动应用的促销
+func summarizeCartPromotions(summary *model.CartSummary, userID int, lines []promotionLine, couponCode string, maxDiscount model.Money) (model.Money, error) {
+	summary.CouponCode = normalizeCouponCode(couponCode)
+	applied, discount, _, err := calculatePromotions(db.DB, userID, lines, couponCode, maxDiscount, false)
+	if err != nil {
+		var validationErr *ValidationError
+		if !errors.Is(err, ErrCouponNotFound) && !errors.As(err, &validationErr) {
+			return model.Money{}, err
+		}
+		summary.CouponError = err.Error()
+		applied, discount, _, err = calculatePromotions(db.DB, userID, lines, "", maxDiscount, false)
+		if err != nil {
+			return model.Money{}, err
+		}
+	}
+	summary.Promotions = applied
+	return discount, nil
+}
+
+// summarizeCartItem 计算购物车商品行的可结算数量、金额和提示
+func summarizeCartItem(userID int, item model.CartItem) (*model.CartSummaryItem, error) {
+	product := item.Product
+	available := min(item.Quantity, product.Stock)
+	if product.HasLimits() {
+		limit, err := maxPurchasableQuantity(userID, &product)
+		if err != nil {
+			return nil, err
+		}
+		available = min(available, limit)
+	}
+	available = max(available, 0)
+
+	lineTotal, err := product.Price.Mul(int64(available))
+	if err != nil {
+		return nil, fmt.Errorf("计算购物车金额失败: %w", err)
+	}
+	line := &model.CartSummaryItem{
+		ProductID:         item.ProductID,
+		Name:              product.Name,
+		Image:             product.Image,
+		Quantity:          item.Quantity,
+		AvailableQuantity: available,
+		UnitPrice:         product.Price,
+		AddedPrice:        item.AddedPrice,
+		LineTotal:         lineTotal,
+		Warnings:          []string{},
+	}
+	switch {
+	case product.Stock <= 0:
+		line.Warnings = append(line.Warnings, model.CartLineOutOfStock)
+	case available < item.Quantity:
+		line.Warnings = append(line.Warnings, model.CartLineQuantityReduced)
+	}
+	if priceChanged(item.AddedPrice, product.Price) {
+		line.Warnings = append(line.Warnings, model.CartLinePriceChanged)
+	}
+	return line, nil
+}
+
+// summarizeCartBundle 计算购物车套装行的可结算数量、金额和提示
+func summarizeCartBundle(b model.CartBundle) (model.CartSummaryBundle, error) {
+	bundle := b.Bundle
+	line := model.CartSummaryBundle{
+		BundleID:   b.BundleID,
+		Name:       bundle.Name,
+		Quantity:   b.Quantity,
+		UnitPrice:  bundle.Price,
+		ListPrice:  bundle.ListPrice,
+		AddedPrice: b.AddedPrice,
+		Warnings:   []string{},
+	}
+
+	available := b.Quantity
+	unavailable := !bundle.Active
+	for _, item := range bundle.Items {
+		if item.Product.DeletedAt.Valid {
+			unavailable = true
+			break
+		}
+		if item.Quantity > 0 {
+			available = min(available, item.Product.Stock/item.Quantity)
+		}
+	}
+	switch {
+	case unavailable:
+		available = 0
+		line.Warnings = append(line.Warnings, model.CartLineUnavailable)
+	case available <= 0:
+		available = 0
+		line.Warnings = append(line.Warnings, model.CartLineOutOfStock)
+	case available < b.Quantity:
+		line.Warnings = append(line.Warnings, model.CartLineQuantityReduced)
+	}
+	if priceChanged(b.AddedPrice, bundle.Price) {
+		line.Warnings = append(line.Warnings, model.CartLinePriceChanged)
+	}
+
+	line.AvailableQuantity = available
+	var err error
+	if line.LineTotal, err = bundle.Price.Mul(int64(available)); err != nil {
+		return line, fmt.Errorf("计算购物车金额失败: %w", err)
+	}
+	if line.Discount, err = bundle.ListPrice.Sub(bundle.Price).Max(model.Money{}).Mul(int64(available)); err != nil {
+		return line, fmt.Errorf("计算购物车金额失败: %w", err)
+	}
+	return line, nil
+}
+
+// priceChanged 当前价格是否与加入购物车时的价格不同，未记录加入时价格时返回 false
+func priceChanged(added *model.Money, current model.Money) bool {
+	return added != nil && !added.Equal(current)
+}
@@ -0,0 +1,138 @@
+package logic
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"shop/dao"
+	"shop/model"
+)
+
+// collectionExcludedStatuses 不计入收藏的订单状态
+var collectionExcludedStatuses = []string{model.OrderStatusCancelled, model.OrderStatusRefunded}
+
+// buildCollections 按系列汇总用户的收藏进度，series 非空时只统计该系列
+// 系列的商品总数为在售商品加上已购买但已下架的商品
+func buildCollections(userID int, series string) (map[string]*model.CollectionSeries, error) {
+	quantities, err := dao.GetPurchasedQuantities(userID, collectionExcludedStatuses)
+	if err != nil {
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+
+	productIDs := make([]int, 0, len(quantities))
+	for id := range quantities {
+		productIDs = append(productIDs, id)
+	}
+	purchased, err := dao.GetProductsByIDsUnscoped(productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("查询商品失败: %w", err)
+	}
+
+	seriesNames := make([]string, 0)
+	seen := make(map[string]bool)
+	if series != "" {
+		seriesNames = append(seriesNames, series)
+		seen[series] = true
+	}
+	for _, p := range purchased {
+		if p.Series == "" || seen[p.Series] || (series != "" && p.Series != series) {
+			continue
+		}
+		seen[p.Series] = true
+		seriesNames = append(seriesNames, p.Series)
+	}
+
+	onSale, err := dao.GetProductsBySeries(seriesNames)
+	if err != nil {
+		return nil, fmt.Errorf("查询商品失败: %w", err)
+	}
+
+	result := make(map[string]*model.CollectionSeries)
+	add := func(p model.Product) {
+		c := result[p.Series]
+		if c == nil {
+			c = &model.CollectionSeries{
+				CollectionSummary: model.CollectionSummary{Series: p.Series},
+				Owned:             []model.CollectionProduct{},
+				Missing:           []model.CollectionProduct{},
+			}
+			result[p.Series] = c
+		}
+		item := model.CollectionProduct{
+			ProductID: p.ID,
+			Name:      p.Name,
+			Image:     p.Image,
+			Price:     p.Price,
+			Quantity:  quantities[p.ID],
+			Duplicate: quantities[p.ID] > 1,
+			Removed:   p.DeletedAt.Valid,
+		}
+		c.TotalProducts++
+		if item.Quantity > 0 {
+			c.OwnedProducts++
+			if item.Duplicate {
+				c.DuplicateProducts++
+			}
+			c.Owned = append(c.Owned, item)
+		} else {
+			c.Missing = append(c.Missing, item)
+		}
+	}
+
+	counted := make(map[int]bool)
+	for _, p := range onSale {
+		counted[p.ID] = true
+		add(p)
+	}
+	for _, p := range purchased {
+		if counted[p.ID] || !seen[p.Series] {
+			continue
+		}
+		add(p)
+	}
+
+	for _, c := range result {
+		if c.TotalProducts > 0 {
+			c.Completion = math.Round(float64(c.OwnedProducts)*1000/float64(c.TotalProducts)) / 10
+		}
+		sort.Slice(c.Owned, func(i, j int) bool { return c.Owned[i].ProductID < c.Owned[j].ProductID })
+	}
+	return result, nil
+}
+
+// GetCollection 获取用户在各个系列的收藏进度（只包含购买过的系列）
+func GetCollection(userID int) ([]model.CollectionSummary, error) {
+	collections, err := buildCollections(userID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.CollectionSummary, 0, len(collections))
+	for _, c := range collections {
+		result = append(result, c.CollectionSummary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Completion != result[j].Completion {
+			return result[i].Completion > result[j].Completion
+		}
+		return result[i].Series < result[j].Series
+	})
+	return result, nil
+}
+
+// GetCollectionSeries 获取用户在某个系列已拥有和缺少的商品
+func GetCollectionSeries(userID int, series string) (*model.CollectionSeries, error) {
+	if series == "" {
+		return nil, newValidationError("系列不能为空")
+	}
+	collections, err := buildCollections(userID, series)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := collections[series]
+	if !ok {
+		return nil, fmt.Errorf("系列不存在")
+	}
+	return c, nil
+}
@@ -0,0 +1,74 @@
+package logic
+
+import (
+	"fmt"
+	"time"
+)
+
+// ValidationError 参数校验错误，控制器应返回400
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// newValidationError 创建参数校验错误
+func newValidationError(format string, args ...interface{}) error {
+	return &ValidationError{Message: fmt.Sprintf(format, args...)}
+}
+
+// ProductNotFoundError 下单时商品不存在或已下架
+type ProductNotFoundError struct {
+	ProductID int
+}
+
+func (e *ProductNotFoundError) Error() string {
+	return fmt.Sprintf("商品不存在: %d", e.ProductID)
+}
+
+// OutOfStockError 下单时商品库存不足
+type OutOfStockError struct {
+	ProductID   int
+	ProductName string
+	Requested   int
+	Available   int
+}
+
+func (e *OutOfStockError) Error() string {
+	return fmt.Sprintf("商品库存不足: %s (需要: %d, 库存: %d)", e.ProductName, e.Requested, e.Available)
+}
+
+// 限购类型
+const (
+	PurchaseLimitPerOrder = "per_order" // 每单限购
+	PurchaseLimitPerDay   = "per_day"   // 每人每天限购
+	PurchaseLimitLifetime = "lifetime"  // 每人累计限购
+	PurchaseLimitCooldown = "cooldown"  // 下单冷却
+)
+
+// PurchaseLimitError 超出商品限购规则
+type PurchaseLimitError struct {
+	ProductID   int
+	ProductName string
+	Limit       string     // 触发的限购类型
+	Max         int        // 限购件数（冷却时为冷却分钟数）
+	Used        int        // 已购买件数（每单限购和冷却时为0）
+	Requested   int        // 本次需要的件数
+	RetryAfter  *time.Time // 冷却结束时间，仅冷却时有值
+}
+
+func (e *PurchaseLimitError) Error() string {
+	switch e.Limit {
+	case PurchaseLimitPerOrder:
+		return fmt.Sprintf("超出每单限购: %s 每单限购 %d 件 (需要: %d)", e.ProductName, e.Max, e.Requested)
+	case PurchaseLimitPerDay:
+		return fmt.Sprintf("超出每日限购: %s 每人每天限购 %d 件 (今日已购: %d, 需要: %d)", e.ProductName, e.Max, e.Used, e.Requested)
+	case PurchaseLimitLifetime:
+		return fmt.Sprintf("超出累计限购: %s 每人限购 %d 件 (已购: %d, 需要: %d)", e.ProductName, e.Max, e.Used, e.Requested)
+	case PurchaseLimitCooldown:
+		return fmt.Sprintf("下单冷却中: %s 两次下单需间隔 %d 分钟，请在 %s 后再试", e.ProductName, e.Max, e.RetryAfter.Format("2006-01-02 15:04:05"))
+	}
+	return fmt.Sprintf("超出限购: %s", e.ProductName)
+}
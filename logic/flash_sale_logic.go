@@ -0,0 +1,461 @@
+package logic
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"shop/dao"
+	"shop/global/db"
+	"shop/model"
+)
+
+// maxFlashSalePerUserLimit 每人限购数量上限
+const maxFlashSalePerUserLimit = 100
+
+// ErrFlashSaleNotFound 抢购不存在
+var ErrFlashSaleNotFound = errors.New("抢购不存在")
+
+// flashSaleCache 抢购的进程内缓存，抢购请求不需要查询MySQL
+// 状态或库存变化（开始、结束、取消）时由本实例删除缓存；其他实例的缓存可能短暂过期，
+// 抢购能否购买以Redis库存键为准，不依赖缓存中的状态
+var flashSaleCache sync.Map
+
+// getCachedFlashSale 获取抢购（进程内缓存），状态以Redis库存键是否存在为准
+func getCachedFlashSale(saleID int) (*model.FlashSale, error) {
+	if v, ok := flashSaleCache.Load(saleID); ok {
+		return v.(*model.FlashSale), nil
+	}
+	sale, err := dao.GetFlashSaleByID(saleID)
+	if err != nil {
+		return nil, fmt.Errorf("查询抢购失败: %w", err)
+	}
+	if sale == nil {
+		return nil, ErrFlashSaleNotFound
+	}
+	flashSaleCache.Store(saleID, sale)
+	return sale, nil
+}
+
+// invalidateFlashSale 删除抢购缓存，下次请求重新从MySQL加载
+func invalidateFlashSale(saleID int) {
+	flashSaleCache.Delete(saleID)
+}
+
+// fillFlashSaleRemaining 为进行中的抢购填充Redis中的剩余库存
+func fillFlashSaleRemaining(sale *model.FlashSale) {
+	if sale.Status != model.FlashSaleStatusActive {
+		return
+	}
+	if stock, err := dao.GetFlashSaleStock(sale.ID); err == nil && stock >= 0 {
+		sale.Remaining = &stock
+	}
+}
+
+// GetFlashSales 获取未开始和进行中的抢购
+func GetFlashSales() ([]model.FlashSale, error) {
+	sales, err := dao.GetFlashSales([]string{model.FlashSaleStatusScheduled, model.FlashSaleStatusActive})
+	if err != nil {
+		return nil, err
+	}
+	for i := range sales {
+		fillFlashSaleRemaining(&sales[i])
+	}
+	return sales, nil
+}
+
+// GetFlashSale 获取抢购详情
+func GetFlashSale(saleID int) (*model.FlashSale, error) {
+	sale, err := dao.GetFlashSaleByID(saleID)
+	if err != nil {
+		return nil, fmt.Errorf("查询抢购失败: %w", err)
+	}
+	if sale == nil {
+		return nil, ErrFlashSaleNotFound
+	}
+	fillFlashSaleRemaining(sale)
+	return sale, nil
+}
+
+// newFlashSaleTicket 生成抢购票据
+func newFlashSaleTicket() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// PurchaseFlashSale 抢购：在Redis中原子扣减库存并校验限购，成功后请求进入下单队列，返回票据供轮询结果
+func PurchaseFlashSale(userID, saleID int, req *model.FlashSalePurchaseRequest) (*model.FlashSaleTicket, error) {
+	sale, err := getCachedFlashSale(saleID)
+	if err != nil {
+		return nil, err
+	}
+
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+	if quantity < 1 || quantity > sale.PerUserLimit {
+		return nil, newValidationError("购买数量必须在 1 到 %d 之间", sale.PerUserLimit)
+	}
+	now := time.Now()
+	if now.Before(sale.StartAt) || !now.Before(sale.EndAt) {
+		return nil, dao.ErrFlashSaleNotActive
+	}
+
+	ticket, err := newFlashSaleTicket()
+	if err != nil {
+		return nil, fmt.Errorf("生成票据失败: %w", err)
+	}
+	item := &dao.FlashSaleQueueItem{
+		Ticket:      ticket,
+		FlashSaleID: saleID,
+		UserID:      userID,
+		Quantity:    quantity,
+	}
+	if _, err := dao.ReserveFlashSale(item, sale.PerUserLimit, sale.EndAt); err != nil {
+		return nil, err
+	}
+
+	return &model.FlashSaleTicket{
+		Ticket:      ticket,
+		FlashSaleID: saleID,
+		UserID:      userID,
+		Quantity:    quantity,
+		Status:      model.FlashSaleTicketQueued,
+		CreatedAt:   now,
+	}, nil
+}
+
+// GetFlashSaleTicket 查询抢购票据结果，只能查询自己的票据
+func GetFlashSaleTicket(userID int, ticket string) (*model.FlashSaleTicket, error) {
+	record, err := dao.GetFlashSaleTicket(ticket)
+	if err != nil {
+		return nil, fmt.Errorf("查询票据失败: %w", err)
+	}
+	if record == nil || record.UserID != userID {
+		return nil, fmt.Errorf("票据不存在")
+	}
+	return record, nil
+}
+
+// AdminGetFlashSales 获取全部抢购（管理后台）
+func AdminGetFlashSales() ([]model.FlashSale, error) {
+	sales, err := dao.GetFlashSales(nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sales {
+		fillFlashSaleRemaining(&sales[i])
+	}
+	return sales, nil
+}
+
+// GetFlashSaleQueueLength 获取排队等待下单的抢购请求数
+func GetFlashSaleQueueLength() (int64, error) {
+	return dao.GetFlashSaleQueueLength()
+}
+
+// CreateFlashSale 创建限时抢购，同一商品同时只能有一场未结束的抢购或抽签
+func CreateFlashSale(req *model.CreateFlashSaleRequest) (*model.FlashSale, error) {
+	if err := validateProductPrice(req.Price); err != nil {
+		return nil, err
+	}
+	if req.Stock < 1 {
+		return nil, newValidationError("抢购库存必须大于0")
+	}
+	if req.PerUserLimit == 0 {
+		req.PerUserLimit = 1
+	}
+	if req.PerUserLimit < 1 || req.PerUserLimit > maxFlashSalePerUserLimit {
+		return nil, newValidationError("每人限购数量必须在 1 到 %d 之间", maxFlashSalePerUserLimit)
+	}
+	if req.StartAt.IsZero() || !req.EndAt.After(req.StartAt) {
+		return nil, newValidationError("结束时间必须晚于开始时间")
+	}
+	if !req.EndAt.After(time.Now()) {
+		return nil, newValidationError("结束时间必须晚于当前时间")
+	}
+
+	product, err := dao.GetProductByIDUnscoped(req.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("查询商品失败: %w", err)
+	}
+	if product == nil || product.DeletedAt.Valid {
+		return nil, fmt.Errorf("商品不存在")
+	}
+	if err := checkProductCampaignFree(req.ProductID); err != nil {
+		return nil, err
+	}
+
+	sale := &model.FlashSale{
+		ProductID:    req.ProductID,
+		Price:        req.Price,
+		Stock:        req.Stock,
+		PerUserLimit: req.PerUserLimit,
+		StartAt:      req.StartAt,
+		EndAt:        req.EndAt,
+		Status:       model.FlashSaleStatusScheduled,
+	}
+	if err := dao.CreateFlashSale(sale); err != nil {
+		return nil, fmt.Errorf("创建抢购失败: %w", err)
+	}
+	return dao.GetFlashSaleByID(sale.ID)
+}
+
+// CancelFlashSale 取消未开始或进行中的抢购，已排队的请求仍会下单
+func CancelFlashSale(saleID int) (*model.FlashSale, error) {
+	sale, err := dao.GetFlashSaleByID(saleID)
+	if err != nil {
+		return nil, fmt.Errorf("查询抢购失败: %w", err)
+	}
+	if sale == nil {
+		return nil, ErrFlashSaleNotFound
+	}
+
+	if sale.Status != model.FlashSaleStatusScheduled && sale.Status != model.FlashSaleStatusActive {
+		return nil, newValidationError("抢购状态为 %s，不能取消", sale.Status)
+	}
+	ok, err := dao.UpdateFlashSaleStatus(saleID, sale.Status, model.FlashSaleStatusCancelled, nil)
+	if err != nil {
+		return nil, fmt.Errorf("取消抢购失败: %w", err)
+	}
+	if !ok {
+		return nil, newValidationError("抢购状态已变化，请刷新后重试")
+	}
+	invalidateFlashSale(saleID)
+	if err := dao.CloseFlashSaleStock(saleID); err != nil {
+		return nil, fmt.Errorf("关闭抢购库存失败: %w", err)
+	}
+	return dao.GetFlashSaleByID(saleID)
+}
+
+// checkFlashSaleLines 抢购进行中的商品不能通过普通结算购买，避免抢购库存被普通订单占用
+func checkFlashSaleLines(lines []orderLine) error {
+	active, err := dao.GetActiveFlashSaleProductIDs()
+	if err != nil {
+		return fmt.Errorf("查询抢购失败: %w", err)
+	}
+	for _, line := range lines {
+		if active[line.productID] {
+			return newValidationError("商品 %d 正在限时抢购中，请通过抢购下单", line.productID)
+		}
+	}
+	return nil
+}
+
+// startFlashSale 开始抢购：预载库存到Redis（不超过商品当前库存）后标记为进行中
+func startFlashSale(sale *model.FlashSale) error {
+	defer invalidateFlashSale(sale.ID)
+
+	if !time.Now().Before(sale.EndAt) {
+		_, err := dao.UpdateFlashSaleStatus(sale.ID, model.FlashSaleStatusScheduled, model.FlashSaleStatusEnded, nil)
+		return err
+	}
+
+	product, err := dao.GetProductByIDUnscoped(sale.ProductID)
+	if err != nil {
+		return err
+	}
+	if product == nil || product.DeletedAt.Valid {
+		_, err := dao.UpdateFlashSaleStatus(sale.ID, model.FlashSaleStatusScheduled, model.FlashSaleStatusCancelled, nil)
+		return err
+	}
+	stock := sale.Stock
+	if product.Stock < stock {
+		stock = product.Stock
+	}
+
+	// 先写Redis再更新状态，更新失败时下次扫描重试，SETNX 不会覆盖已扣减的库存
+	if err := dao.LoadFlashSaleStock(sale.ID, stock, sale.EndAt); err != nil {
+		return err
+	}
+	_, err = dao.UpdateFlashSaleStatus(sale.ID, model.FlashSaleStatusScheduled, model.FlashSaleStatusActive,
+		map[string]interface{}{"stock": stock})
+	return err
+}
+
+// startDueFlashSales 开始所有到达开始时间的抢购，结束到达结束时间的抢购
+func startDueFlashSales() {
+	now := time.Now()
+	due, err := dao.GetDueFlashSales(now)
+	if err != nil {
+		log.Printf("Warning: Failed to query due flash sales: %v", err)
+		return
+	}
+	for i := range due {
+		if err := startFlashSale(&due[i]); err != nil {
+			log.Printf("Warning: Failed to start flash sale %d: %v", due[i].ID, err)
+			continue
+		}
+		log.Printf("Flash sale %d started", due[i].ID)
+	}
+
+	ended, err := dao.GetEndedActiveFlashSales(now)
+	if err != nil {
+		log.Printf("Warning: Failed to query ended flash sales: %v", err)
+		return
+	}
+	for _, sale := range ended {
+		if _, err := dao.UpdateFlashSaleStatus(sale.ID, model.FlashSaleStatusActive, model.FlashSaleStatusEnded, nil); err != nil {
+			log.Printf("Warning: Failed to end flash sale %d: %v", sale.ID, err)
+			continue
+		}
+		invalidateFlashSale(sale.ID)
+		if err := dao.CloseFlashSaleStock(sale.ID); err != nil {
+			log.Printf("Warning: Failed to close flash sale %d stock: %v", sale.ID, err)
+		}
+		log.Printf("Flash sale %d ended", sale.ID)
+	}
+}
+
+// failFlashSaleTicket 下单失败：票据标记为失败，退回抢购库存和用户已购数量
+func failFlashSaleTicket(item *dao.FlashSaleQueueItem, reason string) {
+	if err := dao.ReleaseFlashSale(item); err != nil {
+		log.Printf("Warning: Failed to release flash sale %d stock for ticket %s: %v", item.FlashSaleID, item.Ticket, err)
+	}
+	dao.SaveFlashSaleTicket(&model.FlashSaleTicket{
+		Ticket:      item.Ticket,
+		FlashSaleID: item.FlashSaleID,
+		UserID:      item.UserID,
+		Quantity:    item.Quantity,
+		Status:      model.FlashSaleTicketFailed,
+		Error:       reason,
+		CreatedAt:   time.Now(),
+	})
+}
+
+// succeedFlashSaleTicket 票据标记为下单成功
+func succeedFlashSaleTicket(item *dao.FlashSaleQueueItem, orderID int, totalPrice model.Money) {
+	dao.SaveFlashSaleTicket(&model.FlashSaleTicket{
+		Ticket:      item.Ticket,
+		FlashSaleID: item.FlashSaleID,
+		UserID:      item.UserID,
+		Quantity:    item.Quantity,
+		Status:      model.FlashSaleTicketSuccess,
+		OrderID:     orderID,
+		TotalPrice:  &totalPrice,
+		CreatedAt:   time.Now(),
+	})
+}
+
+// processFlashSaleItem 为一个排队的抢购请求创建订单，同一票据只会下单一次
+// 返回错误表示临时故障（如数据库不可用），请求需要稍后重试
+func processFlashSaleItem(item *dao.FlashSaleQueueItem) error {
+	existing, err := dao.GetFlashSaleOrderByTicket(item.Ticket)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		order, err := dao.GetOrderByIDForAdmin(existing.OrderID)
+		if err != nil {
+			return err
+		}
+		if order != nil {
+			succeedFlashSaleTicket(item, order.ID, order.TotalPrice)
+		}
+		return nil
+	}
+
+	sale, err := getCachedFlashSale(item.FlashSaleID)
+	if errors.Is(err, ErrFlashSaleNotFound) {
+		failFlashSaleTicket(item, err.Error())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var order *model.Order
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = createOrderTx(tx, item.UserID, []orderLine{{
+			productID: sale.ProductID,
+			quantity:  item.Quantity,
+			priced:    true,
+			unitPrice: sale.Price,
+		}})
+		if err != nil {
+			return err
+		}
+		return dao.CreateFlashSaleOrder(tx, &model.FlashSaleOrder{
+			Ticket:      item.Ticket,
+			FlashSaleID: item.FlashSaleID,
+			UserID:      item.UserID,
+			OrderID:     order.ID,
+			Quantity:    item.Quantity,
+		})
+	})
+	if err != nil {
+		var stockErr *OutOfStockError
+		var limitErr *PurchaseLimitError
+		var notFoundErr *ProductNotFoundError
+		if errors.As(err, &stockErr) || errors.As(err, &limitErr) || errors.As(err, &notFoundErr) {
+			failFlashSaleTicket(item, err.Error())
+			return nil
+		}
+		// 其他实例已处理同一票据时唯一索引冲突，下次处理时按已有订单返回
+		return err
+	}
+
+	scheduleOrderExpiry(order)
+	succeedFlashSaleTicket(item, order.ID, order.TotalPrice)
+	return nil
+}
+
+// drainFlashSaleQueue 依次处理队列中的抢购请求，直到队列为空或遇到临时故障
+func drainFlashSaleQueue() {
+	for {
+		item, raw, err := dao.PopFlashSaleQueue()
+		if err != nil {
+			log.Printf("Warning: Failed to read flash sale queue: %v", err)
+			return
+		}
+		if item == nil {
+			return
+		}
+
+		if err := processFlashSaleItem(item); err != nil {
+			log.Printf("Warning: Failed to process flash sale ticket %s, will retry: %v", item.Ticket, err)
+			if err := dao.RetryFlashSaleQueue(raw); err != nil {
+				log.Printf("Warning: Failed to requeue flash sale ticket %s: %v", item.Ticket, err)
+			}
+			return
+		}
+		if err := dao.AckFlashSaleQueue(raw); err != nil {
+			log.Printf("Warning: Failed to ack flash sale ticket %s: %v", item.Ticket, err)
+		}
+	}
+}
+
+// RunFlashSaleWorker 后台开始和结束抢购，并将排队的抢购请求写入MySQL，ctx 取消后退出
+func RunFlashSaleWorker(ctx context.Context, interval time.Duration) {
+	if n, err := dao.RequeueFlashSaleProcessing(); err != nil {
+		log.Printf("Warning: Failed to requeue flash sale tickets: %v", err)
+	} else if n > 0 {
+		log.Printf("Requeued %d unfinished flash sale ticket(s)", n)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("Flash sale worker started (interval %s)", interval)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Flash sale worker stopped")
+			return
+		case <-ticker.C:
+			startDueFlashSales()
+			drainFlashSaleQueue()
+		}
+	}
+}
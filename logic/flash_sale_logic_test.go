@@ -0,0 +1,105 @@
+package logic
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"shop/dao"
+	"shop/global/db"
+	"shop/global/db/dbtest"
+	"shop/global/redis/redistest"
+	"shop/model"
+)
+
+// setupFlashSale 创建商品和一场已开始的抢购
+func setupFlashSale(t *testing.T) (*model.Product, *model.FlashSale) {
+	t.Helper()
+	dbtest.Open(t, &model.Product{}, &model.Order{}, &model.OrderItem{}, &model.OrderStatusHistory{},
+		&model.FlashSale{}, &model.FlashSaleOrder{}, &model.Raffle{})
+	redistest.Open(t)
+
+	product := &model.Product{Name: "拉布布 搪胶脸", Price: model.Cents(9900), Stock: 10}
+	if err := db.DB.Create(product).Error; err != nil {
+		t.Fatalf("创建商品失败: %v", err)
+	}
+	now := time.Now()
+	sale, err := CreateFlashSale(&model.CreateFlashSaleRequest{
+		ProductID:    product.ID,
+		Price:        model.Cents(5900),
+		Stock:        5,
+		PerUserLimit: 2,
+		StartAt:      now.Add(-time.Minute),
+		EndAt:        now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("创建抢购失败: %v", err)
+	}
+	t.Cleanup(func() { invalidateFlashSale(sale.ID) })
+	return product, sale
+}
+
+func cachedFlashSaleStatus(t *testing.T, saleID int) string {
+	t.Helper()
+	sale, err := getCachedFlashSale(saleID)
+	if err != nil {
+		t.Fatalf("查询抢购失败: %v", err)
+	}
+	return sale.Status
+}
+
+func TestFlashSaleCacheInvalidatedOnStatusChange(t *testing.T) {
+	_, sale := setupFlashSale(t)
+
+	if got := cachedFlashSaleStatus(t, sale.ID); got != model.FlashSaleStatusScheduled {
+		t.Fatalf("缓存的抢购状态为 %s，预期 %s", got, model.FlashSaleStatusScheduled)
+	}
+
+	startDueFlashSales()
+	if got := cachedFlashSaleStatus(t, sale.ID); got != model.FlashSaleStatusActive {
+		t.Fatalf("开始后缓存的抢购状态为 %s，预期 %s", got, model.FlashSaleStatusActive)
+	}
+
+	if _, err := CancelFlashSale(sale.ID); err != nil {
+		t.Fatalf("取消抢购失败: %v", err)
+	}
+	if got := cachedFlashSaleStatus(t, sale.ID); got != model.FlashSaleStatusCancelled {
+		t.Fatalf("取消后缓存的抢购状态为 %s，预期 %s", got, model.FlashSaleStatusCancelled)
+	}
+}
+
+// TestProcessFlashSaleItemProductDeleted 排队期间商品下架：票据失败并退回抢购库存，不作为临时故障重试
+func TestProcessFlashSaleItemProductDeleted(t *testing.T) {
+	product, sale := setupFlashSale(t)
+	startDueFlashSales()
+
+	ticket, err := PurchaseFlashSale(1, sale.ID, &model.FlashSalePurchaseRequest{Quantity: 2})
+	if err != nil {
+		t.Fatalf("抢购失败: %v", err)
+	}
+	if err := db.DB.Delete(product).Error; err != nil {
+		t.Fatalf("下架商品失败: %v", err)
+	}
+
+	item, raw, err := dao.PopFlashSaleQueue()
+	if err != nil || item == nil {
+		t.Fatalf("读取下单队列失败: %v", err)
+	}
+	if err := processFlashSaleItem(item); err != nil {
+		t.Fatalf("商品不存在被当作临时故障: %v", err)
+	}
+	if err := dao.AckFlashSaleQueue(raw); err != nil {
+		t.Fatalf("确认队列失败: %v", err)
+	}
+
+	result, err := GetFlashSaleTicket(1, ticket.Ticket)
+	if err != nil {
+		t.Fatalf("查询票据失败: %v", err)
+	}
+	if result.Status != model.FlashSaleTicketFailed || !strings.Contains(result.Error, "商品不存在") {
+		t.Fatalf("票据为 %+v，预期因商品不存在失败", result)
+	}
+	if stock, err := dao.GetFlashSaleStock(sale.ID); err != nil || stock != 5 {
+		t.Fatalf("抢购剩余库存为 %d（%v），预期退回到 5", stock, err)
+	}
+}
@@ -0,0 +1,188 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	"shop/dao"
+	"shop/global/auth"
+	"shop/global/db"
+	"shop/model"
+)
+
+// GetGuestCart 获取游客购物车
+func GetGuestCart(cartID string) ([]model.CartItem, error) {
+	return dao.GetGuestCartItems(cartID)
+}
+
+// AddToGuestCart 添加商品到游客购物车，已存在时增加数量
+func AddToGuestCart(cartID string, req *model.AddToCartRequest) error {
+	product, err := dao.GetProductByIDTx(db.DB, req.ProductID)
+	if err != nil {
+		return fmt.Errorf("查询商品失败: %w", err)
+	}
+	if product == nil {
+		return fmt.Errorf("商品不存在")
+	}
+	if _, err := dao.IncrGuestCartItem(cartID, req.ProductID, req.Quantity, product.Stock, product.Price); err != nil {
+		if errors.Is(err, dao.ErrCartStockExceeded) {
+			return fmt.Errorf("库存不足")
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateGuestCartItem 更新游客购物车商品数量，购物车项是否存在和库存在Redis脚本中与更新一起原子地检查
+func UpdateGuestCartItem(cartID string, productID int, req *model.UpdateCartRequest) error {
+	stock, err := dao.GetProductStock(productID)
+	if err != nil {
+		return fmt.Errorf("查询商品失败: %w", err)
+	}
+	return dao.UpdateGuestCartItemQuantity(cartID, productID, req.Quantity, stock)
+}
+
+// IncrementGuestCartItem 增量更新游客购物车商品数量，数量减到0时删除（在Redis中原子完成）
+func IncrementGuestCartItem(cartID string, productID, delta int) error {
+	stock := math.MaxInt
+	var price model.Money
+	if delta > 0 {
+		product, err := dao.GetProductByIDTx(db.DB, productID)
+		if err != nil {
+			return fmt.Errorf("查询商品失败: %w", err)
+		}
+		if product == nil {
+			return fmt.Errorf("商品不存在")
+		}
+		stock, price = product.Stock, product.Price
+	}
+	if _, err := dao.IncrGuestCartItem(cartID, productID, delta, stock, price); err != nil {
+		if errors.Is(err, dao.ErrCartStockExceeded) {
+			return fmt.Errorf("库存不足，当前库存: %d", stock)
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteGuestCartItem 从游客购物车删除商品
+func DeleteGuestCartItem(cartID string, productID int) error {
+	existing, err := dao.GetGuestCartItemQuantity(cartID, productID)
+	if err != nil {
+		return fmt.Errorf("查询购物车项失败: %w", err)
+	}
+	if existing == 0 {
+		return fmt.Errorf("购物车项不存在")
+	}
+	return dao.DeleteGuestCartItem(cartID, productID)
+}
+
+// mergeGuestCart 将游客购物车合并到用户购物车，合并后删除游客购物车
+// 合并规则：同一商品的数量相加，但不超过商品当前库存和用户剩余可购数量（每单、每日、累计限购）；
+// 用户购物车中原有的数量不会因合并而减少；已下架的商品不合并
+func mergeGuestCart(userID int, cartID string) (*model.CartMergeResult, error) {
+	quantities, prices, err := dao.GetGuestCartLines(cartID)
+	if err != nil {
+		return nil, err
+	}
+
+	productIDs := make([]int, 0, len(quantities))
+	for productID := range quantities {
+		productIDs = append(productIDs, productID)
+	}
+	sort.Ints(productIDs)
+
+	result := &model.CartMergeResult{Adjusted: []model.CartMergeAdjustment{}}
+	for _, productID := range productIDs {
+		guestQuantity := quantities[productID]
+		product, err := dao.GetProductByIDTx(db.DB, productID)
+		if err != nil {
+			return nil, fmt.Errorf("查询商品失败: %w", err)
+		}
+		if product == nil {
+			result.Adjusted = append(result.Adjusted, model.CartMergeAdjustment{
+				ProductID: productID,
+				Requested: guestQuantity,
+				Reason:    model.CartMergeUnavailable,
+			})
+			continue
+		}
+
+		existingItem, err := dao.GetCartItemByUserAndProductFromRedis(userID, productID)
+		if err != nil {
+			return nil, fmt.Errorf("查询购物车失败: %w", err)
+		}
+		existing := 0
+		if existingItem != nil {
+			existing = existingItem.Quantity
+		}
+
+		merged := existing + guestQuantity
+		reason := ""
+		if merged > product.Stock {
+			merged, reason = product.Stock, model.CartMergeOutOfStock
+		}
+		limit, err := maxPurchasableQuantity(userID, product)
+		if err != nil {
+			return nil, err
+		}
+		if merged > limit {
+			merged, reason = limit, model.CartMergePurchaseLimit
+		}
+		if merged < existing {
+			merged = existing
+		}
+
+		added := merged - existing
+		if added > 0 {
+			result.Merged++
+			// 新加入用户购物车的商品沿用游客加入时的单价，用户购物车中已有的单价保持不变
+			price, ok := prices[productID]
+			if !ok {
+				price = product.Price
+			}
+			if err := dao.AddCartItemToRedis(userID, productID, merged, price); err != nil {
+				return nil, fmt.Errorf("合并购物车失败: %w", err)
+			}
+		}
+		if added < guestQuantity {
+			result.Adjusted = append(result.Adjusted, model.CartMergeAdjustment{
+				ProductID: productID,
+				Requested: guestQuantity,
+				Added:     added,
+				Reason:    reason,
+			})
+		}
+	}
+
+	if err := dao.DeleteGuestCart(cartID); err != nil {
+		return nil, fmt.Errorf("删除游客购物车失败: %w", err)
+	}
+	return result, nil
+}
+
+// MergeGuestCartByToken 校验游客购物车令牌后合并到用户购物车，令牌无效或购物车为空时返回nil
+func MergeGuestCartByToken(userID int, token string) *model.CartMergeResult {
+	if token == "" {
+		return nil
+	}
+	cartID, err := auth.ParseGuestCartToken(token)
+	if err != nil {
+		return nil
+	}
+	quantities, err := dao.GetGuestCartQuantities(cartID)
+	if err != nil || len(quantities) == 0 {
+		return nil
+	}
+
+	result, err := mergeGuestCart(userID, cartID)
+	if err != nil {
+		// 合并失败不影响登录，游客购物车保留，下次登录时重试
+		log.Printf("Warning: Failed to merge guest cart for user %d: %v", userID, err)
+		return nil
+	}
+	return result
+}
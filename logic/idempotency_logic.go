@@ -0,0 +1,95 @@
+package logic
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"shop/dao"
+)
+
+// maxIdempotencyKeyLength Idempotency-Key 最大长度
+const maxIdempotencyKeyLength = 255
+
+var (
+	// ErrIdempotencyKeyReused 相同的 Idempotency-Key 用于了不同的请求
+	ErrIdempotencyKeyReused = errors.New("Idempotency-Key 已用于不同的请求")
+	// ErrIdempotencyInProgress 相同 Idempotency-Key 的请求尚未处理完成
+	ErrIdempotencyInProgress = errors.New("相同 Idempotency-Key 的请求正在处理中，请稍后重试")
+)
+
+// RequestFingerprint 计算请求摘要，重试请求的方法、路径和请求体必须与首次请求一致
+// JSON 请求体先规范化（去掉空白、对象键排序）再计算，只是格式不同的重试视为同一请求；
+// 非 JSON 请求体按原始字节计算
+func RequestFingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(canonicalJSON(body))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// canonicalJSON 返回规范化的 JSON，数字保持原样不经过浮点转换；不是合法 JSON 时原样返回
+func canonicalJSON(body []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return body
+	}
+	canonical, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return canonical
+}
+
+// BeginIdempotentRequest 开始处理带 Idempotency-Key 的请求
+// 首次请求返回 nil, nil；已完成的重试返回首次响应用于重放
+func BeginIdempotentRequest(userID int, key, fingerprint string) (*dao.IdempotencyRecord, error) {
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, newValidationError("Idempotency-Key 不能超过%d个字符", maxIdempotencyKeyLength)
+	}
+
+	acquired, record, err := dao.AcquireIdempotencyKey(userID, key, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	if acquired {
+		return nil, nil
+	}
+	if record == nil {
+		return nil, ErrIdempotencyInProgress
+	}
+	if record.Fingerprint != fingerprint {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if record.State != dao.IdempotencyStateCompleted {
+		return nil, ErrIdempotencyInProgress
+	}
+	return record, nil
+}
+
+// CompleteIdempotentRequest 保存首次请求的响应
+// 服务端错误（5xx）不保存，释放键以便客户端重试
+func CompleteIdempotentRequest(userID int, key, fingerprint string, statusCode int, contentType string, body []byte) {
+	if statusCode >= 500 {
+		if err := dao.ReleaseIdempotencyKey(userID, key); err != nil {
+			log.Printf("Warning: Failed to release idempotency key %q: %v", key, err)
+		}
+		return
+	}
+
+	record := &dao.IdempotencyRecord{
+		State:       dao.IdempotencyStateCompleted,
+		Fingerprint: fingerprint,
+		StatusCode:  statusCode,
+		ContentType: contentType,
+		Body:        append([]byte(nil), body...),
+	}
+	if err := dao.SaveIdempotencyResponse(userID, key, record); err != nil {
+		log.Printf("Warning: Failed to save idempotent response for key %q: %v", key, err)
+	}
+}
@@ -0,0 +1,101 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"shop/config"
+	"shop/dao"
+	"shop/model"
+)
+
+// orderExpiryBatchSize 每次扫描处理的最大订单数
+const orderExpiryBatchSize = 100
+
+// paymentTimeout 未支付订单的支付时限
+func paymentTimeout() time.Duration {
+	if config.AppConfig != nil && config.AppConfig.Order.PaymentTimeout > 0 {
+		return config.AppConfig.Order.PaymentTimeout
+	}
+	return 30 * time.Minute
+}
+
+// scheduleOrderExpiry 下单后加入超时取消队列
+func scheduleOrderExpiry(order *model.Order) {
+	deadline := order.CreatedAt.Add(paymentTimeout())
+	if err := dao.ScheduleOrderExpiry(order.ID, deadline); err != nil {
+		// 入队失败时，服务重启时的补偿扫描会重新入队
+		log.Printf("Warning: Failed to schedule expiry for order %d: %v", order.ID, err)
+	}
+}
+
+// ExpireOrder 取消超时未支付的订单并归还库存，订单已不是待支付状态时忽略
+func ExpireOrder(orderID int) error {
+	_, err := transitionOrder(orderTransition{
+		orderID: orderID,
+		to:      model.OrderStatusCancelled,
+		reason:  "超时未支付，系统自动取消",
+	})
+	var transitionErr *InvalidTransitionError
+	if errors.As(err, &transitionErr) || errors.Is(err, ErrOrderNotFound) {
+		return nil
+	}
+	return err
+}
+
+// requeuePendingOrders 将所有待支付订单重新加入延迟队列（已在队列中的保持原截止时间）
+func requeuePendingOrders() error {
+	orders, err := dao.GetOrdersByStatus(model.OrderStatusPendingPayment)
+	if err != nil {
+		return err
+	}
+	for i := range orders {
+		scheduleOrderExpiry(&orders[i])
+	}
+	return nil
+}
+
+// processDueOrderExpiries 处理已到期的订单
+func processDueOrderExpiries(retryDelay time.Duration) {
+	orderIDs, err := dao.GetDueOrderExpiries(time.Now(), orderExpiryBatchSize)
+	if err != nil {
+		log.Printf("Warning: Failed to read order expiry queue: %v", err)
+		return
+	}
+
+	for _, orderID := range orderIDs {
+		claimed, err := dao.ClaimOrderExpiry(orderID)
+		if err != nil || !claimed {
+			continue
+		}
+		if err := ExpireOrder(orderID); err != nil {
+			log.Printf("Warning: Failed to expire order %d, will retry: %v", orderID, err)
+			dao.RescheduleOrderExpiry(orderID, time.Now().Add(retryDelay))
+			continue
+		}
+		log.Printf("Order %d expired", orderID)
+	}
+}
+
+// RunOrderExpiryWorker 后台扫描延迟队列，自动取消超时未支付的订单，ctx 取消后退出
+func RunOrderExpiryWorker(ctx context.Context, interval time.Duration) {
+	if err := requeuePendingOrders(); err != nil {
+		log.Printf("Warning: Failed to requeue pending orders: %v", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("Order expiry worker started (timeout %s, interval %s)", paymentTimeout(), interval)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Order expiry worker stopped")
+			return
+		case <-ticker.C:
+			processDueOrderExpiries(interval)
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package logic
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"sort"
 
 	"gorm.io/gorm"
 	"shop/dao"
@@ -9,32 +12,36 @@ import (
 	"shop/model"
 )
 
-// CreateOrder 创建订单（使用购物车中所有商品）
-func CreateOrder(userID int, req *model.CreateOrderRequest) (int64, float64, error) {
-	// 开始事务
-	tx := db.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
+// orderLine 下单明细
+type orderLine struct {
+	productID int
+	quantity  int
+	// priced 为 true 时使用 unitPrice 作为成交单价（套装分摊价、抢购价），否则使用商品当前价格
+	priced    bool
+	unitPrice model.Money
+	bundleID  int // 非0时表示套装成员
+}
 
-	// 获取购物车中所有商品
+// CreateOrder 创建订单（使用购物车中所有商品和套装）
+func CreateOrder(userID int, req *model.CreateOrderRequest) (int64, model.Money, error) {
+	// 获取购物车中所有商品和套装
 	cartItems, err := dao.GetCartItemsFromRedis(userID)
 	if err != nil {
-		tx.Rollback()
-		return 0, 0, fmt.Errorf("查询购物车失败: %w", err)
+		return 0, model.Money{}, fmt.Errorf("查询购物车失败: %w", err)
+	}
+	cartBundles, err := dao.GetCartBundlesFromRedis(userID)
+	if err != nil {
+		return 0, model.Money{}, fmt.Errorf("查询购物车失败: %w", err)
 	}
 
-	if len(cartItems) == 0 {
-		tx.Rollback()
-		return 0, 0, fmt.Errorf("购物车为空")
+	if len(cartItems) == 0 && len(cartBundles) == 0 {
+		return 0, model.Money{}, fmt.Errorf("购物车为空")
 	}
 
-	// 如果指定了商品ID列表，则只处理指定的商品；否则处理所有商品
+	// 指定了商品ID或套装ID列表时只处理指定的购物车行；否则处理购物车中所有商品和套装
 	var itemsToProcess []model.CartItem
-	if req != nil && req.CartItemIDs != nil && len(req.CartItemIDs) > 0 {
-		// 只处理指定的商品
+	var bundlesToProcess []model.CartBundle
+	if req != nil && (len(req.CartItemIDs) > 0 || len(req.BundleIDs) > 0) {
 		productIDMap := make(map[int]bool)
 		for _, id := range req.CartItemIDs {
 			productIDMap[id] = true
@@ -44,111 +51,178 @@ func CreateOrder(userID int, req *model.CreateOrderRequest) (int64, float64, err
 				itemsToProcess = append(itemsToProcess, item)
 			}
 		}
-		if len(itemsToProcess) == 0 {
-			tx.Rollback()
-			return 0, 0, fmt.Errorf("指定的商品不在购物车中")
+		bundleIDMap := make(map[int]bool)
+		for _, id := range req.BundleIDs {
+			bundleIDMap[id] = true
+		}
+		for _, b := range cartBundles {
+			if bundleIDMap[b.BundleID] {
+				bundlesToProcess = append(bundlesToProcess, b)
+			}
+		}
+		if len(itemsToProcess) == 0 && len(bundlesToProcess) == 0 {
+			return 0, model.Money{}, fmt.Errorf("指定的商品不在购物车中")
 		}
 	} else {
-		// 处理购物车中所有商品
 		itemsToProcess = cartItems
+		bundlesToProcess = cartBundles
 	}
 
-	// 查询购物车项并计算总价
-	var totalPrice float64
-	var orderItems []struct {
-		cartItemID int
-		productID  int
-		quantity   int
-		price      float64
+	lines := make([]orderLine, 0, len(itemsToProcess))
+	for _, cartItem := range itemsToProcess {
+		lines = append(lines, orderLine{productID: cartItem.ProductID, quantity: cartItem.Quantity})
 	}
 
-	for _, cartItem := range itemsToProcess {
-		// 获取商品信息
-		product, err := dao.GetProductByID(fmt.Sprintf("%d", cartItem.ProductID))
-		if err != nil || product == nil {
-			tx.Rollback()
-			return 0, 0, fmt.Errorf("商品不存在: %d", cartItem.ProductID)
+	var order *model.Order
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		allLines := lines
+		for _, b := range bundlesToProcess {
+			bundleLines, err := expandBundleTx(tx, b.BundleID, b.Quantity)
+			if err != nil {
+				return err
+			}
+			allLines = append(allLines, bundleLines...)
+		}
+		if err := checkFlashSaleLines(allLines); err != nil {
+			return err
+		}
+		if err := checkRaffleLines(allLines); err != nil {
+			return err
 		}
 
-		// 检查库存
-		if cartItem.Quantity > product.Stock {
-			tx.Rollback()
-			return 0, 0, fmt.Errorf("商品库存不足: %s (需要: %d, 库存: %d)", product.Name, cartItem.Quantity, product.Stock)
+		var err error
+		order, err = createOrderTx(tx, userID, allLines)
+		if err != nil {
+			return err
 		}
 
-		itemTotal := product.Price * float64(cartItem.Quantity)
-		totalPrice += itemTotal
-		orderItems = append(orderItems, struct {
-			cartItemID int
-			productID  int
-			quantity   int
-			price      float64
-		}{cartItem.ProductID, cartItem.ProductID, cartItem.Quantity, product.Price})
+		// 促销和优惠码在同一事务中计算并记录，优惠码使用次数与订单一起提交
+		couponCode := ""
+		if req != nil {
+			couponCode = req.CouponCode
+		}
+		return applyOrderPromotionsTx(tx, userID, order, couponCode)
+	})
+	if err != nil {
+		return 0, model.Money{}, err
 	}
 
-	// 创建订单
-	order := model.Order{
-		UserID:     userID,
-		TotalPrice: totalPrice,
-		Status:     "pending",
-	}
-	if err := tx.Create(&order).Error; err != nil {
-		tx.Rollback()
-		return 0, 0, fmt.Errorf("创建订单失败: %w", err)
+	// 超时未支付自动取消
+	scheduleOrderExpiry(order)
+
+	// 订单提交成功后再从Redis删除购物车项，事务失败时购物车保持不变
+	for _, line := range lines {
+		if err := dao.DeleteCartItemFromRedis(userID, line.productID); err != nil {
+			// 这里不影响订单，只记录错误
+			log.Printf("Warning: Failed to remove cart item %d for user %d: %v", line.productID, userID, err)
+		}
 	}
+	removeOrderedBundlesFromCart(userID, bundlesToProcess)
 
-	// 创建订单项并更新库存
-	for _, item := range orderItems {
-		// 创建订单项
-		orderItem := model.OrderItem{
-			OrderID:   order.ID,
-			ProductID: item.productID,
-			Quantity:  item.quantity,
-			Price:     item.price,
+	return int64(order.ID), order.TotalPrice, nil
+}
+
+// createOrderTx 在事务中创建订单：读取价格、条件扣减库存、写入订单和订单项
+func createOrderTx(tx *gorm.DB, userID int, lines []orderLine) (*model.Order, error) {
+	var totalPrice model.Money
+	items := make([]model.OrderItem, 0, len(lines))
+	products := make(map[int]*model.Product, len(lines))
+	quantities := make(map[int]int, len(lines))
+
+	// 按商品ID顺序加行锁，避免并发下单时互相等待造成死锁
+	lines = append([]orderLine(nil), lines...)
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].productID < lines[j].productID
+	})
+
+	for _, line := range lines {
+		product, err := dao.GetProductByIDForUpdate(tx, line.productID)
+		if err != nil {
+			return nil, fmt.Errorf("查询商品失败: %w", err)
 		}
-		if err := tx.Create(&orderItem).Error; err != nil {
-			tx.Rollback()
-			return 0, 0, fmt.Errorf("创建订单项失败: %w", err)
+		if product == nil {
+			return nil, &ProductNotFoundError{ProductID: line.productID}
 		}
 
-		// 更新库存
-		if err := tx.Model(&model.Product{}).
-			Where("id = ?", item.productID).
-			Update("stock", gorm.Expr("stock - ?", item.quantity)).Error; err != nil {
-			tx.Rollback()
-			return 0, 0, fmt.Errorf("更新库存失败: %w", err)
+		// 条件扣减库存，并发下单时只有库存足够的请求能成功
+		if err := dao.DecreaseProductStock(tx, line.productID, line.quantity); err != nil {
+			if errors.Is(err, dao.ErrInsufficientStock) {
+				available, _ := dao.GetProductStockTx(tx, line.productID)
+				return nil, &OutOfStockError{
+					ProductID:   product.ID,
+					ProductName: product.Name,
+					Requested:   line.quantity,
+					Available:   available,
+				}
+			}
+			return nil, fmt.Errorf("更新库存失败: %w", err)
+		}
+		if err := dao.IncreaseProductSales(tx, line.productID, line.quantity); err != nil {
+			return nil, fmt.Errorf("更新销量失败: %w", err)
 		}
+		products[line.productID] = product
+		quantities[line.productID] += line.quantity
 
-		// 从Redis删除购物车项
-		if err := dao.DeleteCartItemFromRedis(userID, item.productID); err != nil {
-			// 这里不阻止订单创建，只记录错误
+		item := model.OrderItem{
+			ProductID: line.productID,
+			Quantity:  line.quantity,
+			Price:     product.Price,
+		}
+		if line.priced {
+			item.Price = line.unitPrice
 		}
+		if line.bundleID != 0 {
+			bundleID := line.bundleID
+			item.BundleID = &bundleID
+		}
+		amount, err := item.Price.Mul(int64(item.Quantity))
+		if err != nil {
+			return nil, fmt.Errorf("计算订单金额失败: %w", err)
+		}
+		totalPrice = totalPrice.Add(amount)
+		items = append(items, item)
 	}
 
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
-		return 0, 0, fmt.Errorf("提交订单失败: %w", err)
+	// 限购在扣减库存后校验：此时已持有全部商品的行锁，同一商品的并发下单在这里按顺序执行；
+	// 已有订单用锁定读读取，能看到先持锁的事务提交的订单，同一用户的并发下单不会同时通过
+	if err := checkPurchaseLimits(tx, userID, products, quantities, true, true); err != nil {
+		return nil, err
 	}
 
-	return int64(order.ID), totalPrice, nil
-}
-
-// GetOrders 获取订单历史
-func GetOrders(userID int) ([]model.Order, error) {
-	orders, err := dao.GetOrdersByUserID(userID)
-	if err != nil {
-		return nil, err
+	// 创建订单
+	order := model.Order{
+		UserID:     userID,
+		TotalPrice: totalPrice,
+		Status:     model.OrderStatusPendingPayment,
+	}
+	if err := tx.Create(&order).Error; err != nil {
+		return nil, fmt.Errorf("创建订单失败: %w", err)
+	}
+	if err := dao.CreateOrderStatusHistory(tx, &model.OrderStatusHistory{
+		OrderID:    order.ID,
+		ToStatus:   model.OrderStatusPendingPayment,
+		OperatorID: userID,
+		Reason:     "创建订单",
+	}); err != nil {
+		return nil, fmt.Errorf("记录订单状态失败: %w", err)
 	}
 
-	// 加载订单项
-	for i := range orders {
-		items, err := dao.GetOrderItems(orders[i].ID)
-		if err == nil {
-			orders[i].Items = items
-		}
+	// 创建订单项
+	for i := range items {
+		items[i].OrderID = order.ID
+	}
+	if err := tx.Create(&items).Error; err != nil {
+		return nil, fmt.Errorf("创建订单项失败: %w", err)
 	}
+	order.Items = items
 
-	return orders, nil
+	return &order, nil
+}
+
+// GetOrders 获取订单历史
+func GetOrders(userID int) ([]model.Order, error) {
+	// 订单项随订单一起预加载，避免逐个订单查询
+	return dao.GetOrdersWithItemsByUserID(userID)
 }
 
 // GetOrder 获取订单详情
@@ -158,14 +232,26 @@ func GetOrder(userID, orderID int) (*model.Order, error) {
 		return nil, err
 	}
 	if order == nil {
-		return nil, fmt.Errorf("订单不存在")
+		return nil, ErrOrderNotFound
 	}
 
-	// 加载订单项
+	// 加载订单项、优惠、状态变更记录和支付记录
 	items, err := dao.GetOrderItems(order.ID)
 	if err == nil {
 		order.Items = items
 	}
+	discounts, err := dao.GetOrderDiscounts(order.ID)
+	if err == nil {
+		order.Discounts = discounts
+	}
+	history, err := dao.GetOrderStatusHistory(order.ID)
+	if err == nil {
+		order.History = history
+	}
+	payments, err := dao.GetPaymentsByOrderID(order.ID)
+	if err == nil {
+		order.Payments = payments
+	}
 
 	return order, nil
 }
@@ -0,0 +1,106 @@
+package logic
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"gorm.io/gorm"
+	"shop/global/db"
+	"shop/global/db/dbtest"
+	"shop/model"
+)
+
+// TestCreateOrderTxConcurrentCheckoutDoesNotOversell N 个用户同时购买库存为 K（K < N）的商品：
+// 恰好 K 个下单成功，其余返回 *OutOfStockError，库存最终为 0 且任何时刻都不为负
+func TestCreateOrderTxConcurrentCheckoutDoesNotOversell(t *testing.T) {
+	dbtest.Open(t, &model.Product{}, &model.Order{}, &model.OrderItem{}, &model.OrderStatusHistory{})
+
+	const stock, buyers = 5, 20
+	product := model.Product{Name: "拉布布 心动马卡龙", Price: model.Cents(9900), Stock: stock}
+	if err := db.DB.Create(&product).Error; err != nil {
+		t.Fatalf("创建商品失败: %v", err)
+	}
+
+	// 下单期间持续读取库存，记录出现过的最小值
+	var minStock atomic.Int64
+	minStock.Store(stock)
+	done := make(chan struct{})
+	var sampler sync.WaitGroup
+	sampler.Add(1)
+	go func() {
+		defer sampler.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			var p model.Product
+			if err := db.DB.Select("stock").First(&p, product.ID).Error; err == nil && int64(p.Stock) < minStock.Load() {
+				minStock.Store(int64(p.Stock))
+			}
+		}
+	}()
+
+	start := make(chan struct{})
+	errs := make([]error, buyers)
+	var wg sync.WaitGroup
+	for i := 0; i < buyers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = db.DB.Transaction(func(tx *gorm.DB) error {
+				_, err := createOrderTx(tx, i+1, []orderLine{{productID: product.ID, quantity: 1}})
+				return err
+			})
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	close(done)
+	sampler.Wait()
+
+	succeeded, outOfStock := 0, 0
+	for i, err := range errs {
+		var stockErr *OutOfStockError
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.As(err, &stockErr):
+			outOfStock++
+			if stockErr.ProductID != product.ID || stockErr.Requested != 1 {
+				t.Errorf("用户 %d: 库存不足错误内容不正确: %+v", i+1, stockErr)
+			}
+		default:
+			t.Errorf("用户 %d: 预期成功或 *OutOfStockError，实际为 %T: %v", i+1, err, err)
+		}
+	}
+	if succeeded != stock || outOfStock != buyers-stock {
+		t.Errorf("成功 %d 单、库存不足 %d 单，预期分别为 %d 和 %d", succeeded, outOfStock, stock, buyers-stock)
+	}
+
+	var after model.Product
+	if err := db.DB.First(&after, product.ID).Error; err != nil {
+		t.Fatalf("查询商品失败: %v", err)
+	}
+	if after.Stock != 0 {
+		t.Errorf("最终库存为 %d，预期为 0", after.Stock)
+	}
+	if after.SalesCount != stock {
+		t.Errorf("最终销量为 %d，预期为 %d", after.SalesCount, stock)
+	}
+	if min := minStock.Load(); min < 0 {
+		t.Errorf("下单过程中库存出现负数: %d", min)
+	}
+
+	var orders int64
+	if err := db.DB.Model(&model.Order{}).Count(&orders).Error; err != nil {
+		t.Fatalf("统计订单失败: %v", err)
+	}
+	if orders != stock {
+		t.Errorf("创建了 %d 个订单，预期为 %d", orders, stock)
+	}
+}
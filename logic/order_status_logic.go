@@ -0,0 +1,250 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"shop/dao"
+	"shop/global/db"
+	"shop/model"
+)
+
+// orderTransitions 订单状态允许的流转
+var orderTransitions = map[string][]string{
+	model.OrderStatusPendingPayment: {model.OrderStatusPaid, model.OrderStatusCancelled},
+	model.OrderStatusPaid:           {model.OrderStatusShipped, model.OrderStatusRefunded},
+	model.OrderStatusShipped:        {model.OrderStatusDelivered, model.OrderStatusCompleted, model.OrderStatusRefunded},
+	model.OrderStatusDelivered:      {model.OrderStatusCompleted, model.OrderStatusRefunded},
+	model.OrderStatusCompleted:      {model.OrderStatusRefunded},
+}
+
+// ErrOrderNotFound 订单不存在（或不属于当前用户）
+var ErrOrderNotFound = errors.New("订单不存在")
+
+// InvalidTransitionError 订单当前状态不允许执行该操作
+type InvalidTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("订单状态为 %s，不能变更为 %s", e.From, e.To)
+}
+
+// CanTransitionOrder 检查订单状态能否从 from 变更为 to
+func CanTransitionOrder(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// orderTransition 一次订单状态变更
+type orderTransition struct {
+	orderID    int
+	userID     int // 非0时校验订单归属
+	to         string
+	operatorID int // 0 表示系统操作
+	reason     string
+	extra      map[string]interface{}
+}
+
+// transitionOrderTx 在事务中锁定订单、校验流转并记录状态变更
+func transitionOrderTx(tx *gorm.DB, t orderTransition) (*model.Order, error) {
+	order, err := dao.GetOrderByIDForUpdate(tx, t.orderID)
+	if err != nil {
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+	if order == nil || (t.userID != 0 && order.UserID != t.userID) {
+		return nil, ErrOrderNotFound
+	}
+	if !CanTransitionOrder(order.Status, t.to) {
+		return nil, &InvalidTransitionError{From: order.Status, To: t.to}
+	}
+
+	if err := dao.UpdateOrderStatus(tx, order.ID, t.to, t.extra); err != nil {
+		return nil, fmt.Errorf("更新订单状态失败: %w", err)
+	}
+	history := &model.OrderStatusHistory{
+		OrderID:    order.ID,
+		FromStatus: order.Status,
+		ToStatus:   t.to,
+		OperatorID: t.operatorID,
+		Reason:     t.reason,
+	}
+	if err := dao.CreateOrderStatusHistory(tx, history); err != nil {
+		return nil, fmt.Errorf("记录订单状态失败: %w", err)
+	}
+
+	// 支付成功后抽取盲盒款式
+	if t.to == model.OrderStatusPaid {
+		if _, err := drawBlindBoxesTx(tx, order); err != nil {
+			return nil, err
+		}
+	}
+
+	// 退款时收回已进入用户库存的物品
+	if t.to == model.OrderStatusRefunded {
+		if err := revokeOwnedItemsTx(tx, order); err != nil {
+			return nil, err
+		}
+	}
+
+	// 取消和退款的订单归还库存，与状态变更在同一事务中完成
+	if t.to == model.OrderStatusCancelled || t.to == model.OrderStatusRefunded {
+		if err := restoreOrderStockTx(tx, order.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	// 取消的订单释放优惠码，退款的订单已经实际使用过优惠码，不再释放
+	if t.to == model.OrderStatusCancelled {
+		if err := releaseCouponRedemptionsTx(tx, order.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	order.Status = t.to
+	return order, nil
+}
+
+// restoreOrderStockTx 将订单项数量归还到商品库存，并从销量中扣除，已抽中的盲盒款式归还到款式库存
+func restoreOrderStockTx(tx *gorm.DB, orderID int) error {
+	items, err := dao.GetOrderItemsTx(tx, orderID)
+	if err != nil {
+		return fmt.Errorf("查询订单项失败: %w", err)
+	}
+	for _, item := range items {
+		if err := dao.IncreaseProductStock(tx, item.ProductID, item.Quantity); err != nil {
+			return fmt.Errorf("归还库存失败: %w", err)
+		}
+		if err := dao.IncreaseProductSales(tx, item.ProductID, -item.Quantity); err != nil {
+			return fmt.Errorf("扣减销量失败: %w", err)
+		}
+	}
+	return restoreDrawnFiguresTx(tx, orderID)
+}
+
+// transitionOrder 在独立事务中变更订单状态
+func transitionOrder(t orderTransition) (*model.Order, error) {
+	var order *model.Order
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = transitionOrderTx(tx, t)
+		return err
+	})
+	return order, err
+}
+
+// CancelOrder 用户取消未支付的订单
+func CancelOrder(userID, orderID int, req *model.CancelOrderRequest) (*model.Order, error) {
+	reason := "用户取消"
+	if req != nil && req.Reason != "" {
+		reason = req.Reason
+	}
+	order, err := transitionOrder(orderTransition{
+		orderID:    orderID,
+		userID:     userID,
+		to:         model.OrderStatusCancelled,
+		operatorID: userID,
+		reason:     reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dao.RemoveOrderExpiry(orderID)
+	return order, nil
+}
+
+// ConfirmReceipt 用户确认收货
+func ConfirmReceipt(userID, orderID int) (*model.Order, error) {
+	return transitionOrder(orderTransition{
+		orderID:    orderID,
+		userID:     userID,
+		to:         model.OrderStatusCompleted,
+		operatorID: userID,
+		reason:     "用户确认收货",
+	})
+}
+
+// ShipOrder 发货
+func ShipOrder(operatorID, orderID int, req *model.ShipOrderRequest) (*model.Order, error) {
+	if req.TrackingNumber == "" || len(req.TrackingNumber) > 64 {
+		return nil, newValidationError("物流单号不能为空且不能超过64个字符")
+	}
+	order, err := transitionOrder(orderTransition{
+		orderID:    orderID,
+		to:         model.OrderStatusShipped,
+		operatorID: operatorID,
+		reason:     "发货，物流单号: " + req.TrackingNumber,
+		extra:      map[string]interface{}{"tracking_number": req.TrackingNumber},
+	})
+	if err != nil {
+		return nil, err
+	}
+	order.TrackingNumber = req.TrackingNumber
+	return order, nil
+}
+
+// DeliverOrder 标记订单已送达
+func DeliverOrder(operatorID, orderID int) (*model.Order, error) {
+	return transitionOrder(orderTransition{
+		orderID:    orderID,
+		to:         model.OrderStatusDelivered,
+		operatorID: operatorID,
+		reason:     "物流已送达",
+	})
+}
+
+// RefundOrder 退款
+func RefundOrder(operatorID, orderID int, req *model.RefundOrderRequest) (*model.Order, error) {
+	if req.Reason == "" {
+		return nil, newValidationError("退款原因不能为空")
+	}
+	return transitionOrder(orderTransition{
+		orderID:    orderID,
+		to:         model.OrderStatusRefunded,
+		operatorID: operatorID,
+		reason:     req.Reason,
+	})
+}
+
+// AdminGetOrders 分页获取订单列表（管理后台）
+func AdminGetOrders(status string, page, pageSize int) ([]model.Order, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return dao.GetOrdersForAdmin(status, page, pageSize)
+}
+
+// AdminGetOrder 获取订单详情（管理后台），包含订单项、优惠、状态记录和支付记录
+func AdminGetOrder(orderID int) (*model.Order, error) {
+	order, err := dao.GetOrderByIDForAdmin(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, ErrOrderNotFound
+	}
+
+	if items, err := dao.GetOrderItems(order.ID); err == nil {
+		order.Items = items
+	}
+	if discounts, err := dao.GetOrderDiscounts(order.ID); err == nil {
+		order.Discounts = discounts
+	}
+	if history, err := dao.GetOrderStatusHistory(order.ID); err == nil {
+		order.History = history
+	}
+	if payments, err := dao.GetPaymentsByOrderID(order.ID); err == nil {
+		order.Payments = payments
+	}
+	return order, nil
+}
@@ -0,0 +1,211 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"shop/dao"
+	"shop/global/db"
+	"shop/global/payment"
+	"shop/model"
+)
+
+// paymentCreateTimeout 调用渠道创建支付的超时时间
+const paymentCreateTimeout = 10 * time.Second
+
+// PayOrder 为待支付订单发起支付，同一渠道已有进行中的支付时直接返回该支付
+func PayOrder(userID, orderID int, req *model.PayOrderRequest) (*model.Payment, error) {
+	order, err := dao.GetOrderByID(orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, ErrOrderNotFound
+	}
+	if order.Status != model.OrderStatusPendingPayment {
+		return nil, &InvalidTransitionError{From: order.Status, To: model.OrderStatusPaid}
+	}
+
+	providerName := payment.DefaultProviderName()
+	if req != nil && req.Provider != "" {
+		providerName = req.Provider
+	}
+	provider := payment.GetProvider(providerName)
+	if provider == nil {
+		return nil, newValidationError("不支持的支付渠道: %s", providerName)
+	}
+
+	existing, err := dao.GetPendingPayment(order.ID, providerName)
+	if err != nil {
+		return nil, fmt.Errorf("查询支付记录失败: %w", err)
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	record := &model.Payment{
+		OrderID:  order.ID,
+		UserID:   userID,
+		Provider: providerName,
+		Amount:   order.TotalPrice,
+		Status:   model.PaymentStatusPending,
+	}
+	if err := dao.CreatePayment(record); err != nil {
+		return nil, fmt.Errorf("创建支付记录失败: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), paymentCreateTimeout)
+	defer cancel()
+	result, err := provider.CreatePayment(ctx, &payment.CreateRequest{
+		PaymentID: record.ID,
+		OrderID:   order.ID,
+		Amount:    order.TotalPrice,
+		Subject:   fmt.Sprintf("订单 %d", order.ID),
+	})
+	if err != nil {
+		if updateErr := dao.UpdatePayment(record.ID, map[string]interface{}{
+			"status":         model.PaymentStatusFailed,
+			"failure_reason": truncate(err.Error(), 255),
+		}); updateErr != nil {
+			log.Printf("Warning: Failed to mark payment %d failed: %v", record.ID, updateErr)
+		}
+		return nil, fmt.Errorf("创建支付失败: %w", err)
+	}
+
+	if err := dao.UpdatePayment(record.ID, map[string]interface{}{
+		"provider_ref": result.ProviderRef,
+		"pay_url":      result.PayURL,
+	}); err != nil {
+		return nil, fmt.Errorf("保存支付记录失败: %w", err)
+	}
+	record.ProviderRef = &result.ProviderRef
+	record.PayURL = result.PayURL
+	return record, nil
+}
+
+// HandlePaymentWebhook 处理支付渠道回调：先校验签名，再在事务中更新支付记录和订单状态
+// 已处理过的支付重复回调时直接返回成功，保证渠道重试是幂等的
+func HandlePaymentWebhook(providerName string, payload []byte, signature string) error {
+	provider := payment.GetProvider(providerName)
+	if provider == nil {
+		return fmt.Errorf("支付渠道不存在")
+	}
+	event, err := provider.VerifyWebhook(payload, signature)
+	if err != nil {
+		if errors.Is(err, payment.ErrInvalidSignature) || errors.Is(err, payment.ErrStaleWebhook) {
+			return err
+		}
+		return newValidationError("%s", err.Error())
+	}
+	if event.Status != payment.StatusSucceeded && event.Status != payment.StatusFailed {
+		return newValidationError("未知的支付状态: %s", event.Status)
+	}
+
+	paidOrderID := 0
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		record, err := dao.GetPaymentByProviderRefForUpdate(tx, providerName, event.ProviderRef)
+		if err != nil {
+			return fmt.Errorf("查询支付记录失败: %w", err)
+		}
+		if record == nil {
+			return fmt.Errorf("支付记录不存在")
+		}
+		if record.Status != model.PaymentStatusPending {
+			return nil
+		}
+
+		if event.Status == payment.StatusFailed {
+			return dao.UpdatePaymentTx(tx, record.ID, map[string]interface{}{
+				"status":         model.PaymentStatusFailed,
+				"failure_reason": truncate(event.Message, 255),
+				"event_id":       event.EventID,
+			})
+		}
+
+		if !event.Amount.Equal(record.Amount) {
+			log.Printf("Warning: Payment %d amount mismatch: expected %s, got %s", record.ID, record.Amount, event.Amount)
+			return dao.UpdatePaymentTx(tx, record.ID, map[string]interface{}{
+				"status":         model.PaymentStatusFailed,
+				"failure_reason": fmt.Sprintf("支付金额不一致: 应付 %s, 实付 %s", record.Amount, event.Amount),
+				"event_id":       event.EventID,
+			})
+		}
+
+		updates := map[string]interface{}{
+			"status":   model.PaymentStatusSucceeded,
+			"paid_at":  time.Now(),
+			"event_id": event.EventID,
+		}
+		_, err = transitionOrderTx(tx, orderTransition{
+			orderID: record.OrderID,
+			to:      model.OrderStatusPaid,
+			reason:  fmt.Sprintf("支付成功，渠道: %s，流水号: %s", providerName, event.ProviderRef),
+		})
+		var transitionErr *InvalidTransitionError
+		switch {
+		case errors.As(err, &transitionErr):
+			// 订单已关闭（如超时取消）后才收到支付成功，记录下来由人工退款
+			log.Printf("Warning: Payment %d succeeded but order %d is %s, refund required", record.ID, record.OrderID, transitionErr.From)
+			updates["failure_reason"] = "订单已关闭，需要退款"
+		case err != nil:
+			return err
+		default:
+			paidOrderID = record.OrderID
+		}
+		return dao.UpdatePaymentTx(tx, record.ID, updates)
+	})
+	if err != nil {
+		return err
+	}
+
+	if paidOrderID != 0 {
+		dao.RemoveOrderExpiry(paidOrderID)
+	}
+	return nil
+}
+
+// CompleteMockPayment 在模拟渠道上完成支付，模拟渠道会签名并回调 webhook
+func CompleteMockPayment(userID int, providerRef string, req *model.CompleteMockPaymentRequest) error {
+	mock, ok := payment.GetProvider(payment.MockProviderName).(*payment.MockProvider)
+	if !ok {
+		return fmt.Errorf("支付渠道不存在")
+	}
+
+	status := payment.StatusSucceeded
+	if req != nil && req.Status != "" {
+		status = req.Status
+	}
+	if status != payment.StatusSucceeded && status != payment.StatusFailed {
+		return newValidationError("status 只能是 succeeded 或 failed")
+	}
+
+	record, err := dao.GetPaymentByProviderRef(payment.MockProviderName, providerRef)
+	if err != nil {
+		return fmt.Errorf("查询支付记录失败: %w", err)
+	}
+	if record == nil || record.UserID != userID {
+		return fmt.Errorf("支付记录不存在")
+	}
+	if record.Status != model.PaymentStatusPending {
+		return newValidationError("支付已处理，状态: %s", record.Status)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), paymentCreateTimeout)
+	defer cancel()
+	return mock.Simulate(ctx, providerRef, record.Amount, status)
+}
+
+// truncate 截断字符串到指定字节数以内，不截断多字节字符
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && (s[n]&0xC0) == 0x80 {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,236 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"shop/global/db"
+	"shop/global/db/dbtest"
+	"shop/global/payment"
+	"shop/global/redis/redistest"
+	"shop/model"
+)
+
+const testWebhookSecret = "test-webhook-secret-0123456789abcdef"
+
+// setupPayment 准备订单和支付相关的表，注册模拟支付渠道，创建一笔待支付订单并发起支付
+func setupPayment(t *testing.T) (*model.Order, *model.Payment) {
+	t.Helper()
+	dbtest.Open(t,
+		&model.Product{}, &model.Order{}, &model.OrderItem{}, &model.OrderStatusHistory{}, &model.Payment{},
+		&model.BlindBoxSeries{}, &model.BlindBoxFigure{}, &model.BlindBoxDraw{}, &model.OwnedItem{},
+		&model.Coupon{}, &model.CouponRedemption{}, &model.OrderDiscount{},
+	)
+	redistest.Open(t)
+	payment.Register(payment.NewMockProvider(testWebhookSecret, ""))
+
+	product := model.Product{Name: "拉布布 坐坐派对", Price: model.Cents(5900), Stock: 5}
+	if err := db.DB.Create(&product).Error; err != nil {
+		t.Fatalf("创建商品失败: %v", err)
+	}
+	var order *model.Order
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = createOrderTx(tx, 1, []orderLine{{productID: product.ID, quantity: 2}})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("创建订单失败: %v", err)
+	}
+
+	record, err := PayOrder(order.UserID, order.ID, &model.PayOrderRequest{Provider: payment.MockProviderName})
+	if err != nil {
+		t.Fatalf("发起支付失败: %v", err)
+	}
+	return order, record
+}
+
+// signedEvent 生成模拟渠道的回调内容和签名
+func signedEvent(t *testing.T, event payment.WebhookEvent) ([]byte, string) {
+	t.Helper()
+	if event.Timestamp == 0 {
+		event.Timestamp = time.Now().Unix()
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("序列化回调失败: %v", err)
+	}
+	return payload, payment.Sign([]byte(testWebhookSecret), payload)
+}
+
+func successEvent(record *model.Payment, eventID string) payment.WebhookEvent {
+	return payment.WebhookEvent{
+		EventID:     eventID,
+		ProviderRef: *record.ProviderRef,
+		Status:      payment.StatusSucceeded,
+		Amount:      record.Amount,
+	}
+}
+
+func reloadPayment(t *testing.T, id int) model.Payment {
+	t.Helper()
+	var record model.Payment
+	if err := db.DB.First(&record, id).Error; err != nil {
+		t.Fatalf("查询支付记录失败: %v", err)
+	}
+	return record
+}
+
+func reloadOrder(t *testing.T, id int) model.Order {
+	t.Helper()
+	var order model.Order
+	if err := db.DB.First(&order, id).Error; err != nil {
+		t.Fatalf("查询订单失败: %v", err)
+	}
+	return order
+}
+
+func TestHandlePaymentWebhookSucceeds(t *testing.T) {
+	order, record := setupPayment(t)
+
+	payload, signature := signedEvent(t, successEvent(record, "evt_1"))
+	if err := HandlePaymentWebhook(payment.MockProviderName, payload, signature); err != nil {
+		t.Fatalf("处理回调失败: %v", err)
+	}
+	if got := reloadPayment(t, record.ID); got.Status != model.PaymentStatusSucceeded || got.PaidAt == nil || got.EventID != "evt_1" {
+		t.Fatalf("支付记录为 %+v，预期已成功", got)
+	}
+	if got := reloadOrder(t, order.ID); got.Status != model.OrderStatusPaid {
+		t.Fatalf("订单状态为 %s，预期 %s", got.Status, model.OrderStatusPaid)
+	}
+}
+
+func TestHandlePaymentWebhookRejectsInvalidSignature(t *testing.T) {
+	order, record := setupPayment(t)
+	payload, signature := signedEvent(t, successEvent(record, "evt_1"))
+
+	tests := []struct {
+		name      string
+		payload   []byte
+		signature string
+	}{
+		{name: "wrong secret", payload: payload, signature: payment.Sign([]byte("another-secret-0123456789abcdef00"), payload)},
+		{name: "tampered payload", payload: []byte(strings.Replace(string(payload), "succeeded", "failed", 1)), signature: signature},
+		{name: "missing prefix", payload: payload, signature: strings.TrimPrefix(signature, "sha256=")},
+		{name: "empty", payload: payload, signature: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := HandlePaymentWebhook(payment.MockProviderName, tt.payload, tt.signature)
+			if !errors.Is(err, payment.ErrInvalidSignature) {
+				t.Fatalf("错误为 %v，预期 ErrInvalidSignature", err)
+			}
+		})
+	}
+
+	if got := reloadPayment(t, record.ID); got.Status != model.PaymentStatusPending {
+		t.Fatalf("签名错误后支付状态为 %s，预期 %s", got.Status, model.PaymentStatusPending)
+	}
+	if got := reloadOrder(t, order.ID); got.Status != model.OrderStatusPendingPayment {
+		t.Fatalf("签名错误后订单状态为 %s，预期 %s", got.Status, model.OrderStatusPendingPayment)
+	}
+}
+
+func TestHandlePaymentWebhookRejectsStaleTimestamp(t *testing.T) {
+	_, record := setupPayment(t)
+
+	for _, offset := range []time.Duration{-6 * time.Minute, 6 * time.Minute} {
+		event := successEvent(record, "evt_1")
+		event.Timestamp = time.Now().Add(offset).Unix()
+		payload, signature := signedEvent(t, event)
+		if err := HandlePaymentWebhook(payment.MockProviderName, payload, signature); !errors.Is(err, payment.ErrStaleWebhook) {
+			t.Fatalf("时间戳偏差 %s: 错误为 %v，预期 ErrStaleWebhook", offset, err)
+		}
+	}
+
+	// 窗口内的时间戳可以处理
+	event := successEvent(record, "evt_2")
+	event.Timestamp = time.Now().Add(-4 * time.Minute).Unix()
+	payload, signature := signedEvent(t, event)
+	if err := HandlePaymentWebhook(payment.MockProviderName, payload, signature); err != nil {
+		t.Fatalf("处理窗口内的回调失败: %v", err)
+	}
+	if got := reloadPayment(t, record.ID); got.Status != model.PaymentStatusSucceeded {
+		t.Fatalf("支付状态为 %s，预期 %s", got.Status, model.PaymentStatusSucceeded)
+	}
+}
+
+func TestHandlePaymentWebhookReplayIsIgnored(t *testing.T) {
+	order, record := setupPayment(t)
+
+	payload, signature := signedEvent(t, successEvent(record, "evt_1"))
+	if err := HandlePaymentWebhook(payment.MockProviderName, payload, signature); err != nil {
+		t.Fatalf("处理回调失败: %v", err)
+	}
+	paid := reloadPayment(t, record.ID)
+
+	// 同一事件重放，以及支付已处理后到达的失败事件，都不再修改支付和订单
+	failed := successEvent(record, "evt_2")
+	failed.Status = payment.StatusFailed
+	for _, event := range []payment.WebhookEvent{successEvent(record, "evt_1"), failed} {
+		payload, signature := signedEvent(t, event)
+		if err := HandlePaymentWebhook(payment.MockProviderName, payload, signature); err != nil {
+			t.Fatalf("重复回调 %s 返回错误: %v", event.EventID, err)
+		}
+	}
+
+	got := reloadPayment(t, record.ID)
+	if got.Status != model.PaymentStatusSucceeded || got.EventID != paid.EventID || !got.PaidAt.Equal(*paid.PaidAt) {
+		t.Fatalf("重复回调修改了支付记录: %+v", got)
+	}
+	var history int64
+	db.DB.Model(&model.OrderStatusHistory{}).Where("order_id = ? AND to_status = ?", order.ID, model.OrderStatusPaid).Count(&history)
+	if history != 1 {
+		t.Fatalf("订单支付状态记录有 %d 条，预期 1 条", history)
+	}
+}
+
+func TestHandlePaymentWebhookAmountMismatch(t *testing.T) {
+	order, record := setupPayment(t)
+
+	event := successEvent(record, "evt_1")
+	event.Amount = record.Amount.Sub(model.Cents(1))
+	payload, signature := signedEvent(t, event)
+	if err := HandlePaymentWebhook(payment.MockProviderName, payload, signature); err != nil {
+		t.Fatalf("处理回调失败: %v", err)
+	}
+
+	got := reloadPayment(t, record.ID)
+	if got.Status != model.PaymentStatusFailed || !strings.Contains(got.FailureReason, "支付金额不一致") {
+		t.Fatalf("支付记录为 %+v，预期因金额不一致失败", got)
+	}
+	if got := reloadOrder(t, order.ID); got.Status != model.OrderStatusPendingPayment {
+		t.Fatalf("订单状态为 %s，预期仍为 %s", got.Status, model.OrderStatusPendingPayment)
+	}
+}
+
+func TestHandlePaymentWebhookAfterCancel(t *testing.T) {
+	order, record := setupPayment(t)
+
+	if _, err := CancelOrder(order.UserID, order.ID, nil); err != nil {
+		t.Fatalf("取消订单失败: %v", err)
+	}
+
+	payload, signature := signedEvent(t, successEvent(record, "evt_1"))
+	if err := HandlePaymentWebhook(payment.MockProviderName, payload, signature); err != nil {
+		t.Fatalf("处理回调失败: %v", err)
+	}
+
+	// 支付记为成功并标记需要退款，订单保持已取消，库存不会再次扣减
+	got := reloadPayment(t, record.ID)
+	if got.Status != model.PaymentStatusSucceeded || got.FailureReason != "订单已关闭，需要退款" {
+		t.Fatalf("支付记录为 %+v，预期成功且标记需要退款", got)
+	}
+	if got := reloadOrder(t, order.ID); got.Status != model.OrderStatusCancelled {
+		t.Fatalf("订单状态为 %s，预期 %s", got.Status, model.OrderStatusCancelled)
+	}
+	var product model.Product
+	db.DB.First(&product)
+	if product.Stock != 5 {
+		t.Fatalf("库存为 %d，预期取消后归还为 5", product.Stock)
+	}
+}
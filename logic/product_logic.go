@@ -1,13 +1,122 @@
 package logic
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
 	"shop/dao"
 	"shop/model"
 )
 
-// GetProducts 获取商品列表
-func GetProducts() ([]model.Product, error) {
-	return dao.GetProducts()
+const (
+	// defaultProductPageSize 商品列表默认每页数量
+	defaultProductPageSize = 20
+	// maxProductPageSize 商品列表每页最大数量
+	maxProductPageSize = 100
+)
+
+// GetProducts 分页获取商品列表，支持过滤、排序、关键词搜索和游标分页
+func GetProducts(q *model.ProductListQuery) (*model.ProductListResponse, error) {
+	if err := normalizeProductListQuery(q); err != nil {
+		return nil, err
+	}
+
+	var cursor *model.ProductCursor
+	offset := 0
+	if q.Cursor != "" {
+		c, err := decodeProductCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if c.Sort != q.Sort || (c.Sort == model.ProductSortNewest && c.CreatedAt == nil) {
+			return nil, newValidationError("cursor 与排序方式不匹配")
+		}
+		cursor = c
+		q.Page = 0
+	} else {
+		offset = (q.Page - 1) * q.PageSize
+	}
+
+	total, err := dao.CountProducts(q)
+	if err != nil {
+		return nil, err
+	}
+
+	// 多取一条判断是否还有下一页
+	products, err := dao.GetProductList(q, cursor, offset, q.PageSize+1)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &model.ProductListResponse{
+		Products: products,
+		Total:    total,
+		Page:     q.Page,
+		PageSize: q.PageSize,
+	}
+	if len(products) > q.PageSize {
+		resp.Products = products[:q.PageSize]
+		resp.HasMore = true
+		resp.NextCursor = encodeProductCursor(q.Sort, &resp.Products[q.PageSize-1])
+	}
+	return resp, nil
+}
+
+// normalizeProductListQuery 校验查询条件并填充默认值
+func normalizeProductListQuery(q *model.ProductListQuery) error {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 {
+		q.PageSize = defaultProductPageSize
+	}
+	if q.PageSize > maxProductPageSize {
+		return newValidationError("page_size 不能超过%d", maxProductPageSize)
+	}
+	if !model.IsValidProductSort(q.Sort) {
+		return newValidationError("不支持的排序方式: %s", q.Sort)
+	}
+	if q.MinPrice != nil && q.MinPrice.IsNegative() {
+		return newValidationError("min_price 不能为负数")
+	}
+	if q.MinPrice != nil && q.MaxPrice != nil && q.MinPrice.Cmp(*q.MaxPrice) > 0 {
+		return newValidationError("min_price 不能大于 max_price")
+	}
+	q.Keyword = strings.TrimSpace(q.Keyword)
+	if utf8.RuneCountInString(q.Keyword) > 100 {
+		return newValidationError("关键词不能超过100个字符")
+	}
+	return nil
+}
+
+// encodeProductCursor 根据当前页最后一个商品生成游标
+func encodeProductCursor(sort string, last *model.Product) string {
+	cursor := model.ProductCursor{Sort: sort, ID: last.ID}
+	switch sort {
+	case model.ProductSortPriceAsc, model.ProductSortPriceDesc:
+		cursor.Price = last.Price
+	case model.ProductSortNewest:
+		cursor.CreatedAt = &last.CreatedAt
+	case model.ProductSortBestSelling:
+		cursor.SalesCount = last.SalesCount
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeProductCursor 解析游标
+func decodeProductCursor(s string) (*model.ProductCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, newValidationError("无效的 cursor")
+	}
+	var cursor model.ProductCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 {
+		return nil, newValidationError("无效的 cursor")
+	}
+	return &cursor, nil
 }
 
 // GetProduct 获取商品详情
@@ -0,0 +1,565 @@
+package logic
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"shop/dao"
+	"shop/global/db"
+	"shop/model"
+)
+
+var (
+	// ErrPromotionNotFound 促销不存在
+	ErrPromotionNotFound = errors.New("促销不存在")
+	// ErrCouponNotFound 优惠码不存在
+	ErrCouponNotFound = errors.New("优惠码不存在")
+)
+
+const (
+	// maxPromotionNameLength 促销名称最大长度
+	maxPromotionNameLength = 100
+	// maxPromotionQuantity 买N送M中N和M的最大值
+	maxPromotionQuantity = 100
+	// maxCouponCodeLength 优惠码最大长度
+	maxCouponCodeLength = 32
+	// generatedCouponCodeLength 自动生成的优惠码长度
+	generatedCouponCodeLength = 8
+	// couponCodeAlphabet 自动生成优惠码使用的字符（去掉了容易混淆的 0/O、1/I/L）
+	couponCodeAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+)
+
+// promotionLine 参与促销计算的商品行，套装行已按套装价优惠，不参与促销
+type promotionLine struct {
+	productID int
+	series    string
+	quantity  int
+	unitPrice model.Money
+}
+
+// promotionApplies 促销是否适用于该商品行
+func promotionApplies(p *model.Promotion, line promotionLine) bool {
+	switch p.Scope {
+	case model.PromotionScopeProduct:
+		return p.ProductID != nil && *p.ProductID == line.productID
+	case model.PromotionScopeSeries:
+		return p.Series == line.series
+	}
+	return true
+}
+
+// promotionDiscount 计算促销对商品行的优惠金额，不满足门槛或没有适用商品时返回0
+// 折扣按适用商品金额计算并四舍五入到分；买N送M将适用商品的件数合并计算，每 N+M 件中价格最低的 M 件免费
+func promotionDiscount(p *model.Promotion, lines []promotionLine) (model.Money, error) {
+	var subtotal, discount model.Money
+	var units []model.Money
+	for _, line := range lines {
+		if line.quantity <= 0 || !promotionApplies(p, line) {
+			continue
+		}
+		amount, err := line.unitPrice.Mul(int64(line.quantity))
+		if err != nil {
+			return discount, fmt.Errorf("计算优惠失败: %w", err)
+		}
+		subtotal = subtotal.Add(amount)
+		if p.Type == model.PromotionTypeBuyNGetM {
+			for i := 0; i < line.quantity; i++ {
+				units = append(units, line.unitPrice)
+			}
+		}
+	}
+	if !subtotal.IsPositive() || subtotal.Cmp(p.MinAmount) < 0 {
+		return discount, nil
+	}
+
+	switch p.Type {
+	case model.PromotionTypePercentage:
+		var err error
+		if discount, err = subtotal.MulRatio(int64(p.PercentOff), 100); err != nil {
+			return discount, fmt.Errorf("计算优惠失败: %w", err)
+		}
+	case model.PromotionTypeFixed, model.PromotionTypeThreshold:
+		discount = p.AmountOff
+	case model.PromotionTypeBuyNGetM:
+		group := p.BuyQuantity + p.GetQuantity
+		if group <= 0 {
+			return discount, nil
+		}
+		sort.Slice(units, func(i, j int) bool { return units[i].Cmp(units[j]) < 0 })
+		free := len(units) / group * p.GetQuantity
+		for _, unit := range units[:free] {
+			discount = discount.Add(unit)
+		}
+	}
+	return discount.Min(subtotal), nil
+}
+
+// normalizeCouponCode 优惠码不区分大小写，统一保存为大写
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// loadUsableCoupon 获取并校验优惠码：在有效期内、关联的促销生效中、未超过全局和每人使用次数
+// lock 为 true 时锁定优惠码行，同一优惠码的并发下单依次校验使用次数
+func loadUsableCoupon(tx *gorm.DB, userID int, code string, now time.Time, lock bool) (*model.Coupon, error) {
+	coupon, err := dao.GetCouponByCode(tx, normalizeCouponCode(code), lock)
+	if err != nil {
+		return nil, fmt.Errorf("查询优惠码失败: %w", err)
+	}
+	if coupon == nil {
+		return nil, ErrCouponNotFound
+	}
+
+	promotion := coupon.Promotion
+	if !coupon.Active || !promotion.Active {
+		return nil, newValidationError("优惠码已失效")
+	}
+	if now.Before(coupon.StartAt) || now.Before(promotion.StartAt) {
+		return nil, newValidationError("优惠码尚未生效")
+	}
+	if !now.Before(coupon.EndAt) || !now.Before(promotion.EndAt) {
+		return nil, newValidationError("优惠码已过期")
+	}
+	if coupon.UsageLimit > 0 && coupon.UsedCount >= coupon.UsageLimit {
+		return nil, newValidationError("优惠码已被使用完")
+	}
+	used, err := dao.CountCouponRedemptions(tx, coupon.ID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询优惠码使用记录失败: %w", err)
+	}
+	if used >= int64(coupon.PerUserLimit) {
+		return nil, newValidationError("已达到该优惠码的使用次数上限（每人 %d 次）", coupon.PerUserLimit)
+	}
+	return coupon, nil
+}
+
+// calculatePromotions 计算商品行可以享受的优惠：自动应用的促销中优惠最多的一项，加上优惠码（如果有）
+// 两项均按原价计算，合计不超过 maxDiscount；优惠码与自动促销为同一促销时只计算一次
+// 优惠码不可用或没有适用商品时返回错误；返回已应用的优惠、优惠合计和使用的优惠码
+func calculatePromotions(tx *gorm.DB, userID int, lines []promotionLine, couponCode string, maxDiscount model.Money, lock bool) ([]model.AppliedPromotion, model.Money, *model.Coupon, error) {
+	now := time.Now()
+	applied := []model.AppliedPromotion{}
+
+	var coupon *model.Coupon
+	var couponDiscount, total model.Money
+	if strings.TrimSpace(couponCode) != "" {
+		var err error
+		coupon, err = loadUsableCoupon(tx, userID, couponCode, now, lock)
+		if err != nil {
+			return nil, total, nil, err
+		}
+		if couponDiscount, err = promotionDiscount(&coupon.Promotion, lines); err != nil {
+			return nil, total, nil, err
+		}
+		if couponDiscount.IsZero() {
+			return nil, total, nil, newValidationError("没有满足优惠码使用条件的商品")
+		}
+	}
+
+	promotions, err := dao.GetActivePromotions(tx, now, true)
+	if err != nil {
+		return nil, total, nil, fmt.Errorf("查询促销失败: %w", err)
+	}
+	var best *model.Promotion
+	var bestDiscount model.Money
+	for i := range promotions {
+		if coupon != nil && promotions[i].ID == coupon.PromotionID {
+			continue
+		}
+		d, err := promotionDiscount(&promotions[i], lines)
+		if err != nil {
+			return nil, total, nil, err
+		}
+		if d.Cmp(bestDiscount) > 0 {
+			best, bestDiscount = &promotions[i], d
+		}
+	}
+
+	maxDiscount = maxDiscount.Max(model.Money{})
+	if best != nil {
+		bestDiscount = bestDiscount.Min(maxDiscount)
+		if bestDiscount.IsPositive() {
+			total = total.Add(bestDiscount)
+			applied = append(applied, model.AppliedPromotion{
+				PromotionID: best.ID,
+				Name:        best.Name,
+				Type:        best.Type,
+				Amount:      bestDiscount,
+			})
+		}
+	}
+	if coupon != nil {
+		couponDiscount = couponDiscount.Min(maxDiscount.Sub(total))
+		if couponDiscount.IsZero() {
+			return nil, total, nil, newValidationError("订单金额已无法使用该优惠码")
+		}
+		total = total.Add(couponDiscount)
+		couponID := coupon.ID
+		applied = append(applied, model.AppliedPromotion{
+			PromotionID: coupon.PromotionID,
+			CouponID:    &couponID,
+			CouponCode:  coupon.Code,
+			Name:        coupon.Promotion.Name,
+			Type:        coupon.Promotion.Type,
+			Amount:      couponDiscount,
+		})
+	}
+	return applied, total, coupon, nil
+}
+
+// applyOrderPromotionsTx 在事务中计算订单的促销和优惠码优惠，记录到订单并扣减应付金额
+// 优惠后订单金额至少为 0.01；使用优惠码时记录使用并增加已使用次数
+func applyOrderPromotionsTx(tx *gorm.DB, userID int, order *model.Order, couponCode string) error {
+	productIDs := make([]int, 0, len(order.Items))
+	for _, item := range order.Items {
+		if item.BundleID == nil {
+			productIDs = append(productIDs, item.ProductID)
+		}
+	}
+	series, err := dao.GetProductSeriesTx(tx, productIDs)
+	if err != nil {
+		return fmt.Errorf("查询商品失败: %w", err)
+	}
+	lines := make([]promotionLine, 0, len(productIDs))
+	for _, item := range order.Items {
+		if item.BundleID != nil {
+			continue
+		}
+		lines = append(lines, promotionLine{
+			productID: item.ProductID,
+			series:    series[item.ProductID],
+			quantity:  item.Quantity,
+			unitPrice: item.Price,
+		})
+	}
+
+	applied, discount, coupon, err := calculatePromotions(tx, userID, lines, couponCode, order.TotalPrice.Sub(model.Cents(1)), true)
+	if err != nil {
+		return err
+	}
+	if discount.IsZero() {
+		return nil
+	}
+
+	discounts := make([]model.OrderDiscount, 0, len(applied))
+	for _, a := range applied {
+		discounts = append(discounts, model.OrderDiscount{
+			OrderID:     order.ID,
+			PromotionID: a.PromotionID,
+			CouponID:    a.CouponID,
+			CouponCode:  a.CouponCode,
+			Name:        a.Name,
+			Amount:      a.Amount,
+		})
+	}
+	if err := dao.CreateOrderDiscounts(tx, discounts); err != nil {
+		return fmt.Errorf("记录订单优惠失败: %w", err)
+	}
+	if coupon != nil {
+		if err := dao.CreateCouponRedemption(tx, &model.CouponRedemption{
+			CouponID: coupon.ID,
+			UserID:   userID,
+			OrderID:  order.ID,
+			Status:   model.CouponRedemptionApplied,
+		}); err != nil {
+			return fmt.Errorf("记录优惠码使用失败: %w", err)
+		}
+		if err := dao.IncreaseCouponUsedCount(tx, coupon.ID, 1); err != nil {
+			return fmt.Errorf("更新优惠码使用次数失败: %w", err)
+		}
+	}
+
+	order.TotalPrice = order.TotalPrice.Sub(discount)
+	order.DiscountAmount = discount
+	order.Discounts = discounts
+	if err := dao.UpdateOrderTotals(tx, order.ID, order.TotalPrice, order.DiscountAmount); err != nil {
+		return fmt.Errorf("更新订单金额失败: %w", err)
+	}
+	return nil
+}
+
+// releaseCouponRedemptionsTx 订单取消时释放优惠码使用记录，优惠码可以再次使用
+func releaseCouponRedemptionsTx(tx *gorm.DB, orderID int) error {
+	redemptions, err := dao.GetAppliedCouponRedemptions(tx, orderID)
+	if err != nil {
+		return fmt.Errorf("查询优惠码使用记录失败: %w", err)
+	}
+	for _, r := range redemptions {
+		if err := dao.UpdateCouponRedemptionStatus(tx, r.ID, model.CouponRedemptionReleased); err != nil {
+			return fmt.Errorf("释放优惠码失败: %w", err)
+		}
+		if err := dao.IncreaseCouponUsedCount(tx, r.CouponID, -1); err != nil {
+			return fmt.Errorf("释放优惠码失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetActivePromotions 获取当前生效的自动应用促销（只通过优惠码使用的促销不公开）
+func GetActivePromotions() ([]model.Promotion, error) {
+	return dao.GetActivePromotions(db.DB, time.Now(), true)
+}
+
+// AdminGetPromotions 获取全部促销（管理后台）
+func AdminGetPromotions() ([]model.Promotion, error) {
+	return dao.GetPromotions()
+}
+
+// AdminGetPromotion 获取促销详情（管理后台）
+func AdminGetPromotion(promotionID int) (*model.Promotion, error) {
+	promotion, err := dao.GetPromotionByID(promotionID)
+	if err != nil {
+		return nil, fmt.Errorf("查询促销失败: %w", err)
+	}
+	if promotion == nil {
+		return nil, ErrPromotionNotFound
+	}
+	return promotion, nil
+}
+
+// validatePromotionAmount 校验促销金额：大于0、不超过商品价格上限
+func validatePromotionAmount(name string, amount model.Money) error {
+	if err := validateProductPrice(amount); err != nil {
+		return newValidationError("%s必须大于0，且不超过 %s", name, model.Cents(maxProductPriceCents))
+	}
+	return nil
+}
+
+// CreatePromotion 创建促销
+func CreatePromotion(req *model.CreatePromotionRequest) (*model.Promotion, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len([]rune(req.Name)) > maxPromotionNameLength {
+		return nil, newValidationError("促销名称不能为空，且不超过 %d 个字符", maxPromotionNameLength)
+	}
+	if req.StartAt.IsZero() || !req.EndAt.After(req.StartAt) {
+		return nil, newValidationError("结束时间必须晚于开始时间")
+	}
+	if !req.EndAt.After(time.Now()) {
+		return nil, newValidationError("结束时间必须晚于当前时间")
+	}
+	if !req.MinAmount.IsZero() {
+		if err := validatePromotionAmount("金额门槛", req.MinAmount); err != nil {
+			return nil, err
+		}
+	}
+
+	promotion := &model.Promotion{
+		Name:      req.Name,
+		Type:      req.Type,
+		MinAmount: req.MinAmount,
+		AutoApply: req.AutoApply,
+		Active:    true,
+		StartAt:   req.StartAt,
+		EndAt:     req.EndAt,
+	}
+	switch req.Type {
+	case model.PromotionTypePercentage:
+		if req.PercentOff < 1 || req.PercentOff > 99 {
+			return nil, newValidationError("折扣比例必须在 1 到 99 之间")
+		}
+		promotion.PercentOff = req.PercentOff
+	case model.PromotionTypeFixed, model.PromotionTypeThreshold:
+		if err := validatePromotionAmount("减免金额", req.AmountOff); err != nil {
+			return nil, err
+		}
+		if req.Type == model.PromotionTypeThreshold && req.MinAmount.Cmp(req.AmountOff) < 0 {
+			return nil, newValidationError("满减的金额门槛不能低于减免金额")
+		}
+		promotion.AmountOff = req.AmountOff
+	case model.PromotionTypeBuyNGetM:
+		if req.BuyQuantity < 1 || req.BuyQuantity > maxPromotionQuantity ||
+			req.GetQuantity < 1 || req.GetQuantity > maxPromotionQuantity {
+			return nil, newValidationError("买N送M的件数必须在 1 到 %d 之间", maxPromotionQuantity)
+		}
+		promotion.BuyQuantity = req.BuyQuantity
+		promotion.GetQuantity = req.GetQuantity
+	default:
+		return nil, newValidationError("促销类型必须是 percentage、fixed、threshold 或 buy_n_get_m")
+	}
+
+	switch req.Scope {
+	case "", model.PromotionScopeAll:
+		promotion.Scope = model.PromotionScopeAll
+	case model.PromotionScopeProduct:
+		if req.ProductID == nil {
+			return nil, newValidationError("指定商品的促销必须提供 product_id")
+		}
+		product, err := dao.GetProductByIDUnscoped(*req.ProductID)
+		if err != nil {
+			return nil, fmt.Errorf("查询商品失败: %w", err)
+		}
+		if product == nil || product.DeletedAt.Valid {
+			return nil, fmt.Errorf("商品不存在")
+		}
+		productID := product.ID
+		promotion.Scope = model.PromotionScopeProduct
+		promotion.ProductID = &productID
+	case model.PromotionScopeSeries:
+		req.Series = strings.TrimSpace(req.Series)
+		if req.Series == "" {
+			return nil, newValidationError("指定系列的促销必须提供 series")
+		}
+		promotion.Scope = model.PromotionScopeSeries
+		promotion.Series = req.Series
+	default:
+		return nil, newValidationError("适用范围必须是 all、product 或 series")
+	}
+
+	if err := dao.CreatePromotion(promotion); err != nil {
+		return nil, fmt.Errorf("创建促销失败: %w", err)
+	}
+	return dao.GetPromotionByID(promotion.ID)
+}
+
+// UpdatePromotion 更新促销的名称、启用状态和结束时间，已下单的优惠不受影响
+func UpdatePromotion(promotionID int, req *model.UpdatePromotionRequest) (*model.Promotion, error) {
+	promotion, err := AdminGetPromotion(promotionID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || len([]rune(name)) > maxPromotionNameLength {
+			return nil, newValidationError("促销名称不能为空，且不超过 %d 个字符", maxPromotionNameLength)
+		}
+		updates["name"] = name
+	}
+	if req.Active != nil {
+		updates["active"] = *req.Active
+	}
+	if req.EndAt != nil {
+		if !req.EndAt.After(promotion.StartAt) {
+			return nil, newValidationError("结束时间必须晚于开始时间")
+		}
+		updates["end_at"] = *req.EndAt
+	}
+	if len(updates) > 0 {
+		if err := dao.UpdatePromotion(promotionID, updates); err != nil {
+			return nil, fmt.Errorf("更新促销失败: %w", err)
+		}
+	}
+	return dao.GetPromotionByID(promotionID)
+}
+
+// AdminGetCoupons 获取优惠码列表，promotionID 为0时返回全部
+func AdminGetCoupons(promotionID int) ([]model.Coupon, error) {
+	return dao.GetCoupons(promotionID)
+}
+
+// generateCouponCode 生成随机优惠码
+func generateCouponCode() (string, error) {
+	buf := make([]byte, generatedCouponCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = couponCodeAlphabet[int(b)%len(couponCodeAlphabet)]
+	}
+	return string(buf), nil
+}
+
+// CreateCoupon 为促销创建优惠码，未指定优惠码时随机生成，未指定有效期时使用促销的时间
+func CreateCoupon(req *model.CreateCouponRequest) (*model.Coupon, error) {
+	promotion, err := AdminGetPromotion(req.PromotionID)
+	if err != nil {
+		return nil, err
+	}
+
+	code := normalizeCouponCode(req.Code)
+	if code == "" {
+		if code, err = generateCouponCode(); err != nil {
+			return nil, fmt.Errorf("生成优惠码失败: %w", err)
+		}
+	}
+	if len(code) > maxCouponCodeLength {
+		return nil, newValidationError("优惠码不能超过 %d 个字符", maxCouponCodeLength)
+	}
+	for _, r := range code {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return nil, newValidationError("优惠码只能包含字母、数字、- 和 _")
+		}
+	}
+	if req.UsageLimit < 0 {
+		return nil, newValidationError("总使用次数不能小于0")
+	}
+	if req.PerUserLimit == 0 {
+		req.PerUserLimit = 1
+	}
+	if req.PerUserLimit < 1 {
+		return nil, newValidationError("每人使用次数必须大于0")
+	}
+
+	startAt, endAt := promotion.StartAt, promotion.EndAt
+	if req.StartAt != nil {
+		startAt = *req.StartAt
+	}
+	if req.EndAt != nil {
+		endAt = *req.EndAt
+	}
+	if !endAt.After(startAt) {
+		return nil, newValidationError("结束时间必须晚于开始时间")
+	}
+
+	existing, err := dao.GetCouponByCode(db.DB, code, false)
+	if err != nil {
+		return nil, fmt.Errorf("查询优惠码失败: %w", err)
+	}
+	if existing != nil {
+		return nil, newValidationError("优惠码已存在: %s", code)
+	}
+
+	coupon := &model.Coupon{
+		Code:         code,
+		PromotionID:  promotion.ID,
+		UsageLimit:   req.UsageLimit,
+		PerUserLimit: req.PerUserLimit,
+		StartAt:      startAt,
+		EndAt:        endAt,
+		Active:       true,
+	}
+	if err := dao.CreateCoupon(coupon); err != nil {
+		return nil, fmt.Errorf("创建优惠码失败: %w", err)
+	}
+	return dao.GetCouponByID(coupon.ID)
+}
+
+// UpdateCoupon 更新优惠码的启用状态、总使用次数和结束时间
+func UpdateCoupon(couponID int, req *model.UpdateCouponRequest) (*model.Coupon, error) {
+	coupon, err := dao.GetCouponByID(couponID)
+	if err != nil {
+		return nil, fmt.Errorf("查询优惠码失败: %w", err)
+	}
+	if coupon == nil {
+		return nil, ErrCouponNotFound
+	}
+
+	updates := make(map[string]interface{})
+	if req.Active != nil {
+		updates["active"] = *req.Active
+	}
+	if req.UsageLimit != nil {
+		if *req.UsageLimit < 0 {
+			return nil, newValidationError("总使用次数不能小于0")
+		}
+		updates["usage_limit"] = *req.UsageLimit
+	}
+	if req.EndAt != nil {
+		if !req.EndAt.After(coupon.StartAt) {
+			return nil, newValidationError("结束时间必须晚于开始时间")
+		}
+		updates["end_at"] = *req.EndAt
+	}
+	if len(updates) > 0 {
+		if err := dao.UpdateCoupon(couponID, updates); err != nil {
+			return nil, fmt.Errorf("更新优惠码失败: %w", err)
+		}
+	}
+	return dao.GetCouponByID(couponID)
+}
@@ -0,0 +1,261 @@
+package logic
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"shop/dao"
+	"shop/global/db"
+	"shop/global/db/dbtest"
+	"shop/model"
+)
+
+func TestPromotionDiscount(t *testing.T) {
+	lines := func(ls ...promotionLine) []promotionLine { return ls }
+	labubu := func(quantity int, price int64) promotionLine {
+		return promotionLine{productID: 1, series: "拉布布", quantity: quantity, unitPrice: model.Cents(price)}
+	}
+	stars := func(quantity int, price int64) promotionLine {
+		return promotionLine{productID: 2, series: "星星人", quantity: quantity, unitPrice: model.Cents(price)}
+	}
+
+	tests := []struct {
+		name      string
+		promotion model.Promotion
+		lines     []promotionLine
+		want      model.Money
+	}{
+		{
+			name:      "折扣四舍五入到分",
+			promotion: model.Promotion{Type: model.PromotionTypePercentage, PercentOff: 15},
+			lines:     lines(labubu(3, 33)), // 0.99 × 15% = 0.1485
+			want:      model.Cents(15),
+		},
+		{
+			name:      "半分远离零进位",
+			promotion: model.Promotion{Type: model.PromotionTypePercentage, PercentOff: 10},
+			lines:     lines(labubu(1, 5)), // 0.05 × 10% = 0.005
+			want:      model.Cents(1),
+		},
+		{
+			name:      "立减不超过适用商品金额",
+			promotion: model.Promotion{Type: model.PromotionTypeFixed, AmountOff: model.Cents(5000)},
+			lines:     lines(labubu(1, 3000)),
+			want:      model.Cents(3000),
+		},
+		{
+			name:      "未达到满减门槛",
+			promotion: model.Promotion{Type: model.PromotionTypeThreshold, AmountOff: model.Cents(5000), MinAmount: model.Cents(30000)},
+			lines:     lines(labubu(1, 29999)),
+			want:      model.Cents(0),
+		},
+		{
+			name:      "达到满减门槛",
+			promotion: model.Promotion{Type: model.PromotionTypeThreshold, AmountOff: model.Cents(5000), MinAmount: model.Cents(30000)},
+			lines:     lines(labubu(2, 10000), stars(1, 10000)),
+			want:      model.Cents(5000),
+		},
+		{
+			name:      "买二送一合并计算，最便宜的免费",
+			promotion: model.Promotion{Type: model.PromotionTypeBuyNGetM, BuyQuantity: 2, GetQuantity: 1},
+			lines:     lines(labubu(3, 6900), stars(1, 3900)),
+			want:      model.Cents(3900),
+		},
+		{
+			name:      "系列促销只计算该系列商品",
+			promotion: model.Promotion{Type: model.PromotionTypePercentage, PercentOff: 50, Scope: model.PromotionScopeSeries, Series: "星星人"},
+			lines:     lines(labubu(2, 6900), stars(1, 3900)),
+			want:      model.Cents(1950),
+		},
+		{
+			name:      "指定商品促销没有适用商品",
+			promotion: model.Promotion{Type: model.PromotionTypeFixed, AmountOff: model.Cents(1000), Scope: model.PromotionScopeProduct, ProductID: new(int)},
+			lines:     lines(labubu(1, 6900)),
+			want:      model.Cents(0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := promotionDiscount(&tt.promotion, tt.lines)
+			if err != nil {
+				t.Fatalf("计算优惠失败: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Fatalf("优惠 %s，预期 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// setupPromotions 创建测试表和一个价格为 price 的商品
+func setupPromotions(t *testing.T, price int64, stock int) *model.Product {
+	t.Helper()
+	dbtest.Open(t, &model.Product{}, &model.Order{}, &model.OrderItem{}, &model.OrderStatusHistory{},
+		&model.Promotion{}, &model.Coupon{}, &model.CouponRedemption{}, &model.OrderDiscount{})
+
+	product := &model.Product{Name: "拉布布 坐坐派对", Series: "拉布布", Price: model.Cents(price), Stock: stock}
+	if err := db.DB.Create(product).Error; err != nil {
+		t.Fatalf("创建商品失败: %v", err)
+	}
+	return product
+}
+
+// createTestPromotion 创建正在生效的促销，code 不为空时同时创建关联的优惠码
+func createTestPromotion(t *testing.T, promotion model.Promotion, code string, usageLimit int) *model.Coupon {
+	t.Helper()
+	now := time.Now()
+	promotion.Name = "测试促销"
+	promotion.Active = true
+	promotion.StartAt, promotion.EndAt = now.Add(-time.Hour), now.Add(time.Hour)
+	if promotion.Scope == "" {
+		promotion.Scope = model.PromotionScopeAll
+	}
+	if err := db.DB.Create(&promotion).Error; err != nil {
+		t.Fatalf("创建促销失败: %v", err)
+	}
+	if code == "" {
+		return nil
+	}
+	coupon := &model.Coupon{
+		Code:         code,
+		PromotionID:  promotion.ID,
+		UsageLimit:   usageLimit,
+		PerUserLimit: 1,
+		Active:       true,
+		StartAt:      promotion.StartAt,
+		EndAt:        promotion.EndAt,
+	}
+	if err := db.DB.Omit("Promotion").Create(coupon).Error; err != nil {
+		t.Fatalf("创建优惠码失败: %v", err)
+	}
+	return coupon
+}
+
+// placeOrder 在一个事务中下单并应用促销和优惠码
+func placeOrder(userID, productID, quantity int, couponCode string) (*model.Order, error) {
+	var order *model.Order
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if order, err = createOrderTx(tx, userID, []orderLine{{productID: productID, quantity: quantity}}); err != nil {
+			return err
+		}
+		return applyOrderPromotionsTx(tx, userID, order, couponCode)
+	})
+	return order, err
+}
+
+// TestApplyOrderPromotionsStacking 自动促销只取优惠最多的一项，优惠码叠加计算，两项都按原价计算
+func TestApplyOrderPromotionsStacking(t *testing.T) {
+	product := setupPromotions(t, 5000, 10)
+	createTestPromotion(t, model.Promotion{Type: model.PromotionTypePercentage, PercentOff: 10, AutoApply: true}, "", 0)
+	createTestPromotion(t, model.Promotion{Type: model.PromotionTypeFixed, AmountOff: model.Cents(500), AutoApply: true}, "", 0)
+	createTestPromotion(t, model.Promotion{Type: model.PromotionTypeFixed, AmountOff: model.Cents(2000)}, "STACK20", 0)
+
+	order, err := placeOrder(1, product.ID, 2, "stack20")
+	if err != nil {
+		t.Fatalf("下单失败: %v", err)
+	}
+	// 100.00 - 自动折扣 10.00（优于立减 5.00）- 优惠码 20.00
+	if !order.DiscountAmount.Equal(model.Cents(3000)) || !order.TotalPrice.Equal(model.Cents(7000)) {
+		t.Fatalf("优惠 %s、应付 %s，预期 30.00 和 70.00", order.DiscountAmount, order.TotalPrice)
+	}
+	if len(order.Discounts) != 2 || !order.Discounts[0].Amount.Equal(model.Cents(1000)) || order.Discounts[1].CouponCode != "STACK20" {
+		t.Fatalf("订单优惠记录不正确: %+v", order.Discounts)
+	}
+
+	var stored model.Order
+	if err := db.DB.First(&stored, order.ID).Error; err != nil {
+		t.Fatalf("查询订单失败: %v", err)
+	}
+	if !stored.TotalPrice.Equal(order.TotalPrice) || !stored.DiscountAmount.Equal(order.DiscountAmount) {
+		t.Fatalf("保存的订单金额为 %s / %s", stored.TotalPrice, stored.DiscountAmount)
+	}
+}
+
+// TestApplyOrderPromotionsFloor 优惠合计超过订单金额时，应付金额保留 0.01
+func TestApplyOrderPromotionsFloor(t *testing.T) {
+	product := setupPromotions(t, 6900, 10)
+	createTestPromotion(t, model.Promotion{Type: model.PromotionTypePercentage, PercentOff: 90, AutoApply: true}, "", 0)
+	createTestPromotion(t, model.Promotion{Type: model.PromotionTypeFixed, AmountOff: model.Cents(50000)}, "FREE500", 0)
+
+	order, err := placeOrder(1, product.ID, 1, "FREE500")
+	if err != nil {
+		t.Fatalf("下单失败: %v", err)
+	}
+	if !order.TotalPrice.Equal(model.Cents(1)) || !order.DiscountAmount.Equal(model.Cents(6899)) {
+		t.Fatalf("优惠 %s、应付 %s，预期 68.99 和 0.01", order.DiscountAmount, order.TotalPrice)
+	}
+	// 自动促销 62.10，优惠码只能再减 6.89
+	if len(order.Discounts) != 2 || !order.Discounts[0].Amount.Equal(model.Cents(6210)) || !order.Discounts[1].Amount.Equal(model.Cents(689)) {
+		t.Fatalf("订单优惠记录不正确: %+v", order.Discounts)
+	}
+
+	// 自动促销已减到 0.01 时优惠码不可用
+	createTestPromotion(t, model.Promotion{Type: model.PromotionTypeFixed, AmountOff: model.Cents(100000), AutoApply: true}, "", 0)
+	if _, err := placeOrder(2, product.ID, 1, "FREE500"); err == nil {
+		t.Fatal("订单已无可优惠金额时仍可使用优惠码")
+	}
+}
+
+// TestCouponUsedCountConcurrent 并发使用限量优惠码：成功次数不超过限量，used_count 与使用记录一致，释放后不会减到0以下
+func TestCouponUsedCountConcurrent(t *testing.T) {
+	const usageLimit, attempts = 3, 10
+	product := setupPromotions(t, 6900, 100)
+	coupon := createTestPromotion(t, model.Promotion{Type: model.PromotionTypeFixed, AmountOff: model.Cents(1000)}, "LIMIT3", usageLimit)
+
+	start := make(chan struct{})
+	orders := make([]*model.Order, attempts)
+	errs := make([]error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			orders[i], errs[i] = placeOrder(i+1, product.ID, 1, coupon.Code)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	var placed []*model.Order
+	for i, err := range errs {
+		if err == nil {
+			placed = append(placed, orders[i])
+		}
+	}
+	if len(placed) != usageLimit {
+		t.Fatalf("成功使用优惠码 %d 次，预期 %d 次（错误: %v）", len(placed), usageLimit, errs)
+	}
+	usedCount := func() int {
+		t.Helper()
+		var got model.Coupon
+		if err := db.DB.First(&got, coupon.ID).Error; err != nil {
+			t.Fatalf("查询优惠码失败: %v", err)
+		}
+		return got.UsedCount
+	}
+	if got := usedCount(); got != usageLimit {
+		t.Fatalf("used_count 为 %d，预期 %d", got, usageLimit)
+	}
+
+	// 取消订单释放优惠码，重复释放不重复扣减
+	for _, order := range append(placed, placed[0]) {
+		if err := db.DB.Transaction(func(tx *gorm.DB) error {
+			return releaseCouponRedemptionsTx(tx, order.ID)
+		}); err != nil {
+			t.Fatalf("释放优惠码失败: %v", err)
+		}
+	}
+	if got := usedCount(); got != 0 {
+		t.Fatalf("全部释放后 used_count 为 %d，预期 0", got)
+	}
+	if err := dao.IncreaseCouponUsedCount(db.DB, coupon.ID, -1); err != nil {
+		t.Fatalf("调整使用次数失败: %v", err)
+	}
+	if got := usedCount(); got != 0 {
+		t.Fatalf("used_count 减到了 %d，预期最低为 0", got)
+	}
+}
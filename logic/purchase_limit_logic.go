@@ -0,0 +1,131 @@
+package logic
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"shop/dao"
+	"shop/global/db"
+	"shop/model"
+)
+
+// maxCooldownMinutes 下单冷却时间上限（30天）
+const maxCooldownMinutes = 43200
+
+// purchaseLimitExcludedStatuses 不计入限购的订单状态，订单取消或退款后额度自动恢复
+var purchaseLimitExcludedStatuses = []string{model.OrderStatusCancelled, model.OrderStatusRefunded}
+
+// validatePurchaseLimit 校验限购件数或冷却分钟数
+func validatePurchaseLimit(name string, value, max int) error {
+	if value < 0 || value > max {
+		return newValidationError("%s必须在 0 到 %d 之间", name, max)
+	}
+	return nil
+}
+
+// validatePurchaseLimits 校验商品限购规则
+func validatePurchaseLimits(l *model.PurchaseLimits) error {
+	if err := validatePurchaseLimit("每单限购", l.LimitPerOrder, maxProductStock); err != nil {
+		return err
+	}
+	if err := validatePurchaseLimit("每日限购", l.LimitPerDay, maxProductStock); err != nil {
+		return err
+	}
+	if err := validatePurchaseLimit("累计限购", l.LimitLifetime, maxProductStock); err != nil {
+		return err
+	}
+	return validatePurchaseLimit("下单冷却时间", l.CooldownMinutes, maxCooldownMinutes)
+}
+
+// startOfDay 返回 t 所在自然日的零点（服务器时区）
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// checkPurchaseLimits 校验用户本次购买的各商品数量是否超出限购规则
+// quantities 为本次每个商品的总件数；checkCooldown 为 false 时不校验下单冷却（加入购物车时）；
+// lock 为 true 时加锁读取已有订单，下单事务中已持有商品行锁，同一商品的并发下单会按顺序校验
+func checkPurchaseLimits(tx *gorm.DB, userID int, products map[int]*model.Product, quantities map[int]int, checkCooldown, lock bool) error {
+	ids := make([]int, 0, len(products))
+	for id, p := range products {
+		if p.HasLimits() {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	sort.Ints(ids)
+
+	now := time.Now()
+	usage, err := dao.GetPurchaseUsage(tx, userID, ids, purchaseLimitExcludedStatuses, startOfDay(now), lock)
+	if err != nil {
+		return fmt.Errorf("查询购买记录失败: %w", err)
+	}
+
+	for _, id := range ids {
+		p := products[id]
+		q := quantities[id]
+		u := usage[id]
+		limitErr := &PurchaseLimitError{ProductID: id, ProductName: p.Name, Requested: q}
+		switch {
+		case p.LimitPerOrder > 0 && q > p.LimitPerOrder:
+			limitErr.Limit, limitErr.Max = PurchaseLimitPerOrder, p.LimitPerOrder
+		case p.LimitPerDay > 0 && u.Today+q > p.LimitPerDay:
+			limitErr.Limit, limitErr.Max, limitErr.Used = PurchaseLimitPerDay, p.LimitPerDay, u.Today
+		case p.LimitLifetime > 0 && u.Lifetime+q > p.LimitLifetime:
+			limitErr.Limit, limitErr.Max, limitErr.Used = PurchaseLimitLifetime, p.LimitLifetime, u.Lifetime
+		case checkCooldown && p.CooldownMinutes > 0 && u.LastOrderAt != nil &&
+			now.Before(u.LastOrderAt.Add(time.Duration(p.CooldownMinutes)*time.Minute)):
+			retryAfter := u.LastOrderAt.Add(time.Duration(p.CooldownMinutes) * time.Minute)
+			limitErr.Limit, limitErr.Max, limitErr.RetryAfter = PurchaseLimitCooldown, p.CooldownMinutes, &retryAfter
+		default:
+			continue
+		}
+		return limitErr
+	}
+	return nil
+}
+
+// checkCartPurchaseLimit 校验购物车中商品的数量是否超出每单、每日和累计限购（冷却在下单时校验）
+func checkCartPurchaseLimit(userID, productID, quantity int) error {
+	product, err := dao.GetProductByIDTx(db.DB, productID)
+	if err != nil {
+		return fmt.Errorf("查询商品失败: %w", err)
+	}
+	if product == nil || !product.HasLimits() {
+		return nil
+	}
+	return checkPurchaseLimits(db.DB, userID,
+		map[int]*model.Product{productID: product},
+		map[int]int{productID: quantity},
+		false, false)
+}
+
+// maxPurchasableQuantity 返回用户当前最多还能购买的商品件数（只考虑每单、每日和累计限购，不考虑库存）
+func maxPurchasableQuantity(userID int, product *model.Product) (int, error) {
+	if !product.HasLimits() {
+		return math.MaxInt, nil
+	}
+	usage, err := dao.GetPurchaseUsage(db.DB, userID, []int{product.ID}, purchaseLimitExcludedStatuses, startOfDay(time.Now()), false)
+	if err != nil {
+		return 0, fmt.Errorf("查询购买记录失败: %w", err)
+	}
+	u := usage[product.ID]
+
+	limit := math.MaxInt
+	if product.LimitPerOrder > 0 {
+		limit = min(limit, product.LimitPerOrder)
+	}
+	if product.LimitPerDay > 0 {
+		limit = min(limit, product.LimitPerDay-u.Today)
+	}
+	if product.LimitLifetime > 0 {
+		limit = min(limit, product.LimitLifetime-u.Lifetime)
+	}
+	return max(limit, 0), nil
+}
@@ -0,0 +1,92 @@
+package logic
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+	"shop/global/db"
+	"shop/global/db/dbtest"
+	"shop/model"
+)
+
+// TestCreateOrderTxConcurrentPurchaseLimit 同一用户并发下单同一限购商品：成功的件数不超过限购，其余返回 *PurchaseLimitError
+// SQLite 下事务串行执行；设置 SHOP_TEST_MYSQL_DSN 后在 MySQL 上验证商品行锁和锁定读
+func TestCreateOrderTxConcurrentPurchaseLimit(t *testing.T) {
+	dbtest.Open(t, &model.Product{}, &model.Order{}, &model.OrderItem{}, &model.OrderStatusHistory{})
+
+	const limit, attempts = 3, 12
+	product := model.Product{
+		Name:           "拉布布 一起干杯",
+		Price:          model.Cents(8900),
+		Stock:          100,
+		PurchaseLimits: model.PurchaseLimits{LimitLifetime: limit, LimitPerDay: limit + 1},
+	}
+	if err := db.DB.Create(&product).Error; err != nil {
+		t.Fatalf("创建商品失败: %v", err)
+	}
+	const userID, otherUserID = 1, 2
+
+	start := make(chan struct{})
+	errs := make([]error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = db.DB.Transaction(func(tx *gorm.DB) error {
+				_, err := createOrderTx(tx, userID, []orderLine{{productID: product.ID, quantity: 1}})
+				return err
+			})
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	succeeded, limited := 0, 0
+	for i, err := range errs {
+		var limitErr *PurchaseLimitError
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.As(err, &limitErr):
+			limited++
+			if limitErr.Limit != PurchaseLimitLifetime || limitErr.Max != limit {
+				t.Errorf("第 %d 次下单: 限购错误内容不正确: %+v", i+1, limitErr)
+			}
+		default:
+			t.Errorf("第 %d 次下单: 预期成功或 *PurchaseLimitError，实际为 %T: %v", i+1, err, err)
+		}
+	}
+	if succeeded != limit || limited != attempts-limit {
+		t.Fatalf("成功 %d 单、超出限购 %d 单，预期分别为 %d 和 %d", succeeded, limited, limit, attempts-limit)
+	}
+
+	remaining, err := maxPurchasableQuantity(userID, &product)
+	if err != nil {
+		t.Fatalf("查询可购数量失败: %v", err)
+	}
+	if remaining != 0 {
+		t.Fatalf("达到限购后仍可购买 %d 件", remaining)
+	}
+
+	// 取消的订单不计入限购，其他用户不受影响
+	var order model.Order
+	if err := db.DB.Where("user_id = ?", userID).First(&order).Error; err != nil {
+		t.Fatalf("查询订单失败: %v", err)
+	}
+	if err := db.DB.Model(&order).Update("status", model.OrderStatusCancelled).Error; err != nil {
+		t.Fatalf("取消订单失败: %v", err)
+	}
+	for _, buyer := range []int{userID, otherUserID} {
+		err := db.DB.Transaction(func(tx *gorm.DB) error {
+			_, err := createOrderTx(tx, buyer, []orderLine{{productID: product.ID, quantity: 1}})
+			return err
+		})
+		if err != nil {
+			t.Fatalf("用户 %d 下单失败: %v", buyer, err)
+		}
+	}
+}
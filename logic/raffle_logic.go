@@ -0,0 +1,453 @@
+package logic
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	mathrand "math/rand/v2"
+	"time"
+
+	"gorm.io/gorm"
+	"shop/dao"
+	"shop/global/db"
+	"shop/model"
+)
+
+// 抽签购买时限（分钟）
+const (
+	defaultRaffleCheckoutMinutes = 1440
+	maxRaffleCheckoutMinutes     = 10080
+)
+
+var (
+	// ErrRaffleNotFound 抽签不存在
+	ErrRaffleNotFound = errors.New("抽签不存在")
+	// ErrRaffleNotOpen 不在报名时间内
+	ErrRaffleNotOpen = errors.New("不在报名时间内")
+	// ErrRaffleAlreadyEntered 每个用户每场抽签只能报名一次
+	ErrRaffleAlreadyEntered = errors.New("已报名该抽签")
+	// ErrRaffleEntryNotFound 用户未报名
+	ErrRaffleEntryNotFound = errors.New("未报名该抽签")
+	// ErrRaffleNotWon 未中签或购买资格已失效
+	ErrRaffleNotWon = errors.New("未中签或购买资格已失效")
+)
+
+// fillRaffle 填充报名人数，已开奖时公布种子
+func fillRaffle(raffle *model.Raffle) {
+	if count, err := dao.CountRaffleEntries(raffle.ID); err == nil {
+		raffle.EntryCount = count
+	}
+	if raffle.Status == model.RaffleStatusDrawn {
+		raffle.RevealedSeed = raffle.Seed
+	}
+}
+
+// GetRaffles 获取报名中和已开奖的抽签
+func GetRaffles() ([]model.Raffle, error) {
+	raffles, err := dao.GetRaffles([]string{model.RaffleStatusOpen, model.RaffleStatusDrawn})
+	if err != nil {
+		return nil, err
+	}
+	for i := range raffles {
+		fillRaffle(&raffles[i])
+	}
+	return raffles, nil
+}
+
+// GetRaffle 获取抽签详情
+func GetRaffle(raffleID int) (*model.Raffle, error) {
+	raffle, err := dao.GetRaffleByID(raffleID)
+	if err != nil {
+		return nil, fmt.Errorf("查询抽签失败: %w", err)
+	}
+	if raffle == nil {
+		return nil, ErrRaffleNotFound
+	}
+	fillRaffle(raffle)
+	return raffle, nil
+}
+
+// AdminGetRaffles 获取全部抽签（管理后台）
+func AdminGetRaffles() ([]model.Raffle, error) {
+	raffles, err := dao.GetRaffles(nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range raffles {
+		fillRaffle(&raffles[i])
+	}
+	return raffles, nil
+}
+
+// drawRaffleWinners 用种子从报名中抽取 n 个中签者
+// 随机数序列为 ChaCha8(seed)，对按ID升序排列的报名ID执行 Shuffle，前 n 个即为中签者（按抽中顺序）
+func drawRaffleWinners(seed [32]byte, entryIDs []int, n int) []int {
+	shuffled := append([]int(nil), entryIDs...)
+	rng := mathrand.New(mathrand.NewChaCha8(seed))
+	rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	if n > len(shuffled) {
+		n = len(shuffled)
+	}
+	return shuffled[:n]
+}
+
+// decodeRaffleSeed 解析十六进制种子
+func decodeRaffleSeed(s string) ([32]byte, error) {
+	var seed [32]byte
+	raw, err := hex.DecodeString(s)
+	if err != nil || len(raw) != len(seed) {
+		return seed, fmt.Errorf("抽签种子无效")
+	}
+	copy(seed[:], raw)
+	return seed, nil
+}
+
+// GetRaffleResults 获取开奖结果，任何人都可以用公布的种子重放抽取并与创建时公布的哈希核对
+func GetRaffleResults(raffleID int) (*model.RaffleResult, error) {
+	raffle, err := dao.GetRaffleByID(raffleID)
+	if err != nil {
+		return nil, fmt.Errorf("查询抽签失败: %w", err)
+	}
+	if raffle == nil {
+		return nil, ErrRaffleNotFound
+	}
+	if raffle.Status != model.RaffleStatusDrawn {
+		return nil, newValidationError("抽签尚未开奖")
+	}
+
+	seed, err := decodeRaffleSeed(raffle.Seed)
+	if err != nil {
+		return nil, err
+	}
+	entryIDs, err := dao.GetRaffleEntryIDs(db.DB, raffleID)
+	if err != nil {
+		return nil, fmt.Errorf("查询报名失败: %w", err)
+	}
+	return &model.RaffleResult{
+		RaffleID:       raffle.ID,
+		Seed:           raffle.Seed,
+		SeedHash:       raffle.SeedHash,
+		EntryIDs:       entryIDs,
+		WinnerEntryIDs: drawRaffleWinners(seed, entryIDs, raffle.Winners),
+	}, nil
+}
+
+// CreateRaffle 创建抽签，生成种子并公布其哈希；同一商品同时只能有一场未结束的抽签或抢购
+func CreateRaffle(req *model.CreateRaffleRequest) (*model.Raffle, error) {
+	if err := validateProductPrice(req.Price); err != nil {
+		return nil, err
+	}
+	if req.Quantity < 1 {
+		return nil, newValidationError("中签名额必须大于0")
+	}
+	if req.CheckoutMinutes == 0 {
+		req.CheckoutMinutes = defaultRaffleCheckoutMinutes
+	}
+	if req.CheckoutMinutes < 1 || req.CheckoutMinutes > maxRaffleCheckoutMinutes {
+		return nil, newValidationError("购买时限必须在 1 到 %d 分钟之间", maxRaffleCheckoutMinutes)
+	}
+	if req.EntryStartAt.IsZero() || !req.EntryEndAt.After(req.EntryStartAt) {
+		return nil, newValidationError("报名截止时间必须晚于开始时间")
+	}
+	if !req.EntryEndAt.After(time.Now()) {
+		return nil, newValidationError("报名截止时间必须晚于当前时间")
+	}
+
+	product, err := dao.GetProductByIDUnscoped(req.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("查询商品失败: %w", err)
+	}
+	if product == nil || product.DeletedAt.Valid {
+		return nil, fmt.Errorf("商品不存在")
+	}
+	if err := checkProductCampaignFree(req.ProductID); err != nil {
+		return nil, err
+	}
+
+	var seed [32]byte
+	if _, err := rand.Read(seed[:]); err != nil {
+		return nil, fmt.Errorf("生成抽签种子失败: %w", err)
+	}
+	hash := sha256.Sum256(seed[:])
+
+	raffle := &model.Raffle{
+		ProductID:       req.ProductID,
+		Price:           req.Price,
+		Quantity:        req.Quantity,
+		EntryStartAt:    req.EntryStartAt,
+		EntryEndAt:      req.EntryEndAt,
+		CheckoutMinutes: req.CheckoutMinutes,
+		Status:          model.RaffleStatusOpen,
+		SeedHash:        hex.EncodeToString(hash[:]),
+		Seed:            hex.EncodeToString(seed[:]),
+	}
+	if err := dao.CreateRaffle(raffle); err != nil {
+		return nil, fmt.Errorf("创建抽签失败: %w", err)
+	}
+	return GetRaffle(raffle.ID)
+}
+
+// checkProductCampaignFree 商品没有未结束的抢购或抽签时才能创建新的抢购或抽签
+func checkProductCampaignFree(productID int) error {
+	openSales, err := dao.CountOpenFlashSales(productID)
+	if err != nil {
+		return fmt.Errorf("查询抢购失败: %w", err)
+	}
+	if openSales > 0 {
+		return newValidationError("该商品已有未结束的抢购")
+	}
+	openRaffles, err := dao.CountOpenRaffles(productID, time.Now())
+	if err != nil {
+		return fmt.Errorf("查询抽签失败: %w", err)
+	}
+	if openRaffles > 0 {
+		return newValidationError("该商品已有未结束的抽签")
+	}
+	return nil
+}
+
+// CancelRaffle 取消尚未开奖的抽签
+func CancelRaffle(raffleID int) (*model.Raffle, error) {
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		raffle, err := dao.GetRaffleForUpdate(tx, raffleID)
+		if err != nil {
+			return fmt.Errorf("查询抽签失败: %w", err)
+		}
+		if raffle == nil {
+			return ErrRaffleNotFound
+		}
+		if raffle.Status != model.RaffleStatusOpen {
+			return newValidationError("抽签状态为 %s，不能取消", raffle.Status)
+		}
+		return dao.UpdateRaffle(tx, raffleID, map[string]interface{}{"status": model.RaffleStatusCancelled})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetRaffle(raffleID)
+}
+
+// EnterRaffle 报名抽签，每个用户每场只能报名一次
+// 报名时锁定抽签行，与开奖互斥，开奖后不会再有新的报名
+func EnterRaffle(userID, raffleID int) (*model.RaffleEntry, error) {
+	entry := &model.RaffleEntry{
+		RaffleID: raffleID,
+		UserID:   userID,
+		Status:   model.RaffleEntryEntered,
+	}
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		raffle, err := dao.GetRaffleForUpdate(tx, raffleID)
+		if err != nil {
+			return fmt.Errorf("查询抽签失败: %w", err)
+		}
+		if raffle == nil {
+			return ErrRaffleNotFound
+		}
+		now := time.Now()
+		if raffle.Status != model.RaffleStatusOpen || now.Before(raffle.EntryStartAt) || !now.Before(raffle.EntryEndAt) {
+			return ErrRaffleNotOpen
+		}
+
+		created, err := dao.CreateRaffleEntry(tx, entry)
+		if err != nil {
+			return fmt.Errorf("报名失败: %w", err)
+		}
+		if !created {
+			return ErrRaffleAlreadyEntered
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// GetMyRaffleEntry 获取用户在抽签中的报名
+func GetMyRaffleEntry(userID, raffleID int) (*model.RaffleEntry, error) {
+	entry, err := dao.GetRaffleEntry(raffleID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询报名失败: %w", err)
+	}
+	if entry == nil {
+		return nil, ErrRaffleEntryNotFound
+	}
+	return entry, nil
+}
+
+// DrawRaffle 开奖：报名截止后用种子抽取中签者，中签名额不超过商品当前库存，中签者获得限时购买资格
+func DrawRaffle(raffleID int) (*model.Raffle, error) {
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		raffle, err := dao.GetRaffleForUpdate(tx, raffleID)
+		if err != nil {
+			return fmt.Errorf("查询抽签失败: %w", err)
+		}
+		if raffle == nil {
+			return ErrRaffleNotFound
+		}
+		if raffle.Status != model.RaffleStatusOpen {
+			return newValidationError("抽签状态为 %s，不能开奖", raffle.Status)
+		}
+		now := time.Now()
+		if now.Before(raffle.EntryEndAt) {
+			return newValidationError("报名尚未截止")
+		}
+
+		seed, err := decodeRaffleSeed(raffle.Seed)
+		if err != nil {
+			return err
+		}
+		entryIDs, err := dao.GetRaffleEntryIDs(tx, raffleID)
+		if err != nil {
+			return fmt.Errorf("查询报名失败: %w", err)
+		}
+		product, err := dao.GetProductByIDTx(tx, raffle.ProductID)
+		if err != nil {
+			return fmt.Errorf("查询商品失败: %w", err)
+		}
+		n := raffle.Quantity
+		if product == nil {
+			n = 0
+		} else if product.Stock < n {
+			n = product.Stock
+		}
+		if n < 0 {
+			n = 0
+		}
+		winners := drawRaffleWinners(seed, entryIDs, n)
+
+		deadline := now.Add(time.Duration(raffle.CheckoutMinutes) * time.Minute)
+		if err := dao.UpdateRaffleEntries(tx, winners, map[string]interface{}{
+			"status":            model.RaffleEntryWon,
+			"checkout_deadline": deadline,
+		}); err != nil {
+			return fmt.Errorf("更新中签报名失败: %w", err)
+		}
+		if err := dao.MarkRaffleLosers(tx, raffleID); err != nil {
+			return fmt.Errorf("更新未中签报名失败: %w", err)
+		}
+		return dao.UpdateRaffle(tx, raffleID, map[string]interface{}{
+			"status":            model.RaffleStatusDrawn,
+			"winners":           len(winners),
+			"drawn_at":          now,
+			"checkout_deadline": deadline,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetRaffle(raffleID)
+}
+
+// CheckoutRaffle 中签者在购买截止时间前按抽签价购买一件，购买资格只能使用一次
+func CheckoutRaffle(userID, raffleID int) (*model.Order, error) {
+	raffle, err := dao.GetRaffleByID(raffleID)
+	if err != nil {
+		return nil, fmt.Errorf("查询抽签失败: %w", err)
+	}
+	if raffle == nil {
+		return nil, ErrRaffleNotFound
+	}
+
+	var order *model.Order
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		entry, err := dao.GetRaffleEntryForUpdate(tx, raffleID, userID)
+		if err != nil {
+			return fmt.Errorf("查询报名失败: %w", err)
+		}
+		if entry == nil {
+			return ErrRaffleEntryNotFound
+		}
+		if entry.Status == model.RaffleEntryPurchased {
+			return newValidationError("购买资格已使用")
+		}
+		if entry.Status != model.RaffleEntryWon || entry.CheckoutDeadline == nil || !time.Now().Before(*entry.CheckoutDeadline) {
+			return ErrRaffleNotWon
+		}
+
+		order, err = createOrderTx(tx, userID, []orderLine{{
+			productID: raffle.ProductID,
+			quantity:  1,
+			priced:    true,
+			unitPrice: raffle.Price,
+		}})
+		if err != nil {
+			return err
+		}
+		return dao.UpdateRaffleEntries(tx, []int{entry.ID}, map[string]interface{}{
+			"status":   model.RaffleEntryPurchased,
+			"order_id": order.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 超时未支付自动取消，取消后购买资格不恢复
+	scheduleOrderExpiry(order)
+	return order, nil
+}
+
+// checkRaffleLines 抽签报名中或中签者仍可购买的商品不能通过普通结算购买，避免中签者购买时库存不足
+func checkRaffleLines(lines []orderLine) error {
+	reserved, err := dao.GetReservedRaffleProductIDs(time.Now())
+	if err != nil {
+		return fmt.Errorf("查询抽签失败: %w", err)
+	}
+	for _, line := range lines {
+		if reserved[line.productID] {
+			return newValidationError("商品 %d 正在抽签发售中，请通过抽签购买", line.productID)
+		}
+	}
+	return nil
+}
+
+// drawDueRaffles 为报名已截止的抽签开奖，并使超时未购买的中签资格失效
+func drawDueRaffles() {
+	now := time.Now()
+	ids, err := dao.GetDueRaffleIDs(now)
+	if err != nil {
+		log.Printf("Warning: Failed to query due raffles: %v", err)
+		return
+	}
+	for _, id := range ids {
+		raffle, err := DrawRaffle(id)
+		if err != nil {
+			log.Printf("Warning: Failed to draw raffle %d: %v", id, err)
+			continue
+		}
+		log.Printf("Raffle %d drawn: %d winner(s) from %d entries", id, raffle.Winners, raffle.EntryCount)
+	}
+
+	expired, err := dao.ExpireRaffleWins(now)
+	if err != nil {
+		log.Printf("Warning: Failed to expire raffle wins: %v", err)
+		return
+	}
+	if expired > 0 {
+		log.Printf("Expired %d unused raffle win(s)", expired)
+	}
+}
+
+// RunRaffleWorker 后台为到期的抽签开奖并使超时的购买资格失效，ctx 取消后退出
+func RunRaffleWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("Raffle worker started (interval %s)", interval)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Raffle worker stopped")
+			return
+		case <-ticker.C:
+			drawDueRaffles()
+		}
+	}
+}
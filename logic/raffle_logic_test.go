@@ -0,0 +1,153 @@
+package logic
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"shop/global/db"
+	"shop/global/db/dbtest"
+	"shop/model"
+)
+
+func TestDrawRaffleWinnersDeterministic(t *testing.T) {
+	var seed [32]byte
+	copy(seed[:], "raffle-determinism-test-seed-000")
+	entryIDs := make([]int, 50)
+	for i := range entryIDs {
+		entryIDs[i] = i + 1
+	}
+
+	first := drawRaffleWinners(seed, entryIDs, 10)
+	second := drawRaffleWinners(seed, entryIDs, 10)
+	if !slices.Equal(first, second) {
+		t.Fatalf("相同种子和报名两次抽取结果不同: %v / %v", first, second)
+	}
+	if len(first) != 10 {
+		t.Fatalf("抽取了 %d 个中签者，预期 10 个", len(first))
+	}
+	seen := map[int]bool{}
+	for _, id := range first {
+		if seen[id] || id < 1 || id > len(entryIDs) {
+			t.Fatalf("中签结果包含重复或不存在的报名: %v", first)
+		}
+		seen[id] = true
+	}
+	if entryIDs[0] != 1 || entryIDs[49] != 50 {
+		t.Fatal("抽取修改了传入的报名ID")
+	}
+
+	// 名额更多时前面的中签者不变，名额超过报名人数时全部中签
+	if more := drawRaffleWinners(seed, entryIDs, 20); !slices.Equal(more[:10], first) {
+		t.Fatalf("名额为 20 时前 10 个中签者为 %v，预期 %v", more[:10], first)
+	}
+	if all := drawRaffleWinners(seed, entryIDs, 100); len(all) != len(entryIDs) {
+		t.Fatalf("名额超过报名人数时中签 %d 人，预期 %d 人", len(all), len(entryIDs))
+	}
+	if none := drawRaffleWinners(seed, entryIDs, 0); len(none) != 0 {
+		t.Fatalf("名额为 0 时中签 %d 人", len(none))
+	}
+}
+
+// TestDrawRaffle 开奖前不公开种子；中签人数不超过商品库存；公布的种子与哈希一致，可以重放得到相同的中签者
+func TestDrawRaffle(t *testing.T) {
+	dbtest.Open(t, &model.Product{}, &model.Raffle{}, &model.RaffleEntry{}, &model.FlashSale{})
+
+	product := model.Product{Name: "拉布布 本我", Price: model.Cents(39900), Stock: 3}
+	if err := db.DB.Create(&product).Error; err != nil {
+		t.Fatalf("创建商品失败: %v", err)
+	}
+	now := time.Now()
+	raffle, err := CreateRaffle(&model.CreateRaffleRequest{
+		ProductID:    product.ID,
+		Price:        model.Cents(29900),
+		Quantity:     5,
+		EntryStartAt: now.Add(-time.Hour),
+		EntryEndAt:   now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("创建抽签失败: %v", err)
+	}
+	for userID := 1; userID <= 10; userID++ {
+		if _, err := EnterRaffle(userID, raffle.ID); err != nil {
+			t.Fatalf("用户 %d 报名失败: %v", userID, err)
+		}
+	}
+
+	before, err := GetRaffle(raffle.ID)
+	if err != nil {
+		t.Fatalf("查询抽签失败: %v", err)
+	}
+	data, err := json.Marshal(before)
+	if err != nil {
+		t.Fatalf("序列化抽签失败: %v", err)
+	}
+	if strings.Contains(string(data), `"seed":`) || strings.Contains(string(data), before.Seed) {
+		t.Fatalf("开奖前的抽签信息包含种子: %s", data)
+	}
+	if _, err := GetRaffleResults(raffle.ID); err == nil {
+		t.Fatal("开奖前可以查询开奖结果")
+	}
+
+	// 报名截止后开奖
+	if err := db.DB.Model(&model.Raffle{}).Where("id = ?", raffle.ID).Update("entry_end_at", now.Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("修改报名截止时间失败: %v", err)
+	}
+	drawn, err := DrawRaffle(raffle.ID)
+	if err != nil {
+		t.Fatalf("开奖失败: %v", err)
+	}
+	if drawn.Winners != product.Stock {
+		t.Fatalf("中签 %d 人，预期不超过库存 %d", drawn.Winners, product.Stock)
+	}
+	var won []model.RaffleEntry
+	db.DB.Where("raffle_id = ? AND status = ?", raffle.ID, model.RaffleEntryWon).Order("id").Find(&won)
+	if len(won) != product.Stock {
+		t.Fatalf("中签报名 %d 条，预期 %d 条", len(won), product.Stock)
+	}
+
+	// 开奖后公布种子，哈希与创建时公布的一致
+	data, _ = json.Marshal(drawn)
+	var public struct {
+		Seed     string `json:"seed"`
+		SeedHash string `json:"seed_hash"`
+	}
+	if err := json.Unmarshal(data, &public); err != nil {
+		t.Fatalf("解析抽签失败: %v", err)
+	}
+	if public.Seed == "" || public.SeedHash != before.SeedHash {
+		t.Fatalf("开奖后公布的种子为 %q、哈希为 %q", public.Seed, public.SeedHash)
+	}
+	raw, err := hex.DecodeString(public.Seed)
+	if err != nil {
+		t.Fatalf("公布的种子无效: %v", err)
+	}
+	if sum := sha256.Sum256(raw); hex.EncodeToString(sum[:]) != public.SeedHash {
+		t.Fatal("公布的种子与创建时公布的哈希不一致")
+	}
+
+	// 用公布的种子和报名列表重放，得到相同的中签者
+	results, err := GetRaffleResults(raffle.ID)
+	if err != nil {
+		t.Fatalf("查询开奖结果失败: %v", err)
+	}
+	var seed [32]byte
+	copy(seed[:], raw)
+	replayed := drawRaffleWinners(seed, results.EntryIDs, drawn.Winners)
+	if !slices.Equal(replayed, results.WinnerEntryIDs) {
+		t.Fatalf("重放结果 %v，公布结果 %v", replayed, results.WinnerEntryIDs)
+	}
+	wonIDs := make([]int, len(won))
+	for i, e := range won {
+		wonIDs[i] = e.ID
+	}
+	sorted := slices.Clone(replayed)
+	slices.Sort(sorted)
+	if !slices.Equal(sorted, wonIDs) {
+		t.Fatalf("中签报名为 %v，重放结果为 %v", wonIDs, sorted)
+	}
+}
@@ -0,0 +1,84 @@
+package logic
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"shop/dao"
+	"shop/global/auth"
+	"shop/model"
+)
+
+// createSession 登录时创建会话
+func createSession(userID int, meta *model.SessionMeta) (string, error) {
+	sessionID, err := auth.NewSessionID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	session := &model.Session{
+		ID:         sessionID,
+		UserID:     userID,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	if meta != nil {
+		session.Device = meta.Device
+		session.UserAgent = meta.UserAgent
+		session.IP = meta.IP
+	}
+
+	if err := dao.CreateSession(session, auth.RefreshTokenTTL()); err != nil {
+		return "", err
+	}
+	return sessionID, nil
+}
+
+// ValidateSession 检查会话是否仍然有效（未注销、未过期且属于该用户）
+func ValidateSession(userID int, sessionID string) (bool, error) {
+	session, err := dao.GetSession(sessionID)
+	if err != nil {
+		return false, err
+	}
+	return session != nil && session.UserID == userID, nil
+}
+
+// Logout 注销当前会话
+func Logout(userID int, sessionID string) error {
+	return dao.DeleteSession(userID, sessionID)
+}
+
+// GetSessions 获取用户的会话列表，按最后活跃时间倒序
+func GetSessions(userID int, currentSessionID string) ([]model.Session, error) {
+	sessions, err := dao.GetSessionsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// RevokeSession 注销指定会话
+func RevokeSession(userID int, sessionID string) error {
+	session, err := dao.GetSession(sessionID)
+	if err != nil {
+		return fmt.Errorf("查询会话失败: %w", err)
+	}
+	if session == nil || session.UserID != userID {
+		return fmt.Errorf("会话不存在")
+	}
+	return dao.DeleteSession(userID, sessionID)
+}
+
+// RevokeAllSessions 注销用户在所有设备上的会话
+func RevokeAllSessions(userID int) error {
+	return dao.DeleteAllSessions(userID)
+}
@@ -0,0 +1,78 @@
+package logic
+
+import (
+	"fmt"
+
+	"shop/dao"
+	"shop/global/auth"
+	"shop/model"
+)
+
+// issueTokenPair 为会话签发访问令牌和刷新令牌
+func issueTokenPair(user *model.User, sessionID string) (*model.TokenResponse, error) {
+	userID := user.ID
+	accessToken, _, err := auth.IssueAccessToken(userID, user.Role, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := dao.SaveRefreshToken(auth.HashRefreshToken(refreshToken), userID, sessionID, auth.RefreshTokenTTL()); err != nil {
+		return nil, err
+	}
+
+	return &model.TokenResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(auth.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// RefreshToken 使用刷新令牌换取新的令牌对，旧刷新令牌立即失效
+// 已使用过的刷新令牌再次出现时视为泄露，直接注销对应会话
+func RefreshToken(req *model.RefreshTokenRequest) (*model.TokenResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, fmt.Errorf("刷新令牌无效")
+	}
+
+	record, err := dao.ConsumeRefreshToken(auth.HashRefreshToken(req.RefreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("刷新令牌无效")
+	}
+
+	if record.Used {
+		if err := dao.DeleteSession(record.UserID, record.SessionID); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("刷新令牌无效")
+	}
+
+	exists, err := dao.SessionExists(record.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("刷新令牌无效")
+	}
+
+	if err := dao.TouchSession(record.UserID, record.SessionID, auth.RefreshTokenTTL()); err != nil {
+		return nil, fmt.Errorf("更新会话失败: %w", err)
+	}
+
+	// 重新读取用户，保证新令牌携带最新角色
+	user, err := dao.GetUserByID(record.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("刷新令牌无效")
+	}
+
+	return issueTokenPair(user, record.SessionID)
+}
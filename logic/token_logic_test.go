@@ -0,0 +1,157 @@
+package logic
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"shop/config"
+	"shop/global/auth"
+	"shop/global/db/dbtest"
+	"shop/global/redis/redistest"
+	"shop/model"
+)
+
+// setupAuth 准备用户表、Redis 和令牌签名配置
+func setupAuth(t *testing.T) {
+	t.Helper()
+	dbtest.Open(t, &model.User{})
+	redistest.Open(t)
+	if err := auth.InitAuth(config.AuthConfig{
+		Issuer:          "shop",
+		Audience:        "shop-api",
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: time.Hour,
+		ActiveKeyID:     "k1",
+		SigningKeys:     map[string]string{"k1": strings.Repeat("k", 32)},
+	}); err != nil {
+		t.Fatalf("初始化令牌配置失败: %v", err)
+	}
+}
+
+// registerAndLogin 注册用户并登录，返回用户ID和登录结果
+func registerAndLogin(t *testing.T, username string) (int, *model.LoginResponse) {
+	t.Helper()
+	userID, err := Register(&model.RegisterRequest{Username: username, Password: "secret123", Email: username + "@example.com"})
+	if err != nil {
+		t.Fatalf("注册失败: %v", err)
+	}
+	return int(userID), login(t, username)
+}
+
+func login(t *testing.T, username string) *model.LoginResponse {
+	t.Helper()
+	resp, err := Login(&model.LoginRequest{Username: username, Password: "secret123"}, nil)
+	if err != nil {
+		t.Fatalf("登录失败: %v", err)
+	}
+	return resp
+}
+
+// claimsOf 解析访问令牌，返回其中的声明（用户、角色和会话ID）
+func claimsOf(t *testing.T, accessToken string) *auth.Claims {
+	t.Helper()
+	claims, err := auth.ParseAccessToken(accessToken)
+	if err != nil {
+		t.Fatalf("解析访问令牌失败: %v", err)
+	}
+	return claims
+}
+
+func assertSessionValid(t *testing.T, userID int, sessionID string, want bool) {
+	t.Helper()
+	valid, err := ValidateSession(userID, sessionID)
+	if err != nil {
+		t.Fatalf("校验会话失败: %v", err)
+	}
+	if valid != want {
+		t.Fatalf("会话 %s 有效 = %v，预期 %v", sessionID, valid, want)
+	}
+}
+
+func TestRefreshTokenRotates(t *testing.T) {
+	setupAuth(t)
+	userID, first := registerAndLogin(t, "alice")
+	sessionID := claimsOf(t, first.Token).SessionID
+
+	second, err := RefreshToken(&model.RefreshTokenRequest{RefreshToken: first.RefreshToken})
+	if err != nil {
+		t.Fatalf("刷新令牌失败: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("刷新后刷新令牌没有轮换")
+	}
+	if got := claimsOf(t, second.Token).SessionID; got != sessionID {
+		t.Fatalf("刷新后会话ID为 %s，预期仍为 %s", got, sessionID)
+	}
+
+	// 新的刷新令牌可以继续使用
+	if _, err := RefreshToken(&model.RefreshTokenRequest{RefreshToken: second.RefreshToken}); err != nil {
+		t.Fatalf("使用轮换后的刷新令牌失败: %v", err)
+	}
+	assertSessionValid(t, userID, sessionID, true)
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	setupAuth(t)
+	userID, first := registerAndLogin(t, "bob")
+	sessionID := claimsOf(t, first.Token).SessionID
+	other := login(t, "bob")
+	otherSessionID := claimsOf(t, other.Token).SessionID
+
+	second, err := RefreshToken(&model.RefreshTokenRequest{RefreshToken: first.RefreshToken})
+	if err != nil {
+		t.Fatalf("刷新令牌失败: %v", err)
+	}
+
+	// 已轮换的刷新令牌再次出现：拒绝并注销该会话
+	if _, err := RefreshToken(&model.RefreshTokenRequest{RefreshToken: first.RefreshToken}); err == nil {
+		t.Fatal("重放已使用的刷新令牌没有被拒绝")
+	}
+	assertSessionValid(t, userID, sessionID, false)
+
+	// 同一会话中轮换出的新令牌也随会话失效
+	if _, err := RefreshToken(&model.RefreshTokenRequest{RefreshToken: second.RefreshToken}); err == nil {
+		t.Fatal("会话注销后轮换出的刷新令牌仍然可用")
+	}
+
+	// 其他设备的会话不受影响
+	assertSessionValid(t, userID, otherSessionID, true)
+	if _, err := RefreshToken(&model.RefreshTokenRequest{RefreshToken: other.RefreshToken}); err != nil {
+		t.Fatalf("其他会话的刷新令牌失败: %v", err)
+	}
+}
+
+func TestUpdateUserRoleRevokesSessions(t *testing.T) {
+	setupAuth(t)
+	adminID, _ := registerAndLogin(t, "admin")
+	userID, first := registerAndLogin(t, "carol")
+	second := login(t, "carol")
+
+	if err := UpdateUserRole(adminID, userID, &model.UpdateUserRoleRequest{Role: model.RoleOperator}); err != nil {
+		t.Fatalf("修改角色失败: %v", err)
+	}
+
+	for i, tokens := range []*model.LoginResponse{first, second} {
+		claims := claimsOf(t, tokens.Token)
+		assertSessionValid(t, userID, claims.SessionID, false)
+		if _, err := RefreshToken(&model.RefreshTokenRequest{RefreshToken: tokens.RefreshToken}); err == nil {
+			t.Fatalf("会话 %d 的刷新令牌在修改角色后仍然可用", i+1)
+		}
+	}
+
+	// 重新登录后的令牌携带新角色
+	if role := claimsOf(t, login(t, "carol").Token).Role; role != model.RoleOperator {
+		t.Fatalf("重新登录后角色为 %s，预期 %s", role, model.RoleOperator)
+	}
+}
+
+func TestRefreshTokenUnknown(t *testing.T) {
+	setupAuth(t)
+	for _, token := range []string{"", "not-a-token", fmt.Sprintf("%064x", 1)} {
+		if _, err := RefreshToken(&model.RefreshTokenRequest{RefreshToken: token}); err == nil {
+			t.Fatalf("未知的刷新令牌 %q 没有被拒绝", token)
+		}
+	}
+}
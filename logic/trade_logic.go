@@ -0,0 +1,353 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"shop/dao"
+	"shop/global/db"
+	"shop/model"
+)
+
+// maxTradeItemsPerSide 报价每一方最多包含的物品数
+const maxTradeItemsPerSide = 20
+
+var (
+	// ErrTradeNotPending 报价已被处理，不能再接受、拒绝或取消
+	ErrTradeNotPending = errors.New("报价已处理")
+	// ErrTradeItemsUnavailable 报价中的物品已转让或收回，报价被自动取消
+	ErrTradeItemsUnavailable = errors.New("报价中的物品已不可交换，报价已自动取消")
+)
+
+// SyncOwnedItems 将用户已完成订单中的订单项按件写入库存，盲盒按抽取结果记录款式
+func SyncOwnedItems(userID int) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		return syncOwnedItemsTx(tx, userID)
+	})
+}
+
+// syncOwnedItemsTx 在事务中同步库存
+func syncOwnedItemsTx(tx *gorm.DB, userID int) error {
+	orderItems, err := dao.GetUnsyncedOrderItemsForUpdate(tx, userID)
+	if err != nil {
+		return fmt.Errorf("查询订单项失败: %w", err)
+	}
+
+	var owned []model.OwnedItem
+	for _, oi := range orderItems {
+		figures := make(map[int]int, len(oi.Draws))
+		for _, d := range oi.Draws {
+			figures[d.Sequence] = d.FigureID
+		}
+		for unit := 0; unit < oi.Quantity; unit++ {
+			item := model.OwnedItem{
+				UserID:      userID,
+				ProductID:   oi.ProductID,
+				OrderID:     oi.OrderID,
+				OrderItemID: oi.ID,
+				UnitIndex:   unit,
+				Source:      model.OwnedItemSourcePurchase,
+			}
+			if figureID, ok := figures[unit]; ok {
+				item.FigureID = &figureID
+			}
+			owned = append(owned, item)
+		}
+	}
+	if err := dao.CreateOwnedItems(tx, owned); err != nil {
+		return fmt.Errorf("写入库存失败: %w", err)
+	}
+	return nil
+}
+
+// GetInventory 获取用户的库存物品（先同步已完成的订单）
+func GetInventory(userID int) ([]model.OwnedItem, error) {
+	if err := SyncOwnedItems(userID); err != nil {
+		return nil, err
+	}
+	return dao.GetOwnedItems(userID)
+}
+
+// SetItemListed 挂出或撤下自己库存中的物品
+func SetItemListed(userID, itemID int, listed bool) (*model.OwnedItem, error) {
+	item, err := dao.GetOwnedItemByID(itemID)
+	if err != nil {
+		return nil, fmt.Errorf("查询物品失败: %w", err)
+	}
+	if item == nil || item.UserID != userID {
+		return nil, fmt.Errorf("物品不存在")
+	}
+	if err := dao.UpdateOwnedItemListed(itemID, listed); err != nil {
+		return nil, fmt.Errorf("更新物品失败: %w", err)
+	}
+	item.Listed = listed
+	return item, nil
+}
+
+// GetTradeListings 分页获取其他用户挂出的物品
+func GetTradeListings(userID, productID int, series string, page, pageSize int) ([]model.OwnedItem, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return dao.GetListedItems(userID, productID, series, page, pageSize)
+}
+
+// uniqueItemIDs 校验物品ID列表非空、不重复且不超过上限
+func uniqueItemIDs(ids []int, name string) error {
+	if len(ids) == 0 {
+		return newValidationError("%s不能为空", name)
+	}
+	if len(ids) > maxTradeItemsPerSide {
+		return newValidationError("%s最多 %d 件", name, maxTradeItemsPerSide)
+	}
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return newValidationError("%s重复: %d", name, id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// CreateTradeOffer 用自己库存中的物品向另一个用户挂出的物品发起交换报价
+func CreateTradeOffer(userID int, req *model.CreateTradeOfferRequest) (*model.TradeOffer, error) {
+	if err := uniqueItemIDs(req.OfferedItemIDs, "给出的物品"); err != nil {
+		return nil, err
+	}
+	if err := uniqueItemIDs(req.RequestedItemIDs, "想要的物品"); err != nil {
+		return nil, err
+	}
+	if utf8.RuneCountInString(req.Message) > 255 {
+		return nil, newValidationError("留言不能超过255个字符")
+	}
+
+	offered, err := dao.GetOwnedItemsByIDs(req.OfferedItemIDs)
+	if err != nil {
+		return nil, fmt.Errorf("查询物品失败: %w", err)
+	}
+	if len(offered) != len(req.OfferedItemIDs) {
+		return nil, newValidationError("给出的物品不在你的库存中")
+	}
+	for _, item := range offered {
+		if item.UserID != userID {
+			return nil, newValidationError("给出的物品不在你的库存中")
+		}
+	}
+
+	requested, err := dao.GetOwnedItemsByIDs(req.RequestedItemIDs)
+	if err != nil {
+		return nil, fmt.Errorf("查询物品失败: %w", err)
+	}
+	if len(requested) != len(req.RequestedItemIDs) {
+		return nil, newValidationError("想要的物品不存在")
+	}
+	toUserID := requested[0].UserID
+	for _, item := range requested {
+		if !item.Listed {
+			return nil, newValidationError("物品 %d 未挂出交换", item.ID)
+		}
+		if item.UserID != toUserID {
+			return nil, newValidationError("想要的物品必须属于同一个用户")
+		}
+	}
+	if toUserID == userID {
+		return nil, newValidationError("不能和自己交换")
+	}
+
+	offer := &model.TradeOffer{
+		FromUserID: userID,
+		ToUserID:   toUserID,
+		Status:     model.TradeStatusPending,
+		Message:    req.Message,
+	}
+	items := make([]model.TradeOfferItem, 0, len(offered)+len(requested))
+	for _, id := range req.OfferedItemIDs {
+		items = append(items, model.TradeOfferItem{OwnedItemID: id, Side: model.TradeSideOffered})
+	}
+	for _, id := range req.RequestedItemIDs {
+		items = append(items, model.TradeOfferItem{OwnedItemID: id, Side: model.TradeSideRequested})
+	}
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := dao.CreateTradeOffer(tx, offer, items); err != nil {
+			return fmt.Errorf("创建报价失败: %w", err)
+		}
+		return dao.CreateTradeOfferEvent(tx, &model.TradeOfferEvent{
+			OfferID:  offer.ID,
+			ToStatus: model.TradeStatusPending,
+			ActorID:  userID,
+			Note:     "发起报价",
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dao.GetTradeOfferByID(offer.ID)
+}
+
+// GetTradeOffers 分页获取用户收到或发出的报价
+func GetTradeOffers(userID int, box, status string, page, pageSize int) ([]model.TradeOffer, int64, error) {
+	if box != "incoming" && box != "outgoing" {
+		return nil, 0, newValidationError("box 必须为 incoming 或 outgoing")
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return dao.GetTradeOffers(userID, box, status, page, pageSize)
+}
+
+// GetTradeOffer 获取报价详情，只有交易双方可以查看
+func GetTradeOffer(userID, offerID int) (*model.TradeOffer, error) {
+	offer, err := dao.GetTradeOfferByID(offerID)
+	if err != nil {
+		return nil, fmt.Errorf("查询报价失败: %w", err)
+	}
+	if offer == nil || (offer.FromUserID != userID && offer.ToUserID != userID) {
+		return nil, fmt.Errorf("报价不存在")
+	}
+	return offer, nil
+}
+
+// setTradeOfferStatusTx 在事务中变更报价状态并记录
+func setTradeOfferStatusTx(tx *gorm.DB, offer *model.TradeOffer, to string, actorID int, note string) error {
+	if err := dao.UpdateTradeOfferStatus(tx, offer.ID, to); err != nil {
+		return fmt.Errorf("更新报价失败: %w", err)
+	}
+	if err := dao.CreateTradeOfferEvent(tx, &model.TradeOfferEvent{
+		OfferID:    offer.ID,
+		FromStatus: offer.Status,
+		ToStatus:   to,
+		ActorID:    actorID,
+		Note:       note,
+	}); err != nil {
+		return fmt.Errorf("记录报价状态失败: %w", err)
+	}
+	offer.Status = to
+	return nil
+}
+
+// AcceptTradeOffer 接受报价，在一个事务中交换双方物品的归属
+// 锁定顺序为先报价后物品（按ID升序）；物品已转让或收回时报价自动取消
+func AcceptTradeOffer(userID, offerID int) (*model.TradeOffer, error) {
+	unavailable := false
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		offer, err := dao.GetTradeOfferForUpdate(tx, offerID)
+		if err != nil {
+			return fmt.Errorf("查询报价失败: %w", err)
+		}
+		if offer == nil || offer.ToUserID != userID {
+			return fmt.Errorf("报价不存在")
+		}
+		if offer.Status != model.TradeStatusPending {
+			return ErrTradeNotPending
+		}
+
+		ids := make([]int, 0, len(offer.Items))
+		sides := make(map[int]string, len(offer.Items))
+		for _, item := range offer.Items {
+			ids = append(ids, item.OwnedItemID)
+			sides[item.OwnedItemID] = item.Side
+		}
+		locked, err := dao.GetOwnedItemsForUpdate(tx, ids)
+		if err != nil {
+			return fmt.Errorf("锁定物品失败: %w", err)
+		}
+
+		// 发起方的物品必须仍属于发起方，想要的物品必须仍属于接受方
+		valid := len(locked) == len(ids)
+		var offeredIDs, requestedIDs []int
+		for _, item := range locked {
+			switch sides[item.ID] {
+			case model.TradeSideOffered:
+				valid = valid && item.UserID == offer.FromUserID
+				offeredIDs = append(offeredIDs, item.ID)
+			case model.TradeSideRequested:
+				valid = valid && item.UserID == offer.ToUserID
+				requestedIDs = append(requestedIDs, item.ID)
+			}
+		}
+		if !valid {
+			unavailable = true
+			return setTradeOfferStatusTx(tx, offer, model.TradeStatusCancelled, 0, "物品已转让或收回")
+		}
+
+		if err := dao.TransferOwnedItems(tx, offeredIDs, offer.ToUserID); err != nil {
+			return fmt.Errorf("转移物品失败: %w", err)
+		}
+		if err := dao.TransferOwnedItems(tx, requestedIDs, offer.FromUserID); err != nil {
+			return fmt.Errorf("转移物品失败: %w", err)
+		}
+		return setTradeOfferStatusTx(tx, offer, model.TradeStatusAccepted, userID, "接受报价")
+	})
+	if err != nil {
+		return nil, err
+	}
+	if unavailable {
+		return nil, ErrTradeItemsUnavailable
+	}
+	return dao.GetTradeOfferByID(offerID)
+}
+
+// closeTradeOffer 拒绝（接收方）或取消（发起方）待处理的报价
+func closeTradeOffer(userID, offerID int, to, note string) (*model.TradeOffer, error) {
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		offer, err := dao.GetTradeOfferForUpdate(tx, offerID)
+		if err != nil {
+			return fmt.Errorf("查询报价失败: %w", err)
+		}
+		if offer == nil {
+			return fmt.Errorf("报价不存在")
+		}
+		if (to == model.TradeStatusRejected && offer.ToUserID != userID) ||
+			(to == model.TradeStatusCancelled && offer.FromUserID != userID) {
+			return fmt.Errorf("报价不存在")
+		}
+		if offer.Status != model.TradeStatusPending {
+			return ErrTradeNotPending
+		}
+		return setTradeOfferStatusTx(tx, offer, to, userID, note)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dao.GetTradeOfferByID(offerID)
+}
+
+// RejectTradeOffer 接收方拒绝报价
+func RejectTradeOffer(userID, offerID int) (*model.TradeOffer, error) {
+	return closeTradeOffer(userID, offerID, model.TradeStatusRejected, "拒绝报价")
+}
+
+// CancelTradeOffer 发起方取消报价
+func CancelTradeOffer(userID, offerID int) (*model.TradeOffer, error) {
+	return closeTradeOffer(userID, offerID, model.TradeStatusCancelled, "取消报价")
+}
+
+// revokeOwnedItemsTx 订单退款时收回已进入库存的物品，物品已交易给其他用户时不能退款
+func revokeOwnedItemsTx(tx *gorm.DB, order *model.Order) error {
+	items, err := dao.GetOwnedItemsByOrderForUpdate(tx, order.ID)
+	if err != nil {
+		return fmt.Errorf("查询库存物品失败: %w", err)
+	}
+	for _, item := range items {
+		if item.UserID != order.UserID {
+			return newValidationError("订单中的物品已交易给其他用户，不能退款")
+		}
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	if err := dao.DeleteOwnedItemsByOrder(tx, order.ID); err != nil {
+		return fmt.Errorf("收回库存物品失败: %w", err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package logic
 
 import (
 	"fmt"
+	"log"
 
 	"shop/dao"
 	"shop/model"
@@ -35,8 +36,8 @@ func Register(req *model.RegisterRequest) (int64, error) {
 	return userID, nil
 }
 
-// Login 用户登录
-func Login(req *model.LoginRequest) (*model.LoginResponse, error) {
+// Login 用户登录，为本次登录创建会话
+func Login(req *model.LoginRequest, meta *model.SessionMeta) (*model.LoginResponse, error) {
 	// 查询用户
 	user, passwordHash, err := dao.GetUserByUsername(req.Username)
 	if err != nil {
@@ -52,11 +53,67 @@ func Login(req *model.LoginRequest) (*model.LoginResponse, error) {
 		return nil, fmt.Errorf("用户名或密码错误")
 	}
 
-	// 生成token（简化版，实际应使用JWT）
-	token := fmt.Sprintf("user_%d", user.ID)
+	// 创建会话并签发访问令牌和刷新令牌
+	sessionID, err := createSession(user.ID, meta)
+	if err != nil {
+		return nil, fmt.Errorf("创建会话失败: %w", err)
+	}
+	tokens, err := issueTokenPair(user, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("生成token失败: %w", err)
+	}
 
 	return &model.LoginResponse{
-		Token: token,
-		User:  *user,
+		TokenResponse: *tokens,
+		User:          *user,
+		CartMerge:     MergeGuestCartByToken(user.ID, req.CartToken),
 	}, nil
 }
+
+// GetUsers 分页获取用户列表（管理后台）
+func GetUsers(page, pageSize int) ([]model.User, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return dao.GetUsers(page, pageSize)
+}
+
+// UpdateUserRole 修改用户角色，并注销该用户的所有会话使新角色立即生效
+func UpdateUserRole(operatorID, userID int, req *model.UpdateUserRoleRequest) error {
+	if !model.IsValidRole(req.Role) {
+		return fmt.Errorf("无效的角色")
+	}
+	if operatorID == userID {
+		return fmt.Errorf("不能修改自己的角色")
+	}
+
+	user, err := dao.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user == nil {
+		return fmt.Errorf("用户不存在")
+	}
+
+	if err := dao.UpdateUserRole(userID, req.Role); err != nil {
+		return fmt.Errorf("修改角色失败: %w", err)
+	}
+	return dao.DeleteAllSessions(userID)
+}
+
+// BootstrapAdmins 将配置中指定的用户提升为管理员，用于初始化第一个管理员账号
+func BootstrapAdmins(usernames []string) {
+	for _, username := range usernames {
+		affected, err := dao.UpdateUserRoleByUsername(username, model.RoleAdmin)
+		if err != nil {
+			log.Printf("Warning: Failed to promote %s to admin: %v", username, err)
+			continue
+		}
+		if affected > 0 {
+			log.Printf("Promoted %s to admin", username)
+		}
+	}
+}
@@ -1,51 +1,93 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
 
+	"shop/config"
+	"shop/global/auth"
 	"shop/global/db"
+	"shop/global/payment"
 	"shop/global/redis"
+	"shop/logic"
 	"shop/routers"
 
 	"github.com/cloudwego/hertz/pkg/app/server"
 )
 
-func main() {
-	// 硬编码配置信息
-	databaseHost := "47.118.19.28"
-	databasePort := 3307
-	databaseUser := "root"
-	databasePassword := "sta_go"
-	databaseName := "durlim"
-	databaseCharset := "utf8mb4"
+var (
+	// configPath 配置文件路径
+	configPath = flag.String("config", "", "path to config file (default: $CONFIG_PATH or config.yaml)")
+	// devResetDB 开发环境专用：启动时删除所有表并重新执行迁移
+	devResetDB = flag.Bool("dev-reset-db", false, "DEV ONLY: drop all tables and re-run migrations on startup (destroys all data)")
+)
 
-	redisAddr := "47.118.19.28:6379"
-	redisPassword := "sta_go"
-	redisDB := 0
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage:\n  %s [flags]                     start the server\n  %s migrate up|down [n]|status  manage database migrations\n\nFlags:\n", os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
 
-	serverHost := "0.0.0.0"
-	serverPort := 8080
+	// 加载配置：--config 优先，其次 CONFIG_PATH 环境变量，默认 config.yaml
+	path := *configPath
+	if path == "" {
+		path = os.Getenv("CONFIG_PATH")
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
 
-	log.Printf("Database: %s@%s:%d/%s", databaseUser, databaseHost, databasePort, databaseName)
-	log.Printf("Redis: %s", redisAddr)
+	log.Printf("Database: %s@%s:%d/%s", cfg.Database.User, cfg.Database.Host, cfg.Database.Port, cfg.Database.Database)
+	log.Printf("Redis: %s", cfg.Redis.Addr)
 
 	// 初始化数据库
-	dsn := getDSN(databaseUser, databasePassword, databaseHost, databasePort, databaseName, databaseCharset)
-	if err := db.InitDB(dsn); err != nil {
+	if err := db.InitDB(cfg.Database.GetDSN()); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer db.CloseDB()
 
+	// 子命令
+	if args := flag.Args(); len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			if err := runMigrate(args[1:]); err != nil {
+				log.Fatalf("Migrate failed: %v", err)
+			}
+		default:
+			flag.Usage()
+			os.Exit(2)
+		}
+		return
+	}
+
+	// 初始化令牌签名
+	if err := auth.InitAuth(cfg.Auth); err != nil {
+		log.Fatalf("Failed to initialize auth: %v", err)
+	}
+
+	// 初始化支付渠道
+	payment.InitPayment(cfg.Payment)
+
 	// 初始化Redis
-	if err := redis.InitRedis(redisAddr, redisPassword, redisDB); err != nil {
+	if err := redis.InitRedis(cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB); err != nil {
 		log.Fatalf("Failed to initialize redis: %v", err)
 	}
 	defer redis.CloseRedis()
 
-	// 创建表
-	if err := db.CreateTables(); err != nil {
-		log.Fatalf("Failed to create tables: %v", err)
+	// 执行数据库迁移（开发环境可通过 --dev-reset-db 清空重建）
+	if *devResetDB {
+		log.Printf("WARNING: --dev-reset-db is set, dropping all tables")
+		if err := db.ResetDatabase(); err != nil {
+			log.Fatalf("Failed to reset database: %v", err)
+		}
+	} else if _, err := db.MigrateUp(); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
 	// 初始化商品数据
@@ -53,24 +95,73 @@ func main() {
 		log.Printf("Warning: Failed to seed products: %v", err)
 	}
 
+	// 初始化管理员账号
+	logic.BootstrapAdmins(cfg.Auth.BootstrapAdmins)
+
+	// 迁移旧版购物车Redis键
+	logic.MigrateLegacyCarts()
+
 	// 创建Hertz服务器
-	serverAddr := getServerAddr(serverHost, serverPort)
+	serverAddr := cfg.Server.GetAddr()
 	log.Printf("Server starting on %s", serverAddr)
 	h := server.Default(server.WithHostPorts(serverAddr))
 
 	// 初始化路由
 	routers.InitRouter(h)
 
+	// 后台任务，随服务关闭一起退出
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	h.OnShutdown = append(h.OnShutdown, func(ctx context.Context) {
+		stopWorkers()
+	})
+	go logic.RunOrderExpiryWorker(workerCtx, cfg.Order.ExpiryPollInterval)
+	go logic.RunFlashSaleWorker(workerCtx, cfg.Order.FlashSaleInterval)
+	go logic.RunRaffleWorker(workerCtx, cfg.Order.RaffleInterval)
+
 	h.Spin()
 }
 
-// getDSN 生成数据库连接字符串
-func getDSN(user, password, host string, port int, database, charset string) string {
-	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local",
-		user, password, host, port, database, charset)
-}
+// runMigrate 执行 migrate 子命令
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate command, expected up|down|status")
+	}
 
-// getServerAddr 生成服务器地址
-func getServerAddr(host string, port int) string {
-	return fmt.Sprintf("%s:%d", host, port)
+	switch args[0] {
+	case "up":
+		count, err := db.MigrateUp()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migration(s)\n", count)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid step count: %s", args[1])
+			}
+			steps = n
+		}
+		count, err := db.MigrateDown(steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Reverted %d migration(s)\n", count)
+	case "status":
+		statuses, err := db.GetMigrationStatus()
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.Applied {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-40s  %s\n", s.Version, s.Name, appliedAt)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up|down|status", args[0])
+	}
+	return nil
 }
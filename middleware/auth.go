@@ -2,9 +2,12 @@ package middleware
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"strings"
 
+	"shop/global/auth"
+	"shop/logic"
+
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/common/utils"
 )
@@ -21,7 +24,6 @@ func AuthMiddleware() app.HandlerFunc {
 			return
 		}
 
-		// 简单的token验证（实际项目中应使用JWT）
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
 			c.JSON(401, utils.H{
@@ -31,33 +33,41 @@ func AuthMiddleware() app.HandlerFunc {
 			return
 		}
 
-		token := parts[1]
-		// 从token中提取用户ID（简化版，实际应验证JWT）
-		userID := extractUserIDFromToken(token)
-		if userID == 0 {
+		// 验证签名、有效期、签发者和受众
+		claims, err := auth.ParseAccessToken(parts[1])
+		if err != nil {
+			message := "无效的token"
+			if errors.Is(err, auth.ErrTokenExpired) {
+				message = "token已过期"
+			}
 			c.JSON(401, utils.H{
-				"error": "无效的token",
+				"error": message,
 			})
 			c.Abort()
 			return
 		}
 
-		// 将用户ID存储到上下文中
-		c.Set("user_id", userID)
-		c.Next(ctx)
-	}
-}
-
-// extractUserIDFromToken 从token中提取用户ID（简化实现）
-func extractUserIDFromToken(token string) int {
-	// 这里简化处理，实际应该验证JWT token
-	// 假设token格式为 "user_{id}"
-	if strings.HasPrefix(token, "user_") {
-		var userID int
-		_, err := fmt.Sscanf(token, "user_%d", &userID)
-		if err == nil {
-			return userID
+		// 会话被注销后，该会话签发的令牌立即失效
+		valid, err := logic.ValidateSession(claims.UserID, claims.SessionID)
+		if err != nil {
+			c.JSON(500, utils.H{
+				"error": "校验会话失败: " + err.Error(),
+			})
+			c.Abort()
+			return
 		}
+		if !valid {
+			c.JSON(401, utils.H{
+				"error": "会话已失效，请重新登录",
+			})
+			c.Abort()
+			return
+		}
+
+		// 将用户ID、角色和会话ID存储到上下文中
+		c.Set("user_id", claims.UserID)
+		c.Set("role", claims.Role)
+		c.Set("session_id", claims.SessionID)
+		c.Next(ctx)
 	}
-	return 0
 }
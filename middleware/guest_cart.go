@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"log"
+
+	"shop/dao"
+	"shop/global/auth"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/cloudwego/hertz/pkg/protocol"
+)
+
+const (
+	// GuestCartTokenHeader 游客购物车令牌请求头，新签发的令牌也通过同名响应头返回
+	GuestCartTokenHeader = "X-Cart-Token"
+	// GuestCartCookie 游客购物车令牌Cookie
+	GuestCartCookie = "cart_token"
+)
+
+// readGuestCartToken 从请求头或Cookie中读取游客购物车令牌，请求头优先
+func readGuestCartToken(c *app.RequestContext) string {
+	if token := string(c.GetHeader(GuestCartTokenHeader)); token != "" {
+		return token
+	}
+	return string(c.Cookie(GuestCartCookie))
+}
+
+// setGuestCartCookie 设置游客购物车Cookie
+func setGuestCartCookie(c *app.RequestContext, token string, maxAge int) {
+	secure := string(c.URI().Scheme()) == "https" || string(c.GetHeader("X-Forwarded-Proto")) == "https"
+	c.SetCookie(GuestCartCookie, token, maxAge, "/api", "", protocol.CookieSameSiteLaxMode, secure, true)
+}
+
+// GuestCart 游客购物车中间件：校验签名令牌并把购物车ID存入上下文；
+// 没有令牌或令牌无效时签发新令牌，通过 X-Cart-Token 响应头和 cart_token Cookie 返回
+func GuestCart() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		cartID, err := auth.ParseGuestCartToken(readGuestCartToken(c))
+		if err != nil {
+			var token string
+			token, cartID, err = auth.NewGuestCartToken()
+			if err != nil {
+				log.Printf("Warning: Failed to issue guest cart token: %v", err)
+				c.JSON(500, utils.H{
+					"error": "生成购物车令牌失败",
+				})
+				c.Abort()
+				return
+			}
+			c.Header(GuestCartTokenHeader, token)
+			setGuestCartCookie(c, token, int(dao.CartExpireTime.Seconds()))
+		}
+
+		c.Set("guest_cart_id", cartID)
+		c.Next(ctx)
+	}
+}
+
+// WithGuestCartToken 把请求携带的游客购物车令牌（未校验）存入上下文，登录时用于合并游客购物车
+func WithGuestCartToken() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		c.Set("guest_cart_token", readGuestCartToken(c))
+		c.Next(ctx)
+	}
+}
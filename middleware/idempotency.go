@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"shop/logic"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+const (
+	// IdempotencyKeyHeader 幂等键请求头
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader 响应为重放时设置的响应头
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// Idempotency 幂等中间件，需放在 AuthMiddleware 之后
+// 带 Idempotency-Key 的写请求首次响应会被保存，相同键和请求体的重试直接重放该响应
+func Idempotency() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		key := string(c.GetHeader(IdempotencyKeyHeader))
+		method := string(c.Method())
+		if key == "" || method == consts.MethodGet || method == consts.MethodHead || method == consts.MethodOptions {
+			c.Next(ctx)
+			return
+		}
+
+		userID := c.GetInt("user_id")
+		fingerprint := logic.RequestFingerprint(method, string(c.Request.URI().RequestURI()), c.Request.Body())
+		record, err := logic.BeginIdempotentRequest(userID, key, fingerprint)
+		if err != nil {
+			var validationErr *logic.ValidationError
+			switch {
+			case errors.As(err, &validationErr):
+				c.JSON(400, utils.H{
+					"error": err.Error(),
+				})
+			case errors.Is(err, logic.ErrIdempotencyKeyReused):
+				c.JSON(409, utils.H{
+					"error": err.Error(),
+					"code":  "idempotency_key_reused",
+				})
+			case errors.Is(err, logic.ErrIdempotencyInProgress):
+				c.JSON(409, utils.H{
+					"error": err.Error(),
+					"code":  "idempotency_in_progress",
+				})
+			default:
+				c.JSON(500, utils.H{
+					"error": "处理幂等键失败: " + err.Error(),
+				})
+			}
+			c.Abort()
+			return
+		}
+
+		// 重放首次响应
+		if record != nil {
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(record.StatusCode, record.ContentType, record.Body)
+			c.Abort()
+			return
+		}
+
+		c.Next(ctx)
+		logic.CompleteIdempotentRequest(userID, key, fingerprint,
+			c.Response.StatusCode(), string(c.Response.Header.ContentType()), c.Response.Body())
+	}
+}
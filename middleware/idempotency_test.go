@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+
+	"shop/global/redis/redistest"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/hertz/pkg/route"
+)
+
+// newIdempotencyEngine 创建挂载幂等中间件的测试路由，handler 前固定设置 user_id
+func newIdempotencyEngine(t *testing.T, handler app.HandlerFunc) *route.Engine {
+	t.Helper()
+	redistest.Open(t)
+	engine := route.NewEngine(config.NewOptions([]config.Option{}))
+	engine.POST("/orders", func(ctx context.Context, c *app.RequestContext) {
+		c.Set("user_id", 1)
+		c.Next(ctx)
+	}, Idempotency(), handler)
+	return engine
+}
+
+func postOrder(engine *route.Engine, key, body string) *protocol.Response {
+	w := ut.PerformRequest(engine, "POST", "/orders", &ut.Body{Body: bytes.NewBufferString(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/json"},
+		ut.Header{Key: IdempotencyKeyHeader, Value: key})
+	return w.Result()
+}
+
+// countingHandler 每次调用返回递增的序号
+func countingHandler(calls *atomic.Int64) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		c.JSON(201, utils.H{"order_id": calls.Add(1)})
+	}
+}
+
+func TestIdempotencyReplaysStoredResponse(t *testing.T) {
+	var calls atomic.Int64
+	engine := newIdempotencyEngine(t, countingHandler(&calls))
+
+	first := postOrder(engine, "key-1", `{"product_id":1,"quantity":2}`)
+	if first.StatusCode() != 201 {
+		t.Fatalf("首次请求状态码为 %d，预期 201", first.StatusCode())
+	}
+
+	// 只是空白和字段顺序不同的 JSON 请求体视为同一请求
+	for _, body := range []string{`{"product_id":1,"quantity":2}`, "{ \"quantity\": 2,\n  \"product_id\": 1 }"} {
+		replay := postOrder(engine, "key-1", body)
+		if replay.StatusCode() != 201 || !bytes.Equal(replay.Body(), first.Body()) {
+			t.Fatalf("重放响应为 %d %s，预期 %d %s", replay.StatusCode(), replay.Body(), first.StatusCode(), first.Body())
+		}
+		if got := replay.Header.Get(IdempotentReplayedHeader); got != "true" {
+			t.Fatalf("重放响应头 %s = %q，预期 true", IdempotentReplayedHeader, got)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("handler 执行了 %d 次，预期 1 次", n)
+	}
+
+	// 不同的键是独立的请求
+	if resp := postOrder(engine, "key-2", `{"product_id":1,"quantity":2}`); resp.Header.Get(IdempotentReplayedHeader) != "" {
+		t.Fatal("不同的键返回了重放响应")
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("handler 执行了 %d 次，预期 2 次", n)
+	}
+}
+
+func TestIdempotencyKeyReusedWithDifferentBody(t *testing.T) {
+	var calls atomic.Int64
+	engine := newIdempotencyEngine(t, countingHandler(&calls))
+
+	postOrder(engine, "key-1", `{"product_id":1,"quantity":2}`)
+	resp := postOrder(engine, "key-1", `{"product_id":1,"quantity":3}`)
+	if resp.StatusCode() != 409 {
+		t.Fatalf("状态码为 %d，预期 409", resp.StatusCode())
+	}
+	assertErrorCode(t, resp, "idempotency_key_reused")
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("handler 执行了 %d 次，预期 1 次", n)
+	}
+}
+
+func TestIdempotencyConcurrentRequestInProgress(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	engine := newIdempotencyEngine(t, func(ctx context.Context, c *app.RequestContext) {
+		close(entered)
+		<-release
+		c.JSON(201, utils.H{"order_id": 1})
+	})
+
+	done := make(chan *protocol.Response)
+	go func() {
+		done <- postOrder(engine, "key-1", `{"product_id":1}`)
+	}()
+	<-entered
+
+	resp := postOrder(engine, "key-1", `{"product_id":1}`)
+	if resp.StatusCode() != 409 {
+		t.Fatalf("状态码为 %d，预期 409", resp.StatusCode())
+	}
+	assertErrorCode(t, resp, "idempotency_in_progress")
+
+	close(release)
+	if first := <-done; first.StatusCode() != 201 {
+		t.Fatalf("首次请求状态码为 %d，预期 201", first.StatusCode())
+	}
+	if replay := postOrder(engine, "key-1", `{"product_id":1}`); replay.Header.Get(IdempotentReplayedHeader) != "true" {
+		t.Fatal("首次请求完成后的重试没有重放响应")
+	}
+}
+
+func TestIdempotencyServerErrorReleasesKey(t *testing.T) {
+	var calls atomic.Int64
+	engine := newIdempotencyEngine(t, func(ctx context.Context, c *app.RequestContext) {
+		if calls.Add(1) == 1 {
+			c.JSON(500, utils.H{"error": "数据库不可用"})
+			return
+		}
+		c.JSON(201, utils.H{"order_id": 1})
+	})
+
+	if resp := postOrder(engine, "key-1", `{"product_id":1}`); resp.StatusCode() != 500 {
+		t.Fatalf("首次请求状态码为 %d，预期 500", resp.StatusCode())
+	}
+	resp := postOrder(engine, "key-1", `{"product_id":1}`)
+	if resp.StatusCode() != 201 || resp.Header.Get(IdempotentReplayedHeader) != "" {
+		t.Fatalf("5xx 后重试返回 %d（重放: %q），预期重新执行并返回 201", resp.StatusCode(), resp.Header.Get(IdempotentReplayedHeader))
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("handler 执行了 %d 次，预期 2 次", n)
+	}
+}
+
+func assertErrorCode(t *testing.T, resp *protocol.Response, want string) {
+	t.Helper()
+	var body struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(resp.Body(), &body); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if body.Code != want {
+		t.Fatalf("错误码为 %q，预期 %q", body.Code, want)
+	}
+}
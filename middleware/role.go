@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+)
+
+// RequireRole 角色校验中间件，需放在 AuthMiddleware 之后
+func RequireRole(roles ...string) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next(ctx)
+				return
+			}
+		}
+
+		c.JSON(403, utils.H{
+			"error": "权限不足",
+		})
+		c.Abort()
+	}
+}
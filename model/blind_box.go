@@ -0,0 +1,102 @@
+package model
+
+import "time"
+
+// BlindBoxSeries 盲盒系列，绑定一个盲盒商品（购买的是盒子，支付后抽取具体款式）
+type BlindBoxSeries struct {
+	ID          int              `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	ProductID   int              `json:"product_id" gorm:"type:int;not null;uniqueIndex:idx_product_id"`
+	Name        string           `json:"name" gorm:"type:varchar(100);not null"`
+	Description string           `json:"description" gorm:"type:text"`
+	Figures     []BlindBoxFigure `json:"figures,omitempty" gorm:"foreignKey:SeriesID"`
+	CreatedAt   time.Time        `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time        `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (BlindBoxSeries) TableName() string {
+	return "blind_box_series"
+}
+
+// BlindBoxFigure 盲盒款式，抽中概率为 权重 / 有库存款式的权重之和
+type BlindBoxFigure struct {
+	ID        int       `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	SeriesID  int       `json:"series_id" gorm:"type:int;not null;index:idx_series_id"`
+	Name      string    `json:"name" gorm:"type:varchar(100);not null"`
+	Image     string    `json:"image" gorm:"type:varchar(500)"`
+	Weight    int       `json:"weight" gorm:"type:int;not null"`
+	Stock     int       `json:"stock" gorm:"type:int;not null;default:0"`
+	IsHidden  bool      `json:"is_hidden" gorm:"not null;default:false"` // 隐藏款，公开款式列表中不展示名称和图片
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (BlindBoxFigure) TableName() string {
+	return "blind_box_figures"
+}
+
+// BlindBoxDraw 抽取记录，每个盒子一条
+// 可用订单项的 draw_seed 重放随机数序列，结合 pool 校验抽取结果
+type BlindBoxDraw struct {
+	ID          int            `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	OrderID     int            `json:"order_id" gorm:"type:int;not null;index:idx_order_id"`
+	OrderItemID int            `json:"order_item_id" gorm:"type:int;not null;index:idx_order_item_id"`
+	UserID      int            `json:"user_id" gorm:"type:int;not null;index:idx_user_id"`
+	SeriesID    int            `json:"series_id" gorm:"type:int;not null"`
+	FigureID    int            `json:"figure_id" gorm:"type:int;not null"`
+	Sequence    int            `json:"sequence" gorm:"type:int;not null"`     // 订单项中的第几个盒子，从0开始
+	Roll        int            `json:"roll" gorm:"type:int;not null"`         // 随机数，范围 [0, total_weight)
+	TotalWeight int            `json:"total_weight" gorm:"type:int;not null"` // 抽取时奖池总权重
+	Pool        string         `json:"pool" gorm:"type:text;not null"`        // 抽取时奖池快照，格式 款式ID:权重,...（按款式ID升序）
+	Figure      BlindBoxFigure `json:"figure" gorm:"foreignKey:FigureID"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName 指定表名
+func (BlindBoxDraw) TableName() string {
+	return "blind_box_draws"
+}
+
+// BlindBoxFigureView 公开的款式信息
+type BlindBoxFigureView struct {
+	ID          int     `json:"id"`
+	Name        string  `json:"name"`
+	Image       string  `json:"image"`
+	IsHidden    bool    `json:"is_hidden"`
+	Probability float64 `json:"probability"` // 当前抽中概率，售罄的款式为0
+	SoldOut     bool    `json:"sold_out"`
+}
+
+// BlindBoxSeriesView 公开的盲盒系列信息
+type BlindBoxSeriesView struct {
+	ID          int                  `json:"id"`
+	ProductID   int                  `json:"product_id"`
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Figures     []BlindBoxFigureView `json:"figures"`
+}
+
+// CreateBlindBoxSeriesRequest 创建盲盒系列请求
+type CreateBlindBoxSeriesRequest struct {
+	ProductID   int    `json:"product_id" binding:"required"`
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+// CreateBlindBoxFigureRequest 添加款式请求
+type CreateBlindBoxFigureRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Image    string `json:"image"`
+	Weight   int    `json:"weight" binding:"required"`
+	Stock    int    `json:"stock"`
+	IsHidden bool   `json:"is_hidden"`
+}
+
+// UpdateBlindBoxFigureRequest 更新款式请求（只更新传入的字段，库存通过库存调整接口修改）
+type UpdateBlindBoxFigureRequest struct {
+	Name     *string `json:"name"`
+	Image    *string `json:"image"`
+	Weight   *int    `json:"weight"`
+	IsHidden *bool   `json:"is_hidden"`
+}
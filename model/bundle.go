@@ -0,0 +1,92 @@
+package model
+
+import (
+	"time"
+)
+
+// Bundle 套装，以一个套装价购买一组商品（如某个系列的全部商品）
+type Bundle struct {
+	ID          int          `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	Name        string       `json:"name" gorm:"type:varchar(200);not null"`
+	Series      string       `json:"series" gorm:"type:varchar(50);index:idx_series"`
+	Description string       `json:"description" gorm:"type:text"`
+	Price       Money        `json:"price" gorm:"type:bigint;not null"`   // 套装价
+	Active      bool         `json:"active" gorm:"not null;default:true"` // 下架后不能加入购物车和下单
+	Items       []BundleItem `json:"items" gorm:"foreignKey:BundleID"`
+	ListPrice   Money        `json:"list_price" gorm:"-"` // 成员按原价计算的总价，查询时计算
+	CreatedAt   time.Time    `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time    `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (Bundle) TableName() string {
+	return "bundles"
+}
+
+// FillListPrice 根据已加载的成员商品计算原价总价
+func (b *Bundle) FillListPrice() error {
+	var total Money
+	for _, item := range b.Items {
+		amount, err := item.Product.Price.Mul(int64(item.Quantity))
+		if err != nil {
+			return err
+		}
+		total = total.Add(amount)
+	}
+	b.ListPrice = total
+	return nil
+}
+
+// BundleItem 套装成员
+type BundleItem struct {
+	ID        int     `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	BundleID  int     `json:"bundle_id" gorm:"type:int;not null;index:idx_bundle_id"`
+	ProductID int     `json:"product_id" gorm:"type:int;not null"`
+	Quantity  int     `json:"quantity" gorm:"type:int;not null"`
+	Product   Product `json:"product" gorm:"foreignKey:ProductID"`
+}
+
+// TableName 指定表名
+func (BundleItem) TableName() string {
+	return "bundle_items"
+}
+
+// CartBundle 购物车中的套装行（整个套装占一行）
+type CartBundle struct {
+	UserID   int    `json:"user_id"`
+	BundleID int    `json:"bundle_id"`
+	Quantity int    `json:"quantity"`
+	Bundle   Bundle `json:"bundle"`
+	// AddedPrice 加入购物车时的套装价，旧版本加入的套装行为空
+	AddedPrice *Money `json:"added_price,omitempty"`
+}
+
+// BundleItemRequest 套装成员请求
+type BundleItemRequest struct {
+	ProductID int `json:"product_id"`
+	Quantity  int `json:"quantity"`
+}
+
+// CreateBundleRequest 创建套装请求，items 为空时使用 series 下的全部商品（各1件）
+type CreateBundleRequest struct {
+	Name        string              `json:"name" binding:"required"`
+	Series      string              `json:"series"`
+	Description string              `json:"description"`
+	Price       Money               `json:"price" binding:"required"`
+	Items       []BundleItemRequest `json:"items"`
+}
+
+// UpdateBundleRequest 更新套装请求（只更新传入的字段，items 传入时整体替换）
+type UpdateBundleRequest struct {
+	Name        *string             `json:"name"`
+	Description *string             `json:"description"`
+	Price       *Money              `json:"price"`
+	Active      *bool               `json:"active"`
+	Items       []BundleItemRequest `json:"items"`
+}
+
+// AddBundleToCartRequest 添加套装到购物车请求
+type AddBundleToCartRequest struct {
+	BundleID int `json:"bundle_id" binding:"required"`
+	Quantity int `json:"quantity" binding:"required,min=1"`
+}
@@ -11,6 +11,8 @@ type CartItem struct {
 	Product   Product   `json:"product" gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	// AddedPrice 加入购物车时的单价（保存在Redis购物车中），旧版本加入的购物车项为空
+	AddedPrice *Money `json:"added_price,omitempty" gorm:"-"`
 }
 
 // TableName 指定表名
@@ -33,3 +35,73 @@ type UpdateCartRequest struct {
 type IncrementCartRequest struct {
 	Delta int `json:"delta" binding:"required"` // 增量值，+1 或 -1
 }
+
+// 游客购物车合并时数量被调整的原因
+const (
+	CartMergeUnavailable   = "unavailable"    // 商品已下架
+	CartMergeOutOfStock    = "out_of_stock"   // 合并后数量超过库存
+	CartMergePurchaseLimit = "purchase_limit" // 合并后数量超过限购
+)
+
+// CartMergeAdjustment 游客购物车合并时未能全部加入的商品
+type CartMergeAdjustment struct {
+	ProductID int    `json:"product_id"`
+	Requested int    `json:"requested"` // 游客购物车中的数量
+	Added     int    `json:"added"`     // 实际加入用户购物车的数量
+	Reason    string `json:"reason"`
+}
+
+// CartMergeResult 游客购物车合并结果
+type CartMergeResult struct {
+	Merged   int                   `json:"merged"`   // 全部或部分加入用户购物车的商品数
+	Adjusted []CartMergeAdjustment `json:"adjusted"` // 数量被调整或未加入的商品
+}
+
+// 购物车结算预览中商品行和套装行的提示
+const (
+	CartLineOutOfStock      = "out_of_stock"     // 已售罄，不能结算
+	CartLineQuantityReduced = "quantity_reduced" // 库存或限购不足，可结算数量少于购物车中的数量
+	CartLinePriceChanged    = "price_changed"    // 当前价格与加入购物车时不同
+	CartLineUnavailable     = "unavailable"      // 套装已下架或套装中的商品已下架
+)
+
+// CartSummaryItem 购物车结算预览中的商品行
+type CartSummaryItem struct {
+	ProductID         int      `json:"product_id"`
+	Name              string   `json:"name"`
+	Image             string   `json:"image"`
+	Quantity          int      `json:"quantity"`              // 购物车中的数量
+	AvailableQuantity int      `json:"available_quantity"`    // 当前可结算的数量
+	UnitPrice         Money    `json:"unit_price"`            // 当前单价
+	AddedPrice        *Money   `json:"added_price,omitempty"` // 加入购物车时的单价
+	LineTotal         Money    `json:"line_total"`            // 当前单价 × 可结算数量
+	Warnings          []string `json:"warnings"`
+}
+
+// CartSummaryBundle 购物车结算预览中的套装行
+type CartSummaryBundle struct {
+	BundleID          int      `json:"bundle_id"`
+	Name              string   `json:"name"`
+	Quantity          int      `json:"quantity"`
+	AvailableQuantity int      `json:"available_quantity"`
+	UnitPrice         Money    `json:"unit_price"`            // 当前套装价
+	ListPrice         Money    `json:"list_price"`            // 成员按原价计算的单套总价
+	AddedPrice        *Money   `json:"added_price,omitempty"` // 加入购物车时的套装价
+	LineTotal         Money    `json:"line_total"`            // 当前套装价 × 可结算数量
+	Discount          Money    `json:"discount"`              // (原价 - 套装价) × 可结算数量
+	Warnings          []string `json:"warnings"`
+}
+
+// CartSummary 购物车结算预览，金额按当前价格和可结算数量计算
+type CartSummary struct {
+	Items       []CartSummaryItem   `json:"items"`
+	Bundles     []CartSummaryBundle `json:"bundles"`
+	Subtotal    Money               `json:"subtotal"`     // 按原价计算的商品总价（套装按成员原价）
+	Discount    Money               `json:"discount"`     // 优惠金额（套装优惠 + 促销和优惠码）
+	Shipping    Money               `json:"shipping"`     // 运费
+	Total       Money               `json:"total"`        // 应付总额 = 商品总价 - 优惠 + 运费
+	CanCheckout bool                `json:"can_checkout"` // 所有行都能按购物车中的数量结算时为 true
+	Promotions  []AppliedPromotion  `json:"promotions"`   // 已应用的促销和优惠码
+	CouponCode  string              `json:"coupon_code,omitempty"`
+	CouponError string              `json:"coupon_error,omitempty"` // 优惠码不可用的原因，此时不计入优惠
+}
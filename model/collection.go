@@ -0,0 +1,28 @@
+package model
+
+// CollectionProduct 收藏进度中的商品
+type CollectionProduct struct {
+	ProductID int    `json:"product_id"`
+	Name      string `json:"name"`
+	Image     string `json:"image"`
+	Price     Money  `json:"price"`
+	Quantity  int    `json:"quantity"`  // 已购买件数，缺少的商品为0
+	Duplicate bool   `json:"duplicate"` // 购买超过1件
+	Removed   bool   `json:"removed"`   // 商品已下架
+}
+
+// CollectionSummary 某个系列的收藏进度
+type CollectionSummary struct {
+	Series            string  `json:"series"`
+	TotalProducts     int     `json:"total_products"`
+	OwnedProducts     int     `json:"owned_products"`
+	DuplicateProducts int     `json:"duplicate_products"`
+	Completion        float64 `json:"completion"` // 完成百分比，0 到 100
+}
+
+// CollectionSeries 某个系列已拥有和缺少的商品
+type CollectionSeries struct {
+	CollectionSummary
+	Owned   []CollectionProduct `json:"owned"`
+	Missing []CollectionProduct `json:"missing"`
+}
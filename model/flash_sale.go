@@ -0,0 +1,83 @@
+package model
+
+import "time"
+
+// 限时抢购状态
+const (
+	FlashSaleStatusScheduled = "scheduled" // 未开始
+	FlashSaleStatusActive    = "active"    // 进行中，库存已预载到Redis
+	FlashSaleStatusEnded     = "ended"     // 已结束
+	FlashSaleStatusCancelled = "cancelled" // 已取消
+)
+
+// FlashSale 限时抢购：开始时将抢购库存预载到Redis，抢购请求在Redis中扣减库存后排队异步下单
+type FlashSale struct {
+	ID           int       `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	ProductID    int       `json:"product_id" gorm:"type:int;not null;index:idx_product_id"`
+	Price        Money     `json:"price" gorm:"type:bigint;not null"`               // 抢购价
+	Stock        int       `json:"stock" gorm:"type:int;not null"`                  // 抢购库存，开始时不超过商品库存
+	PerUserLimit int       `json:"per_user_limit" gorm:"type:int;not null"`         // 每个用户在本场抢购中最多购买的件数
+	StartAt      time.Time `json:"start_at" gorm:"not null;index:idx_status_start"` // 开始时间
+	EndAt        time.Time `json:"end_at" gorm:"not null"`                          // 结束时间
+	Status       string    `json:"status" gorm:"type:varchar(20);not null;default:'scheduled';index:idx_status_start"`
+	Remaining    *int      `json:"remaining,omitempty" gorm:"-"` // 进行中的抢购剩余库存（来自Redis）
+	Product      Product   `json:"product" gorm:"foreignKey:ProductID"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (FlashSale) TableName() string {
+	return "flash_sales"
+}
+
+// FlashSaleOrder 抢购票据与订单的对应关系，票据唯一，队列重复投递时不会重复下单
+type FlashSaleOrder struct {
+	ID          int       `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	Ticket      string    `json:"ticket" gorm:"type:varchar(64);not null;uniqueIndex:idx_ticket"`
+	FlashSaleID int       `json:"flash_sale_id" gorm:"type:int;not null;index:idx_flash_sale_id"`
+	UserID      int       `json:"user_id" gorm:"type:int;not null"`
+	OrderID     int       `json:"order_id" gorm:"type:int;not null"`
+	Quantity    int       `json:"quantity" gorm:"type:int;not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName 指定表名
+func (FlashSaleOrder) TableName() string {
+	return "flash_sale_orders"
+}
+
+// 抢购票据状态
+const (
+	FlashSaleTicketQueued  = "queued"  // 排队中
+	FlashSaleTicketSuccess = "success" // 下单成功
+	FlashSaleTicketFailed  = "failed"  // 下单失败，库存已退回抢购
+)
+
+// FlashSaleTicket 抢购票据，客户端轮询获取最终下单结果
+type FlashSaleTicket struct {
+	Ticket      string    `json:"ticket"`
+	FlashSaleID int       `json:"flash_sale_id"`
+	UserID      int       `json:"user_id"`
+	Quantity    int       `json:"quantity"`
+	Status      string    `json:"status"`
+	OrderID     int       `json:"order_id,omitempty"`
+	TotalPrice  *Money    `json:"total_price,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CreateFlashSaleRequest 创建限时抢购请求
+type CreateFlashSaleRequest struct {
+	ProductID    int       `json:"product_id" binding:"required"`
+	Price        Money     `json:"price" binding:"required"`
+	Stock        int       `json:"stock" binding:"required"`
+	PerUserLimit int       `json:"per_user_limit"` // 默认1
+	StartAt      time.Time `json:"start_at" binding:"required"`
+	EndAt        time.Time `json:"end_at" binding:"required"`
+}
+
+// FlashSalePurchaseRequest 抢购请求
+type FlashSalePurchaseRequest struct {
+	Quantity int `json:"quantity"` // 默认1
+}
@@ -0,0 +1,277 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// moneyScale 每个货币单位包含的最小单位数（1元 = 100分）
+const moneyScale = 100
+
+var (
+	// ErrMoneyPrecision 金额超过两位小数
+	ErrMoneyPrecision = errors.New("金额最多保留两位小数")
+	// ErrMoneyOverflow 金额超出 int64 分的范围
+	ErrMoneyOverflow = errors.New("金额超出范围")
+)
+
+// Money 金额，以分为单位的整数保存，加减和乘以数量都是精确的整数运算
+// 系统只使用一种币种（人民币），金额不保存币种；数据库中保存为 BIGINT 分，
+// JSON 中序列化为以元为单位的数字（与原来的 float64 格式相同，如 69、69.5、0.01）。零值表示 0 元
+//
+// 舍入规则：只有比例计算（MulRatio）和从浮点数转换（MoneyFromFloat）会舍入，均为四舍五入到分（0.5 分远离零方向进位）；
+// 解析字符串和 JSON 时不舍入，超过两位小数返回 ErrMoneyPrecision
+type Money struct {
+	cents int64
+}
+
+// Cents 以分为单位创建金额
+func Cents(cents int64) Money {
+	return Money{cents: cents}
+}
+
+// MoneyFromFloat 将以元为单位的浮点数四舍五入到分，只用于兼容仍使用浮点数的外部输入
+func MoneyFromFloat(amount float64) Money {
+	return Money{cents: int64(math.Round(amount * moneyScale))}
+}
+
+// ParseMoney 精确解析以元为单位的十进制金额（如 "69"、"-0.5"、"1299.00"），超过两位小数时返回 ErrMoneyPrecision
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, frac, _ := strings.Cut(digits, ".")
+	frac = strings.TrimRight(frac, "0")
+	if whole == "" && frac == "" || strings.ContainsAny(whole+frac, "+-eE") {
+		return Money{}, fmt.Errorf("无效的金额: %q", s)
+	}
+	if len(frac) > 2 {
+		return Money{}, ErrMoneyPrecision
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+	if whole == "" {
+		whole = "0"
+	}
+
+	cents, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("无效的金额: %q", s)
+	}
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > (math.MaxInt64-cents)/moneyScale {
+		return Money{}, fmt.Errorf("无效的金额: %q", s)
+	}
+	cents += units * moneyScale
+	if negative {
+		cents = -cents
+	}
+	return Money{cents: cents}, nil
+}
+
+// Cents 金额的分值
+func (m Money) Cents() int64 {
+	return m.cents
+}
+
+// IsZero 金额是否为0
+func (m Money) IsZero() bool {
+	return m.cents == 0
+}
+
+// IsPositive 金额是否大于0
+func (m Money) IsPositive() bool {
+	return m.cents > 0
+}
+
+// IsNegative 金额是否小于0
+func (m Money) IsNegative() bool {
+	return m.cents < 0
+}
+
+// Add 相加
+func (m Money) Add(o Money) Money {
+	return Money{cents: m.cents + o.cents}
+}
+
+// Sub 相减
+func (m Money) Sub(o Money) Money {
+	return Money{cents: m.cents - o.cents}
+}
+
+// Mul 乘以数量，结果超出 int64 分时返回 ErrMoneyOverflow
+func (m Money) Mul(quantity int64) (Money, error) {
+	cents, ok := mulInt64(m.cents, quantity)
+	if !ok {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{cents: cents}, nil
+}
+
+// MulRatio 乘以 num/den 并四舍五入到分，用于按比例折扣和分摊
+// 中间结果超出 int64 时返回 ErrMoneyOverflow，den 为0时返回错误
+func (m Money) MulRatio(num, den int64) (Money, error) {
+	if den == 0 {
+		return Money{}, errors.New("money: 比例的分母为0")
+	}
+	product, ok := mulInt64(m.cents, num)
+	if !ok || product == math.MinInt64 && den == -1 {
+		return Money{}, ErrMoneyOverflow
+	}
+	// 先除后按余数进位，避免 product ± den/2 溢出；整数除法向零取整，余数的两倍不小于分母时远离零进位
+	cents, rem := product/den, product%den
+	if absInt64(rem) >= absInt64(den)-absInt64(rem) {
+		if (product < 0) != (den < 0) {
+			cents--
+		} else {
+			cents++
+		}
+	}
+	return Money{cents: cents}, nil
+}
+
+// mulInt64 两个 int64 相乘，溢出时 ok 为 false
+func mulInt64(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	c := a * b
+	return c, c/b == a
+}
+
+// absInt64 绝对值，用 uint64 表示 math.MinInt64 的绝对值
+func absInt64(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+	return uint64(n)
+}
+
+// Cmp 比较金额，m 小于、等于、大于 o 时分别返回 -1、0、1
+func (m Money) Cmp(o Money) int {
+	switch {
+	case m.cents < o.cents:
+		return -1
+	case m.cents > o.cents:
+		return 1
+	}
+	return 0
+}
+
+// Equal 金额相同
+func (m Money) Equal(o Money) bool {
+	return m.cents == o.cents
+}
+
+// Min 取较小的金额
+func (m Money) Min(o Money) Money {
+	if m.Cmp(o) <= 0 {
+		return m
+	}
+	return o
+}
+
+// Max 取较大的金额
+func (m Money) Max(o Money) Money {
+	if m.Cmp(o) >= 0 {
+		return m
+	}
+	return o
+}
+
+// String 以元为单位、固定两位小数的金额，如 "69.00"
+func (m Money) String() string {
+	cents := m.cents
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/moneyScale, cents%moneyScale)
+}
+
+// Float64 以元为单位的浮点数，只用于展示和兼容旧接口，不能再参与金额计算
+func (m Money) Float64() float64 {
+	return float64(m.cents) / moneyScale
+}
+
+// MarshalJSON 序列化为以元为单位的数字，去掉末尾的0（与 float64 的格式相同）
+func (m Money) MarshalJSON() ([]byte, error) {
+	s := m.String()
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	return []byte(s), nil
+}
+
+// UnmarshalJSON 解析以元为单位的数字或数字字符串，超过两位小数时返回 ErrMoneyPrecision
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n json.Number
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("无效的金额: %s", data)
+		}
+		s = n.String()
+	}
+	if strings.ContainsAny(s, "eE") {
+		// 科学计数法只在数值足够精确时接受
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("无效的金额: %s", data)
+		}
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value 写入数据库时保存为以分为单位的整数（BIGINT 列）
+func (m Money) Value() (driver.Value, error) {
+	return m.cents, nil
+}
+
+// Scan 读取以分为单位的 BIGINT 列；MySQL 文本协议返回数字字符串，SUM 等聚合结果可能是不带小数的 DECIMAL
+func (m *Money) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*m = Money{}
+		return nil
+	case int64:
+		*m = Money{cents: v}
+		return nil
+	case []byte:
+		return m.scanCents(string(v))
+	case string:
+		return m.scanCents(v)
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return fmt.Errorf("money: 无效的分值 %v", v)
+		}
+		*m = Money{cents: int64(v)}
+		return nil
+	}
+	return fmt.Errorf("money: unsupported scan type %T", value)
+}
+
+func (m *Money) scanCents(s string) error {
+	cents, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("money: 无效的分值 %q", s)
+	}
+	*m = Money{cents: cents}
+	return nil
+}
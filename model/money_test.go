@@ -0,0 +1,196 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr error
+	}{
+		{in: "69", want: 6900},
+		{in: "69.5", want: 6950},
+		{in: "0.01", want: 1},
+		{in: ".5", want: 50},
+		{in: "1299.00", want: 129900},
+		{in: "1.0050", wantErr: ErrMoneyPrecision},
+		{in: "1.005", wantErr: ErrMoneyPrecision},
+		{in: "-0.5", want: -50},
+		{in: "-1299.99", want: -129999},
+		{in: "+3", want: 300},
+		{in: " 12.30 ", want: 1230},
+		{in: "92233720368547758.07", want: math.MaxInt64},
+		{in: "-92233720368547758.07", want: -math.MaxInt64},
+		{in: "92233720368547758.08"},
+		{in: "92233720368547759"},
+		{in: "1e3"},
+		{in: ""},
+		{in: "-"},
+		{in: "1.2.3"},
+		{in: "--1"},
+		{in: "abc"},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in)
+		switch {
+		case tt.wantErr != nil:
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseMoney(%q) 错误为 %v，预期 %v", tt.in, err, tt.wantErr)
+			}
+		case tt.want == 0:
+			if err == nil {
+				t.Errorf("ParseMoney(%q) = %s，预期返回错误", tt.in, got)
+			}
+		case err != nil || got.Cents() != tt.want:
+			t.Errorf("ParseMoney(%q) = %d 分（%v），预期 %d 分", tt.in, got.Cents(), err, tt.want)
+		}
+	}
+}
+
+func TestMoneyMulRatio(t *testing.T) {
+	tests := []struct {
+		cents, num, den int64
+		want            int64
+	}{
+		{cents: 999, num: 15, den: 100, want: 150}, // 149.85
+		{cents: 5, num: 10, den: 100, want: 1},     // 0.5 分进位
+		{cents: 4, num: 10, den: 100, want: 0},     // 0.4 分舍去
+		{cents: -5, num: 10, den: 100, want: -1},   // 负数远离零进位
+		{cents: -4, num: 10, den: 100, want: 0},    // 负数 0.4 分舍去
+		{cents: 5, num: -10, den: 100, want: -1},   // 比例为负
+		{cents: 5, num: 10, den: -100, want: -1},   // 分母为负
+		{cents: 100, num: 1, den: 3, want: 33},     // 33.33
+		{cents: 200, num: 1, den: 3, want: 67},     // 66.67
+		{cents: 6900, num: 0, den: 100, want: 0},   // 比例为0
+		{cents: math.MaxInt64, num: 1, den: 2, want: math.MaxInt64/2 + 1},
+		{cents: math.MaxInt64, num: -1, den: 1, want: -math.MaxInt64},
+	}
+	for _, tt := range tests {
+		got, err := Cents(tt.cents).MulRatio(tt.num, tt.den)
+		if err != nil || got.Cents() != tt.want {
+			t.Errorf("%d × %d/%d = %d（%v），预期 %d", tt.cents, tt.num, tt.den, got.Cents(), err, tt.want)
+		}
+	}
+
+	if _, err := Cents(math.MaxInt64).MulRatio(2, 3); !errors.Is(err, ErrMoneyOverflow) {
+		t.Errorf("中间结果溢出时错误为 %v，预期 ErrMoneyOverflow", err)
+	}
+	if _, err := Cents(math.MinInt64).MulRatio(-1, 1); !errors.Is(err, ErrMoneyOverflow) {
+		t.Errorf("MinInt64 × -1 时错误为 %v，预期 ErrMoneyOverflow", err)
+	}
+	if _, err := Cents(math.MinInt64).MulRatio(1, -1); !errors.Is(err, ErrMoneyOverflow) {
+		t.Errorf("MinInt64 ÷ -1 时错误为 %v，预期 ErrMoneyOverflow", err)
+	}
+	if _, err := Cents(100).MulRatio(1, 0); err == nil {
+		t.Error("分母为0时没有返回错误")
+	}
+}
+
+func TestMoneyMul(t *testing.T) {
+	if got, err := Cents(6900).Mul(3); err != nil || got.Cents() != 20700 {
+		t.Errorf("69.00 × 3 = %s（%v），预期 207.00", got, err)
+	}
+	if got, err := Cents(-50).Mul(-2); err != nil || got.Cents() != 100 {
+		t.Errorf("-0.50 × -2 = %s（%v），预期 1.00", got, err)
+	}
+	for _, tt := range []struct{ cents, quantity int64 }{
+		{math.MaxInt64, 2},
+		{math.MaxInt64/2 + 1, 2},
+		{math.MinInt64, -1},
+		{-1, math.MinInt64},
+		{3037000500, 3037000500},
+	} {
+		if _, err := Cents(tt.cents).Mul(tt.quantity); !errors.Is(err, ErrMoneyOverflow) {
+			t.Errorf("%d × %d 错误为 %v，预期 ErrMoneyOverflow", tt.cents, tt.quantity, err)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		cents int64
+		json  string
+	}{
+		{6900, "69"},
+		{6950, "69.5"},
+		{1, "0.01"},
+		{0, "0"},
+		{-50, "-0.5"},
+		{129999, "1299.99"},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(Cents(tt.cents))
+		if err != nil || string(data) != tt.json {
+			t.Errorf("%d 分序列化为 %s（%v），预期 %s", tt.cents, data, err, tt.json)
+			continue
+		}
+		var got Money
+		if err := json.Unmarshal(data, &got); err != nil || got.Cents() != tt.cents {
+			t.Errorf("%s 反序列化为 %d 分（%v），预期 %d 分", data, got.Cents(), err, tt.cents)
+		}
+	}
+
+	var m Money
+	for _, tt := range []struct {
+		in   string
+		want int64
+	}{
+		{`"69.50"`, 6950},
+		{`6.9e1`, 6900},
+		{`null`, 6900}, // null 不修改原值
+	} {
+		if err := json.Unmarshal([]byte(tt.in), &m); err != nil || m.Cents() != tt.want {
+			t.Errorf("%s 反序列化为 %d 分（%v），预期 %d 分", tt.in, m.Cents(), err, tt.want)
+		}
+	}
+	if err := json.Unmarshal([]byte(`1.005`), &m); !errors.Is(err, ErrMoneyPrecision) {
+		t.Errorf("1.005 反序列化错误为 %v，预期 ErrMoneyPrecision", err)
+	}
+	if err := json.Unmarshal([]byte(`true`), &m); err == nil {
+		t.Error("布尔值反序列化没有返回错误")
+	}
+}
+
+func TestMoneyValueScan(t *testing.T) {
+	for _, cents := range []int64{0, 1, 6900, -50, math.MaxInt64} {
+		value, err := Cents(cents).Value()
+		if err != nil || value != cents {
+			t.Errorf("%d 分写入数据库的值为 %v（%v），预期 int64 分", cents, value, err)
+			continue
+		}
+		var got Money
+		if err := got.Scan(value); err != nil || got.Cents() != cents {
+			t.Errorf("%d 分读回为 %d 分（%v）", cents, got.Cents(), err)
+		}
+	}
+
+	tests := []struct {
+		value interface{}
+		want  int64
+		ok    bool
+	}{
+		{value: nil, want: 0, ok: true},
+		{value: []byte("6900"), want: 6900, ok: true},
+		{value: "-50", want: -50, ok: true},
+		{value: float64(1299), want: 1299, ok: true},
+		{value: "69.00"},
+		{value: []byte("abc")},
+		{value: 12.5},
+		{value: true},
+	}
+	for _, tt := range tests {
+		got := Cents(1)
+		err := got.Scan(tt.value)
+		if tt.ok && (err != nil || got.Cents() != tt.want) {
+			t.Errorf("Scan(%#v) = %d 分（%v），预期 %d 分", tt.value, got.Cents(), err, tt.want)
+		}
+		if !tt.ok && err == nil {
+			t.Errorf("Scan(%#v) 没有返回错误", tt.value)
+		}
+	}
+}
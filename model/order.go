@@ -2,15 +2,31 @@ package model
 
 import "time"
 
+// 订单状态
+const (
+	OrderStatusPendingPayment = "pending_payment" // 待支付
+	OrderStatusPaid           = "paid"            // 已支付
+	OrderStatusShipped        = "shipped"         // 已发货
+	OrderStatusDelivered      = "delivered"       // 已送达
+	OrderStatusCompleted      = "completed"       // 已完成（用户确认收货）
+	OrderStatusCancelled      = "cancelled"       // 已取消
+	OrderStatusRefunded       = "refunded"        // 已退款
+)
+
 // Order 订单模型
 type Order struct {
-	ID         int         `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
-	UserID     int         `json:"user_id" gorm:"type:int;not null;index:idx_user_id"`
-	TotalPrice float64     `json:"total_price" gorm:"type:decimal(10,2);not null"`
-	Status     string      `json:"status" gorm:"type:varchar(20);default:'pending'"`
-	Items      []OrderItem `json:"items" gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE"`
-	CreatedAt  time.Time   `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt  time.Time   `json:"updated_at" gorm:"autoUpdateTime"`
+	ID             int                  `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	UserID         int                  `json:"user_id" gorm:"type:int;not null;index:idx_user_id"`
+	TotalPrice     Money                `json:"total_price" gorm:"type:bigint;not null"`               // 应付金额（已扣除优惠）
+	DiscountAmount Money                `json:"discount_amount" gorm:"type:bigint;not null;default:0"` // 促销和优惠码的优惠合计
+	Status         string               `json:"status" gorm:"type:varchar(20);not null;default:'pending_payment'"`
+	TrackingNumber string               `json:"tracking_number" gorm:"type:varchar(64)"`
+	Items          []OrderItem          `json:"items" gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE"`
+	History        []OrderStatusHistory `json:"history,omitempty" gorm:"foreignKey:OrderID"`
+	Payments       []Payment            `json:"payments,omitempty" gorm:"foreignKey:OrderID"`
+	Discounts      []OrderDiscount      `json:"discounts,omitempty" gorm:"foreignKey:OrderID"`
+	CreatedAt      time.Time            `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time            `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
 // TableName 指定表名
@@ -20,14 +36,17 @@ func (Order) TableName() string {
 
 // OrderItem 订单项模型
 type OrderItem struct {
-	ID        int       `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
-	OrderID   int       `json:"order_id" gorm:"type:int;not null;index:idx_order_id"`
-	ProductID int       `json:"product_id" gorm:"type:int;not null;index:idx_product_id"`
-	Quantity  int       `json:"quantity" gorm:"type:int;not null"`
-	Price     float64   `json:"price" gorm:"type:decimal(10,2);not null"`
-	Product   Product   `json:"product" gorm:"foreignKey:ProductID"`
-	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID        int            `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	OrderID   int            `json:"order_id" gorm:"type:int;not null;index:idx_order_id"`
+	ProductID int            `json:"product_id" gorm:"type:int;not null;index:idx_product_id"`
+	Quantity  int            `json:"quantity" gorm:"type:int;not null"`
+	Price     Money          `json:"price" gorm:"type:bigint;not null"`
+	BundleID  *int           `json:"bundle_id,omitempty" gorm:"type:int;index:idx_bundle_id"` // 套装成员的订单项，Price 为分摊后的套装价
+	Product   Product        `json:"product" gorm:"foreignKey:ProductID"`
+	DrawSeed  string         `json:"draw_seed,omitempty" gorm:"type:varchar(64)"` // 盲盒抽取随机数种子（十六进制），未抽取时为空
+	Draws     []BlindBoxDraw `json:"draws,omitempty" gorm:"foreignKey:OrderItemID"`
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
 // TableName 指定表名
@@ -35,7 +54,40 @@ func (OrderItem) TableName() string {
 	return "order_items"
 }
 
-// CreateOrderRequest 创建订单请求（可选，如果为空则使用购物车中所有商品）
+// OrderStatusHistory 订单状态变更记录
+type OrderStatusHistory struct {
+	ID         int       `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	OrderID    int       `json:"order_id" gorm:"type:int;not null;index:idx_order_id"`
+	FromStatus string    `json:"from_status" gorm:"type:varchar(20)"`
+	ToStatus   string    `json:"to_status" gorm:"type:varchar(20);not null"`
+	OperatorID int       `json:"operator_id" gorm:"type:int;not null;default:0"` // 0 表示系统操作
+	Reason     string    `json:"reason" gorm:"type:varchar(255)"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName 指定表名
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
+
+// CreateOrderRequest 创建订单请求（可选，如果为空则使用购物车中所有商品和套装）
 type CreateOrderRequest struct {
-	CartItemIDs []int `json:"cart_item_ids"` // 可选，如果为空则使用购物车中所有商品
+	CartItemIDs []int  `json:"cart_item_ids"` // 可选，要结算的商品ID
+	BundleIDs   []int  `json:"bundle_ids"`    // 可选，要结算的套装ID
+	CouponCode  string `json:"coupon_code"`   // 可选，优惠码
+}
+
+// CancelOrderRequest 取消订单请求
+type CancelOrderRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ShipOrderRequest 发货请求
+type ShipOrderRequest struct {
+	TrackingNumber string `json:"tracking_number" binding:"required"`
+}
+
+// RefundOrderRequest 退款请求
+type RefundOrderRequest struct {
+	Reason string `json:"reason" binding:"required"`
 }
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// 支付状态
+const (
+	PaymentStatusPending   = "pending"   // 已发起，等待渠道回调
+	PaymentStatusSucceeded = "succeeded" // 支付成功
+	PaymentStatusFailed    = "failed"    // 支付失败
+)
+
+// Payment 支付记录，每次发起支付生成一条
+type Payment struct {
+	ID            int        `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	OrderID       int        `json:"order_id" gorm:"type:int;not null;index:idx_order_id"`
+	UserID        int        `json:"user_id" gorm:"type:int;not null"`
+	Provider      string     `json:"provider" gorm:"type:varchar(32);not null"`
+	ProviderRef   *string    `json:"provider_ref" gorm:"type:varchar(128)"` // 渠道流水号，渠道创建成功前为空
+	Amount        Money      `json:"amount" gorm:"type:bigint;not null"`
+	Status        string     `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	PayURL        string     `json:"pay_url" gorm:"type:varchar(500)"`
+	FailureReason string     `json:"failure_reason,omitempty" gorm:"type:varchar(255)"`
+	EventID       string     `json:"-" gorm:"type:varchar(128)"` // 最后处理的回调事件ID
+	PaidAt        *time.Time `json:"paid_at"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (Payment) TableName() string {
+	return "payments"
+}
+
+// PayOrderRequest 发起支付请求
+type PayOrderRequest struct {
+	Provider string `json:"provider"` // 可选，为空时使用默认渠道
+}
+
+// CompleteMockPaymentRequest 模拟支付完成请求
+type CompleteMockPaymentRequest struct {
+	Status string `json:"status"` // succeeded 或 failed，默认 succeeded
+}
@@ -1,21 +1,167 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // Product 商品模型
 type Product struct {
-	ID          int       `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
-	Name        string    `json:"name" gorm:"type:varchar(200);not null"`
-	Description string    `json:"description" gorm:"type:text"`
-	Price       float64   `json:"price" gorm:"type:decimal(10,2);not null"`
-	Image       string    `json:"image" gorm:"type:varchar(500)"`
-	Stock       int       `json:"stock" gorm:"type:int;default:0"`
-	Series      string    `json:"series" gorm:"type:varchar(50);default:'拉布布'"`
-	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID          int    `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	Name        string `json:"name" gorm:"type:varchar(200);not null"`
+	Description string `json:"description" gorm:"type:text"`
+	Price       Money  `json:"price" gorm:"type:bigint;not null"`
+	Image       string `json:"image" gorm:"type:varchar(500)"`
+	Stock       int    `json:"stock" gorm:"type:int;default:0"`
+	Series      string `json:"series" gorm:"type:varchar(50);default:'拉布布'"`
+	SalesCount  int    `json:"sales_count" gorm:"type:int;not null;default:0"` // 销量，取消和退款的订单不计入
+	PurchaseLimits
+	CreatedAt time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"` // 软删除，历史订单仍可关联到已下架商品
 }
 
 // TableName 指定表名
 func (Product) TableName() string {
 	return "products"
 }
+
+// PurchaseLimits 商品限购规则，0 表示不限制
+type PurchaseLimits struct {
+	LimitPerOrder   int `json:"limit_per_order" gorm:"type:int;not null;default:0"`  // 每单限购件数
+	LimitPerDay     int `json:"limit_per_day" gorm:"type:int;not null;default:0"`    // 每人每天（自然日）限购件数
+	LimitLifetime   int `json:"limit_lifetime" gorm:"type:int;not null;default:0"`   // 每人累计限购件数
+	CooldownMinutes int `json:"cooldown_minutes" gorm:"type:int;not null;default:0"` // 同一用户两次下单的最小间隔（分钟）
+}
+
+// HasLimits 是否设置了任一限购规则
+func (l PurchaseLimits) HasLimits() bool {
+	return l.LimitPerOrder > 0 || l.LimitPerDay > 0 || l.LimitLifetime > 0 || l.CooldownMinutes > 0
+}
+
+// PurchaseUsage 用户对商品的有效购买量，已取消和已退款的订单不计入
+type PurchaseUsage struct {
+	ProductID   int        `json:"product_id"`
+	Lifetime    int        `json:"lifetime"`                // 累计购买件数
+	Today       int        `json:"today"`                   // 今日购买件数
+	LastOrderAt *time.Time `json:"last_order_at,omitempty"` // 最近一次下单时间
+}
+
+// 库存调整原因
+const (
+	StockReasonRestock    = "restock"    // 补货
+	StockReasonDamaged    = "damaged"    // 损坏
+	StockReasonLost       = "lost"       // 丢失
+	StockReasonReturn     = "return"     // 退货入库
+	StockReasonCorrection = "correction" // 盘点修正
+)
+
+// StockAdjustment 库存调整记录
+type StockAdjustment struct {
+	ID         int       `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	ProductID  int       `json:"product_id" gorm:"type:int;not null;index:idx_product_id"`
+	Delta      int       `json:"delta" gorm:"type:int;not null"`
+	StockAfter int       `json:"stock_after" gorm:"type:int;not null"`
+	Reason     string    `json:"reason" gorm:"type:varchar(20);not null"`
+	Note       string    `json:"note" gorm:"type:varchar(255)"`
+	OperatorID int       `json:"operator_id" gorm:"type:int;not null"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName 指定表名
+func (StockAdjustment) TableName() string {
+	return "stock_adjustments"
+}
+
+// IsValidStockReason 检查库存调整原因是否合法
+func IsValidStockReason(reason string) bool {
+	switch reason {
+	case StockReasonRestock, StockReasonDamaged, StockReasonLost, StockReasonReturn, StockReasonCorrection:
+		return true
+	}
+	return false
+}
+
+// CreateProductRequest 创建商品请求
+type CreateProductRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Price       Money  `json:"price" binding:"required"`
+	Image       string `json:"image"`
+	Stock       int    `json:"stock"`
+	Series      string `json:"series"`
+	PurchaseLimits
+}
+
+// UpdateProductRequest 更新商品请求（只更新传入的字段，库存通过库存调整接口修改）
+type UpdateProductRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Price       *Money  `json:"price"`
+	Image       *string `json:"image"`
+	Series      *string `json:"series"`
+	// 限购规则，传 0 取消对应限制
+	LimitPerOrder   *int `json:"limit_per_order"`
+	LimitPerDay     *int `json:"limit_per_day"`
+	LimitLifetime   *int `json:"limit_lifetime"`
+	CooldownMinutes *int `json:"cooldown_minutes"`
+}
+
+// AdjustStockRequest 库存调整请求
+type AdjustStockRequest struct {
+	Delta  int    `json:"delta" binding:"required"` // 正数为入库，负数为出库
+	Reason string `json:"reason" binding:"required"`
+	Note   string `json:"note"`
+}
+
+// 商品列表排序方式
+const (
+	ProductSortDefault     = ""             // 默认按ID倒序
+	ProductSortPriceAsc    = "price_asc"    // 价格从低到高
+	ProductSortPriceDesc   = "price_desc"   // 价格从高到低
+	ProductSortNewest      = "newest"       // 最新上架
+	ProductSortBestSelling = "best_selling" // 销量最高
+)
+
+// IsValidProductSort 检查商品列表排序方式是否合法
+func IsValidProductSort(sort string) bool {
+	switch sort {
+	case ProductSortDefault, ProductSortPriceAsc, ProductSortPriceDesc, ProductSortNewest, ProductSortBestSelling:
+		return true
+	}
+	return false
+}
+
+// ProductListQuery 商品列表查询条件
+// 传入 Cursor 时使用游标分页（忽略 Page），否则按 Page/PageSize 分页
+type ProductListQuery struct {
+	Page     int
+	PageSize int
+	Cursor   string
+	Series   string
+	MinPrice *Money
+	MaxPrice *Money
+	InStock  bool   // 只返回有库存的商品
+	Keyword  string // 匹配名称、描述和系列
+	Sort     string
+}
+
+// ProductCursor 游标分页位置：上一页最后一个商品的排序字段值和ID
+type ProductCursor struct {
+	Sort       string     `json:"o"`
+	ID         int        `json:"id"`
+	Price      Money      `json:"p"`
+	CreatedAt  *time.Time `json:"t,omitempty"`
+	SalesCount int        `json:"s,omitempty"`
+}
+
+// ProductListResponse 商品列表响应
+type ProductListResponse struct {
+	Products   []Product `json:"products"`
+	Total      int64     `json:"total"`          // 满足过滤条件的商品总数
+	Page       int       `json:"page,omitempty"` // 游标分页时为空
+	PageSize   int       `json:"page_size"`
+	HasMore    bool      `json:"has_more"`
+	NextCursor string    `json:"next_cursor,omitempty"` // 传给下一次请求的 cursor 参数
+}
@@ -0,0 +1,156 @@
+package model
+
+import "time"
+
+// 促销类型
+const (
+	PromotionTypePercentage = "percentage"  // 按比例折扣
+	PromotionTypeFixed      = "fixed"       // 立减固定金额
+	PromotionTypeThreshold  = "threshold"   // 满减：适用商品金额达到门槛后减免固定金额（如满300减50）
+	PromotionTypeBuyNGetM   = "buy_n_get_m" // 买N送M：适用商品每 N+M 件中价格最低的 M 件免费
+)
+
+// 促销适用范围
+const (
+	PromotionScopeAll     = "all"     // 全部商品
+	PromotionScopeProduct = "product" // 指定商品
+	PromotionScopeSeries  = "series"  // 指定系列
+)
+
+// Promotion 促销规则，自动应用的促销对所有用户生效，其他促销只能通过优惠码使用
+// 套装行已按套装价优惠，不参与促销
+type Promotion struct {
+	ID          int       `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	Name        string    `json:"name" gorm:"type:varchar(100);not null"`
+	Type        string    `json:"type" gorm:"type:varchar(20);not null"`
+	Scope       string    `json:"scope" gorm:"type:varchar(20);not null;default:'all'"`
+	ProductID   *int      `json:"product_id,omitempty" gorm:"type:int;index:idx_product_id"` // scope 为 product 时适用的商品
+	Series      string    `json:"series,omitempty" gorm:"type:varchar(50)"`                  // scope 为 series 时适用的系列
+	PercentOff  int       `json:"percent_off,omitempty" gorm:"type:int;not null;default:0"`  // 折扣比例（1-99），percentage 类型
+	AmountOff   Money     `json:"amount_off" gorm:"type:bigint;not null;default:0"`
+	MinAmount   Money     `json:"min_amount" gorm:"type:bigint;not null;default:0"` // 适用商品金额门槛，threshold 类型必填
+	BuyQuantity int       `json:"buy_quantity,omitempty" gorm:"type:int;not null;default:0"`
+	GetQuantity int       `json:"get_quantity,omitempty" gorm:"type:int;not null;default:0"`
+	AutoApply   bool      `json:"auto_apply" gorm:"not null;default:false"`
+	Active      bool      `json:"active" gorm:"not null;default:true"`
+	StartAt     time.Time `json:"start_at" gorm:"not null"`
+	EndAt       time.Time `json:"end_at" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (Promotion) TableName() string {
+	return "promotions"
+}
+
+// Coupon 优惠码，使用时应用关联的促销规则
+type Coupon struct {
+	ID           int       `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	Code         string    `json:"code" gorm:"type:varchar(32);not null;uniqueIndex:idx_code"`
+	PromotionID  int       `json:"promotion_id" gorm:"type:int;not null;index:idx_promotion_id"`
+	UsageLimit   int       `json:"usage_limit" gorm:"type:int;not null;default:0"`    // 所有用户合计可使用次数，0 表示不限制
+	PerUserLimit int       `json:"per_user_limit" gorm:"type:int;not null;default:1"` // 每个用户可使用次数
+	UsedCount    int       `json:"used_count" gorm:"type:int;not null;default:0"`     // 已使用次数，取消订单后释放
+	StartAt      time.Time `json:"start_at" gorm:"not null"`
+	EndAt        time.Time `json:"end_at" gorm:"not null"`
+	Active       bool      `json:"active" gorm:"not null;default:true"`
+	Promotion    Promotion `json:"promotion" gorm:"foreignKey:PromotionID"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (Coupon) TableName() string {
+	return "coupons"
+}
+
+// 优惠码使用记录状态
+const (
+	CouponRedemptionApplied  = "applied"  // 已使用
+	CouponRedemptionReleased = "released" // 订单取消后已释放
+)
+
+// CouponRedemption 优惠码使用记录，计入每人和全局使用次数
+type CouponRedemption struct {
+	ID        int       `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	CouponID  int       `json:"coupon_id" gorm:"type:int;not null;index:idx_coupon_user"`
+	UserID    int       `json:"user_id" gorm:"type:int;not null;index:idx_coupon_user"`
+	OrderID   int       `json:"order_id" gorm:"type:int;not null;index:idx_order_id"`
+	Status    string    `json:"status" gorm:"type:varchar(20);not null;default:'applied'"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (CouponRedemption) TableName() string {
+	return "coupon_redemptions"
+}
+
+// OrderDiscount 订单使用的优惠
+type OrderDiscount struct {
+	ID          int       `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	OrderID     int       `json:"order_id" gorm:"type:int;not null;index:idx_order_id"`
+	PromotionID int       `json:"promotion_id" gorm:"type:int;not null"`
+	CouponID    *int      `json:"coupon_id,omitempty" gorm:"type:int"`
+	CouponCode  string    `json:"coupon_code,omitempty" gorm:"type:varchar(32)"`
+	Name        string    `json:"name" gorm:"type:varchar(100);not null"`
+	Amount      Money     `json:"amount" gorm:"type:bigint;not null"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName 指定表名
+func (OrderDiscount) TableName() string {
+	return "order_discounts"
+}
+
+// AppliedPromotion 购物车或订单中已应用的一项优惠
+type AppliedPromotion struct {
+	PromotionID int    `json:"promotion_id"`
+	CouponID    *int   `json:"coupon_id,omitempty"`
+	CouponCode  string `json:"coupon_code,omitempty"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Amount      Money  `json:"amount"`
+}
+
+// CreatePromotionRequest 创建促销请求
+type CreatePromotionRequest struct {
+	Name        string    `json:"name" binding:"required"`
+	Type        string    `json:"type" binding:"required"`
+	Scope       string    `json:"scope"` // 默认 all
+	ProductID   *int      `json:"product_id"`
+	Series      string    `json:"series"`
+	PercentOff  int       `json:"percent_off"`
+	AmountOff   Money     `json:"amount_off"`
+	MinAmount   Money     `json:"min_amount"`
+	BuyQuantity int       `json:"buy_quantity"`
+	GetQuantity int       `json:"get_quantity"`
+	AutoApply   bool      `json:"auto_apply"`
+	StartAt     time.Time `json:"start_at" binding:"required"`
+	EndAt       time.Time `json:"end_at" binding:"required"`
+}
+
+// UpdatePromotionRequest 更新促销请求（只更新传入的字段）
+type UpdatePromotionRequest struct {
+	Name   *string    `json:"name"`
+	Active *bool      `json:"active"`
+	EndAt  *time.Time `json:"end_at"`
+}
+
+// CreateCouponRequest 创建优惠码请求，code 为空时自动生成，有效期为空时使用促销的时间
+type CreateCouponRequest struct {
+	PromotionID  int        `json:"promotion_id" binding:"required"`
+	Code         string     `json:"code"`
+	UsageLimit   int        `json:"usage_limit"`
+	PerUserLimit int        `json:"per_user_limit"` // 默认1
+	StartAt      *time.Time `json:"start_at"`
+	EndAt        *time.Time `json:"end_at"`
+}
+
+// UpdateCouponRequest 更新优惠码请求（只更新传入的字段）
+type UpdateCouponRequest struct {
+	Active     *bool      `json:"active"`
+	UsageLimit *int       `json:"usage_limit"`
+	EndAt      *time.Time `json:"end_at"`
+}
@@ -0,0 +1,82 @@
+package model
+
+import "time"
+
+// 抽签状态
+const (
+	RaffleStatusOpen      = "open"      // 报名中（或报名已截止等待开奖）
+	RaffleStatusDrawn     = "drawn"     // 已开奖
+	RaffleStatusCancelled = "cancelled" // 已取消
+)
+
+// 报名状态
+const (
+	RaffleEntryEntered   = "entered"   // 已报名，等待开奖
+	RaffleEntryWon       = "won"       // 中签，可在截止时间前购买
+	RaffleEntryLost      = "lost"      // 未中签
+	RaffleEntryPurchased = "purchased" // 已使用购买资格下单
+	RaffleEntryExpired   = "expired"   // 中签后未在截止时间前购买
+)
+
+// Raffle 抽签发售：报名期内每个用户报名一次，截止后用预先公布哈希的种子抽取中签者，中签者在限定时间内按抽签价购买
+type Raffle struct {
+	ID               int        `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	ProductID        int        `json:"product_id" gorm:"type:int;not null;index:idx_product_id"`
+	Price            Money      `json:"price" gorm:"type:bigint;not null"`
+	Quantity         int        `json:"quantity" gorm:"type:int;not null"`                 // 中签名额
+	EntryStartAt     time.Time  `json:"entry_start_at" gorm:"not null"`                    // 报名开始时间
+	EntryEndAt       time.Time  `json:"entry_end_at" gorm:"not null;index:idx_status_end"` // 报名截止时间，截止后开奖
+	CheckoutMinutes  int        `json:"checkout_minutes" gorm:"type:int;not null"`         // 中签后购买时限（分钟）
+	Status           string     `json:"status" gorm:"type:varchar(20);not null;default:'open';index:idx_status_end"`
+	SeedHash         string     `json:"seed_hash" gorm:"type:varchar(64);not null"` // 种子的 SHA-256，创建时公布
+	Seed             string     `json:"-" gorm:"type:varchar(64);not null"`         // 抽取种子（十六进制），开奖后公布
+	Winners          int        `json:"winners" gorm:"type:int;not null;default:0"` // 实际中签人数
+	DrawnAt          *time.Time `json:"drawn_at,omitempty"`
+	CheckoutDeadline *time.Time `json:"checkout_deadline,omitempty"` // 中签者购买截止时间
+	RevealedSeed     string     `json:"seed,omitempty" gorm:"-"`     // 开奖后公布的种子
+	EntryCount       int64      `json:"entry_count" gorm:"-"`        // 报名人数
+	Product          Product    `json:"product" gorm:"foreignKey:ProductID"`
+	CreatedAt        time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (Raffle) TableName() string {
+	return "raffles"
+}
+
+// RaffleEntry 抽签报名，每个用户每场抽签一条
+type RaffleEntry struct {
+	ID               int        `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	RaffleID         int        `json:"raffle_id" gorm:"type:int;not null;uniqueIndex:idx_raffle_user"`
+	UserID           int        `json:"user_id" gorm:"type:int;not null;uniqueIndex:idx_raffle_user"`
+	Status           string     `json:"status" gorm:"type:varchar(20);not null;default:'entered'"`
+	CheckoutDeadline *time.Time `json:"checkout_deadline,omitempty"`
+	OrderID          *int       `json:"order_id,omitempty" gorm:"type:int"`
+	CreatedAt        time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (RaffleEntry) TableName() string {
+	return "raffle_entries"
+}
+
+// RaffleResult 开奖结果，可用公布的种子重放校验
+type RaffleResult struct {
+	RaffleID       int    `json:"raffle_id"`
+	Seed           string `json:"seed"`
+	SeedHash       string `json:"seed_hash"`
+	EntryIDs       []int  `json:"entry_ids"`        // 全部报名ID（升序），即洗牌前的顺序
+	WinnerEntryIDs []int  `json:"winner_entry_ids"` // 中签的报名ID（按抽中顺序）
+}
+
+// CreateRaffleRequest 创建抽签请求
+type CreateRaffleRequest struct {
+	ProductID       int       `json:"product_id" binding:"required"`
+	Price           Money     `json:"price" binding:"required"`
+	Quantity        int       `json:"quantity" binding:"required"`
+	EntryStartAt    time.Time `json:"entry_start_at" binding:"required"`
+	EntryEndAt      time.Time `json:"entry_end_at" binding:"required"`
+	CheckoutMinutes int       `json:"checkout_minutes"` // 默认1440（24小时）
+}
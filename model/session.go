@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// Session 登录会话（存储在Redis中）
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     int       `json:"user_id"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"` // 是否为发起请求的会话
+}
+
+// SessionMeta 创建会话时记录的客户端信息
+type SessionMeta struct {
+	Device    string
+	UserAgent string
+	IP        string
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 物品来源
+const (
+	OwnedItemSourcePurchase = "purchase" // 订单确认收货后进入库存
+	OwnedItemSourceTrade    = "trade"    // 通过交易获得
+)
+
+// OwnedItem 用户拥有的单件物品，每件商品一行，来源于已完成订单的订单项
+type OwnedItem struct {
+	ID          int             `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	UserID      int             `json:"user_id" gorm:"type:int;not null;index:idx_user_id"`
+	ProductID   int             `json:"product_id" gorm:"type:int;not null"`
+	OrderID     int             `json:"order_id" gorm:"type:int;not null;index:idx_order_id"`
+	OrderItemID int             `json:"order_item_id" gorm:"type:int;not null;uniqueIndex:idx_order_item_unit"`
+	UnitIndex   int             `json:"unit_index" gorm:"type:int;not null;uniqueIndex:idx_order_item_unit"` // 订单项中的第几件，从0开始
+	FigureID    *int            `json:"figure_id,omitempty" gorm:"type:int"`                                 // 盲盒抽中的款式
+	Listed      bool            `json:"listed" gorm:"not null;default:false"`                                // 是否挂出供其他用户发起交换
+	Source      string          `json:"source" gorm:"type:varchar(20);not null"`
+	Product     Product         `json:"product" gorm:"foreignKey:ProductID"`
+	Figure      *BlindBoxFigure `json:"figure,omitempty" gorm:"foreignKey:FigureID"`
+	CreatedAt   time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt   gorm.DeletedAt  `json:"-" gorm:"index"` // 订单退款后收回
+}
+
+// TableName 指定表名
+func (OwnedItem) TableName() string {
+	return "owned_items"
+}
+
+// 交易报价状态
+const (
+	TradeStatusPending   = "pending"   // 等待对方处理
+	TradeStatusAccepted  = "accepted"  // 已接受，物品已交换
+	TradeStatusRejected  = "rejected"  // 对方已拒绝
+	TradeStatusCancelled = "cancelled" // 发起方取消，或物品已不可交换
+)
+
+// 报价物品方向
+const (
+	TradeSideOffered   = "offered"   // 发起方给出的物品
+	TradeSideRequested = "requested" // 发起方想要的物品
+)
+
+// TradeOffer 交易报价：发起方用自己的物品交换对方挂出的物品
+type TradeOffer struct {
+	ID         int               `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	FromUserID int               `json:"from_user_id" gorm:"type:int;not null;index:idx_from_user_id"`
+	ToUserID   int               `json:"to_user_id" gorm:"type:int;not null;index:idx_to_user_id"`
+	Status     string            `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	Message    string            `json:"message" gorm:"type:varchar(255)"`
+	Items      []TradeOfferItem  `json:"items" gorm:"foreignKey:OfferID"`
+	Events     []TradeOfferEvent `json:"events,omitempty" gorm:"foreignKey:OfferID"`
+	CreatedAt  time.Time         `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (TradeOffer) TableName() string {
+	return "trade_offers"
+}
+
+// TradeOfferItem 报价中的物品
+type TradeOfferItem struct {
+	ID          int       `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	OfferID     int       `json:"offer_id" gorm:"type:int;not null;index:idx_offer_id"`
+	OwnedItemID int       `json:"owned_item_id" gorm:"type:int;not null;index:idx_owned_item_id"`
+	Side        string    `json:"side" gorm:"type:varchar(20);not null"`
+	OwnedItem   OwnedItem `json:"owned_item" gorm:"foreignKey:OwnedItemID"`
+}
+
+// TableName 指定表名
+func (TradeOfferItem) TableName() string {
+	return "trade_offer_items"
+}
+
+// TradeOfferEvent 报价状态变更记录
+type TradeOfferEvent struct {
+	ID         int       `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
+	OfferID    int       `json:"offer_id" gorm:"type:int;not null;index:idx_offer_id"`
+	FromStatus string    `json:"from_status" gorm:"type:varchar(20)"`
+	ToStatus   string    `json:"to_status" gorm:"type:varchar(20);not null"`
+	ActorID    int       `json:"actor_id" gorm:"type:int;not null;default:0"` // 0 表示系统操作
+	Note       string    `json:"note" gorm:"type:varchar(255)"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName 指定表名
+func (TradeOfferEvent) TableName() string {
+	return "trade_offer_events"
+}
+
+// CreateTradeOfferRequest 发起交易报价请求
+type CreateTradeOfferRequest struct {
+	OfferedItemIDs   []int  `json:"offered_item_ids" binding:"required"`   // 自己库存中的物品
+	RequestedItemIDs []int  `json:"requested_item_ids" binding:"required"` // 对方挂出的物品，必须属于同一个用户
+	Message          string `json:"message"`
+}
@@ -2,12 +2,20 @@ package model
 
 import "time"
 
+// 用户角色
+const (
+	RoleCustomer = "customer" // 普通顾客
+	RoleOperator = "operator" // 运营人员，可管理商品和订单
+	RoleAdmin    = "admin"    // 管理员，可管理用户角色
+)
+
 // User 用户模型
 type User struct {
 	ID        int       `json:"id" gorm:"primaryKey;autoIncrement;type:int"`
 	Username  string    `json:"username" gorm:"type:varchar(50);uniqueIndex;not null"`
 	Password  string    `json:"-" gorm:"type:varchar(255);not null"`
 	Email     string    `json:"email" gorm:"type:varchar(100);uniqueIndex"`
+	Role      string    `json:"role" gorm:"type:varchar(20);not null;default:'customer'"`
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 }
@@ -26,12 +34,41 @@ type RegisterRequest struct {
 
 // LoginRequest 登录请求
 type LoginRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Username  string `json:"username" binding:"required"`
+	Password  string `json:"password" binding:"required"`
+	Device    string `json:"device"`     // 可选，设备名称，用于会话列表展示
+	CartToken string `json:"cart_token"` // 可选，游客购物车令牌，登录后合并到用户购物车
 }
 
 // LoginResponse 登录响应
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	TokenResponse
+	User      User             `json:"user"`
+	CartMerge *CartMergeResult `json:"cart_merge,omitempty"` // 携带游客购物车令牌登录时的合并结果
+}
+
+// TokenResponse 令牌响应
+type TokenResponse struct {
+	Token        string `json:"token"`         // 访问令牌（JWT）
+	RefreshToken string `json:"refresh_token"` // 刷新令牌，每次刷新后轮换
+	ExpiresIn    int64  `json:"expires_in"`    // 访问令牌有效期（秒）
+}
+
+// RefreshTokenRequest 刷新令牌请求
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// UpdateUserRoleRequest 修改用户角色请求
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// IsValidRole 检查角色是否合法
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleCustomer, RoleOperator, RoleAdmin:
+		return true
+	}
+	return false
 }
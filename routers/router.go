@@ -5,6 +5,7 @@ import (
 
 	"shop/controller/api"
 	"shop/middleware"
+	"shop/model"
 
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/app/server"
@@ -17,7 +18,8 @@ func InitRouter(h *server.Hertz) {
 	h.Use(func(ctx context.Context, c *app.RequestContext) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key, X-Cart-Token")
+		c.Header("Access-Control-Expose-Headers", "X-Cart-Token")
 		if string(c.Method()) == consts.MethodOptions {
 			c.AbortWithStatus(consts.StatusNoContent)
 			return
@@ -33,24 +35,151 @@ func InitRouter(h *server.Hertz) {
 	{
 		// 公开路由
 		apiGroup.POST("/register", api.Register)
-		apiGroup.POST("/login", api.Login)
+		apiGroup.POST("/login", middleware.WithGuestCartToken(), api.Login)
+		apiGroup.POST("/token/refresh", api.RefreshToken)
 		apiGroup.GET("/products", api.GetProducts)
 		apiGroup.GET("/products/:id", api.GetProduct)
+		apiGroup.GET("/products/:id/blind-box", api.GetBlindBox)
+		apiGroup.GET("/bundles", api.GetBundles)
+		apiGroup.GET("/bundles/:id", api.GetBundle)
+		apiGroup.GET("/flash-sales", api.GetFlashSales)
+		apiGroup.GET("/flash-sales/:id", api.GetFlashSale)
+		apiGroup.GET("/promotions", api.GetPromotions)
+		apiGroup.GET("/raffles", api.GetRaffles)
+		apiGroup.GET("/raffles/:id", api.GetRaffle)
+		apiGroup.GET("/raffles/:id/results", api.GetRaffleResults)
 
-		// 需要认证的路由
-		authGroup := apiGroup.Group("/", middleware.AuthMiddleware())
+		// 游客购物车（通过签名的 X-Cart-Token 请求头或 cart_token Cookie 识别，登录时合并到用户购物车）
+		guestCartGroup := apiGroup.Group("/guest/cart", middleware.GuestCart())
 		{
+			guestCartGroup.GET("", api.GetGuestCart)
+			guestCartGroup.POST("", api.AddToGuestCart)
+			guestCartGroup.PATCH("/:id/increment", api.IncrementGuestCartItem)
+			guestCartGroup.PUT("/:id", api.UpdateGuestCartItem)
+			guestCartGroup.DELETE("/:id", api.DeleteGuestCartItem)
+		}
+
+		// 支付渠道回调（通过签名认证）
+		apiGroup.POST("/payments/webhook/:provider", api.PaymentWebhook)
+
+		// 需要认证的路由，写请求支持 Idempotency-Key
+		authGroup := apiGroup.Group("/", middleware.AuthMiddleware(), middleware.Idempotency())
+		{
+			// 会话
+			authGroup.POST("/logout", api.Logout)
+			authGroup.GET("/sessions", api.GetSessions)
+			authGroup.DELETE("/sessions", api.RevokeAllSessions)
+			authGroup.DELETE("/sessions/:id", api.RevokeSession)
+
 			// 购物车
 			authGroup.GET("/cart", api.GetCart)
+			authGroup.GET("/cart/summary", api.GetCartSummary)
 			authGroup.POST("/cart", api.AddToCart)
 			authGroup.PATCH("/cart/:id/increment", api.IncrementCartItem) // 增量更新（+1/-1）
 			authGroup.PUT("/cart/:id", api.UpdateCartItem)
 			authGroup.DELETE("/cart/:id", api.DeleteCartItem)
+			authGroup.POST("/cart/bundles", api.AddBundleToCart)
+			authGroup.PUT("/cart/bundles/:id", api.UpdateCartBundle)
+			authGroup.DELETE("/cart/bundles/:id", api.DeleteCartBundle)
 
 			// 订单
 			authGroup.POST("/orders", api.CreateOrder)
 			authGroup.GET("/orders", api.GetOrders)
 			authGroup.GET("/orders/:id", api.GetOrder)
+			authGroup.POST("/orders/:id/cancel", api.CancelOrder)
+			authGroup.POST("/orders/:id/confirm-receipt", api.ConfirmReceipt)
+			authGroup.POST("/orders/:id/pay", api.PayOrder)
+
+			// 盲盒抽取记录
+			authGroup.GET("/draws", api.GetMyDraws)
+
+			// 系列收藏进度
+			authGroup.GET("/collection", api.GetCollection)
+			authGroup.GET("/collection/:series", api.GetCollectionSeries)
+
+			// 限时抢购（异步下单，轮询票据获取结果）
+			authGroup.POST("/flash-sales/:id/purchase", api.PurchaseFlashSale)
+			authGroup.GET("/flash-sales/tickets/:ticket", api.GetFlashSaleTicket)
+
+			// 抽签发售（报名、查看中签结果、中签后限时购买）
+			authGroup.POST("/raffles/:id/enter", api.EnterRaffle)
+			authGroup.GET("/raffles/:id/entry", api.GetMyRaffleEntry)
+			authGroup.POST("/raffles/:id/checkout", api.CheckoutRaffle)
+
+			// 库存物品和交换
+			authGroup.GET("/inventory", api.GetInventory)
+			authGroup.POST("/inventory/:id/list", api.ListInventoryItem)
+			authGroup.DELETE("/inventory/:id/list", api.UnlistInventoryItem)
+			authGroup.GET("/trades/listings", api.GetTradeListings)
+			authGroup.POST("/trades", api.CreateTradeOffer)
+			authGroup.GET("/trades", api.GetTradeOffers)
+			authGroup.GET("/trades/:id", api.GetTradeOffer)
+			authGroup.POST("/trades/:id/accept", api.AcceptTradeOffer)
+			authGroup.POST("/trades/:id/reject", api.RejectTradeOffer)
+			authGroup.POST("/trades/:id/cancel", api.CancelTradeOffer)
+
+			// 模拟支付渠道收银台
+			authGroup.POST("/payments/mock/:ref/complete", api.CompleteMockPayment)
+		}
+
+		// 管理后台路由（运营人员和管理员）
+		adminGroup := apiGroup.Group("/admin", middleware.AuthMiddleware(), middleware.RequireRole(model.RoleOperator, model.RoleAdmin), middleware.Idempotency())
+		{
+			// 用户管理
+			adminGroup.GET("/users", api.AdminGetUsers)
+			adminGroup.PUT("/users/:id/role", middleware.RequireRole(model.RoleAdmin), api.AdminUpdateUserRole)
+			adminGroup.DELETE("/users/:id/sessions", api.AdminRevokeUserSessions)
+
+			// 商品管理
+			adminGroup.GET("/products", api.AdminGetProducts)
+			adminGroup.POST("/products", api.AdminCreateProduct)
+			adminGroup.PUT("/products/:id", api.AdminUpdateProduct)
+			adminGroup.DELETE("/products/:id", api.AdminDeleteProduct)
+			adminGroup.POST("/products/:id/stock", api.AdminAdjustStock)
+			adminGroup.GET("/products/:id/stock", api.AdminGetStockAdjustments)
+
+			// 订单管理
+			adminGroup.GET("/orders", api.AdminGetOrders)
+			adminGroup.GET("/orders/:id", api.AdminGetOrder)
+			adminGroup.POST("/orders/:id/ship", api.AdminShipOrder)
+			adminGroup.POST("/orders/:id/deliver", api.AdminDeliverOrder)
+			adminGroup.POST("/orders/:id/refund", api.AdminRefundOrder)
+			adminGroup.POST("/orders/:id/draw", api.AdminDrawOrder)
+
+			// 盲盒管理
+			adminGroup.GET("/blind-boxes", api.AdminGetBlindBoxSeriesList)
+			adminGroup.POST("/blind-boxes", api.AdminCreateBlindBoxSeries)
+			adminGroup.GET("/blind-boxes/:id", api.AdminGetBlindBoxSeries)
+			adminGroup.POST("/blind-boxes/:id/figures", api.AdminAddBlindBoxFigure)
+			adminGroup.PUT("/blind-box-figures/:id", api.AdminUpdateBlindBoxFigure)
+			adminGroup.POST("/blind-box-figures/:id/stock", api.AdminAdjustBlindBoxFigureStock)
+
+			// 套装管理
+			adminGroup.GET("/bundles", api.AdminGetBundles)
+			adminGroup.POST("/bundles", api.AdminCreateBundle)
+			adminGroup.PUT("/bundles/:id", api.AdminUpdateBundle)
+			adminGroup.DELETE("/bundles/:id", api.AdminDeleteBundle)
+
+			// 限时抢购管理
+			adminGroup.GET("/flash-sales", api.AdminGetFlashSales)
+			adminGroup.POST("/flash-sales", api.AdminCreateFlashSale)
+			adminGroup.POST("/flash-sales/:id/cancel", api.AdminCancelFlashSale)
+
+			// 促销和优惠码管理
+			adminGroup.GET("/promotions", api.AdminGetPromotions)
+			adminGroup.POST("/promotions", api.AdminCreatePromotion)
+			adminGroup.GET("/promotions/:id", api.AdminGetPromotion)
+			adminGroup.PUT("/promotions/:id", api.AdminUpdatePromotion)
+			adminGroup.GET("/coupons", api.AdminGetCoupons)
+			adminGroup.POST("/coupons", api.AdminCreateCoupon)
+			adminGroup.PUT("/coupons/:id", api.AdminUpdateCoupon)
+
+			// 抽签管理
+			adminGroup.GET("/raffles", api.AdminGetRaffles)
+			adminGroup.POST("/raffles", api.AdminCreateRaffle)
+			adminGroup.GET("/raffles/:id", api.AdminGetRaffle)
+			adminGroup.POST("/raffles/:id/draw", api.AdminDrawRaffle)
+			adminGroup.POST("/raffles/:id/cancel", api.AdminCancelRaffle)
 		}
 	}
 